@@ -0,0 +1,896 @@
+package aoscxgo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/exp/slices"
+)
+
+// Acl is an IPv4, IPv6 or MAC access control list with its ordered entries.
+// Update replaces all entries in a single request, so the switch never
+// applies a partially updated list.
+type Acl struct {
+
+	// Connection properties.
+	Name string `json:"name"`
+	// Type is "ipv4", "ipv6" or "mac".
+	Type string `json:"type"`
+	// Entries are applied in order of their Sequence.
+	Entries []AclEntry `json:"entries"`
+
+	AclDetails   map[string]interface{} `json:"details"`
+	materialized bool
+	uri          string
+}
+
+// AclEntry is an entry (ACE) of an Acl. Empty match fields match any value.
+type AclEntry struct {
+	// Sequence orders the entries, 1-4294967295.
+	Sequence int64 `json:"sequence"`
+	// Action is "permit" or "deny".
+	Action string `json:"action"`
+	// Protocol is a name such as "tcp", "udp" or "icmp" or an IP protocol
+	// number, any protocol if empty. Not used by MAC ACLs.
+	Protocol string `json:"protocol"`
+	// Source and Destination are a prefix or host address of the type of
+	// the ACL, e.g. "10.0.0.0/24", or a MAC address for MAC ACLs.
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
+	// SourcePort and DestinationPort are a TCP, UDP or SCTP port or port
+	// range, e.g. "443" or "1024-65535".
+	SourcePort      string `json:"source_port"`
+	DestinationPort string `json:"destination_port"`
+	// SourceGroup and DestinationGroup name an AddressGroup of the type of
+	// the ACL to match instead of Source and Destination.
+	SourceGroup      string `json:"source_group"`
+	DestinationGroup string `json:"destination_group"`
+	// SourcePortGroup and DestinationPortGroup name a PortGroup to match
+	// instead of SourcePort and DestinationPort.
+	SourcePortGroup      string `json:"source_port_group"`
+	DestinationPortGroup string `json:"destination_port_group"`
+	// Dscp is a DSCP value 0-63 or name such as "EF" or "AF41".
+	Dscp string `json:"dscp"`
+	// Ethertype is matched by MAC ACLs, any if zero.
+	Ethertype int    `json:"ethertype"`
+	Log       bool   `json:"log"`
+	Count     bool   `json:"count"`
+	Comment   string `json:"comment"`
+}
+
+// ip_protocols maps protocol names to IP protocol numbers.
+var ip_protocols = map[string]int{
+	"icmp":   1,
+	"igmp":   2,
+	"tcp":    6,
+	"udp":    17,
+	"gre":    47,
+	"esp":    50,
+	"ah":     51,
+	"icmpv6": 58,
+	"ospf":   89,
+	"pim":    103,
+	"vrrp":   112,
+	"sctp":   132,
+}
+
+// dscp_names maps DSCP names to their values.
+var dscp_names = map[string]int{
+	"CS0": 0, "CS1": 8, "CS2": 16, "CS3": 24, "CS4": 32, "CS5": 40, "CS6": 48, "CS7": 56,
+	"AF11": 10, "AF12": 12, "AF13": 14, "AF21": 18, "AF22": 20, "AF23": 22,
+	"AF31": 26, "AF32": 28, "AF33": 30, "AF41": 34, "AF42": 36, "AF43": 38,
+	"EF": 46,
+}
+
+// parseDscp returns the value of a DSCP given as number 0-63 or name.
+func parseDscp(dscp string) (int, bool) {
+	if value, ok := dscp_names[strings.ToUpper(dscp)]; ok {
+		return value, true
+	}
+	value, err := strconv.Atoi(dscp)
+	if err != nil || value < 0 || value > 63 {
+		return 0, false
+	}
+	return value, true
+}
+
+// parsePortRange returns the bounds of a port or port range such as
+// "1024-65535".
+func parsePortRange(ports string) (int, int, bool) {
+	min_str, max_str, is_range := strings.Cut(ports, "-")
+	if !is_range {
+		max_str = min_str
+	}
+	min, err := strconv.Atoi(strings.TrimSpace(min_str))
+	if err != nil || min < 0 || min > 65535 {
+		return 0, 0, false
+	}
+	max, err := strconv.Atoi(strings.TrimSpace(max_str))
+	if err != nil || max < min || max > 65535 {
+		return 0, 0, false
+	}
+	return min, max, true
+}
+
+// formatPortRange returns the port or port range of the given bounds.
+func formatPortRange(min int, max int) string {
+	if min == max {
+		return strconv.Itoa(min)
+	}
+	return strconv.Itoa(min) + "-" + strconv.Itoa(max)
+}
+
+// aclAddress returns the canonical prefix of an address or prefix of the
+// given family, "ipv4" or "ipv6", host addresses as full length prefix.
+func aclAddress(address string, family string) (string, bool) {
+	if !strings.Contains(address, "/") {
+		if family == "ipv4" {
+			address += "/32"
+		} else {
+			address += "/128"
+		}
+	}
+	_, network, err := net.ParseCIDR(address)
+	if err != nil || (network.IP.To4() != nil) != (family == "ipv4") {
+		return "", false
+	}
+	return network.String(), true
+}
+
+// aclAddressMask returns a prefix as address and mask, as used by the
+// switch, e.g. "10.0.0.0/255.255.255.0".
+func aclAddressMask(prefix string) string {
+	_, network, _ := net.ParseCIDR(prefix)
+	return network.IP.String() + "/" + net.IP(network.Mask).String()
+}
+
+// aclPrefix returns an address and mask as prefix, e.g. "10.0.0.0/24".
+func aclPrefix(address_mask string) string {
+	address, mask, _ := strings.Cut(address_mask, "/")
+	ip := net.ParseIP(address)
+	mask_ip := net.ParseIP(mask)
+	if ip == nil || mask_ip == nil {
+		return address_mask
+	}
+	if ip.To4() != nil {
+		ip = ip.To4()
+		mask_ip = mask_ip.To4()
+	}
+	ones, _ := net.IPMask(mask_ip).Size()
+	return (&net.IPNet{IP: ip, Mask: net.CIDRMask(ones, len(ip)*8)}).String()
+}
+
+// checkValues validates the ACL and normalizes its entries as returned by
+// Get, sorted by Sequence. op names the calling operation in the returned
+// error.
+func (a *Acl) checkValues(op string) error {
+	if a.Name == "" || strings.ContainsAny(a.Name, ",/") {
+		return newValidationError(op, "Name", "invalid ACL name: "+a.Name)
+	}
+	if !slices.Contains([]string{"ipv4", "ipv6", "mac"}, a.Type) {
+		return newValidationError(op, "Type", "valid options are 'ipv4', 'ipv6' or 'mac' received: "+a.Type)
+	}
+
+	return checkAclEntries(op, a.Type, a.Entries, []string{"permit", "deny"})
+}
+
+// checkAclEntries validates entries of an ACL or class of the given type,
+// "ipv4", "ipv6" or "mac", with the given actions and normalizes them as
+// returned by Get, sorted by Sequence. op names the calling operation in the
+// returned error.
+func checkAclEntries(op string, list_type string, entries []AclEntry, actions []string) error {
+	seen := map[int64]bool{}
+	for index := range entries {
+		entry := &entries[index]
+		sequence_str := strconv.FormatInt(entry.Sequence, 10)
+
+		if entry.Sequence < 1 || entry.Sequence > 4294967295 {
+			return newValidationError(op, "Sequence", "valid range is 1-4294967295 received: "+sequence_str)
+		}
+		if seen[entry.Sequence] {
+			return newValidationError(op, "Sequence", "duplicate sequence "+sequence_str)
+		}
+		seen[entry.Sequence] = true
+
+		if !slices.Contains(actions, entry.Action) {
+			return newValidationError(op, "Action", "valid options are '"+strings.Join(actions, "' or '")+"' received: "+entry.Action+" in entry "+sequence_str)
+		}
+
+		if list_type == "mac" {
+			if entry.Protocol != "" || entry.SourcePort != "" || entry.DestinationPort != "" || entry.Dscp != "" ||
+				entry.SourceGroup != "" || entry.DestinationGroup != "" || entry.SourcePortGroup != "" || entry.DestinationPortGroup != "" {
+				return newValidationError(op, "Entries", "MAC ACL entry "+sequence_str+" only matches addresses and Ethertype")
+			}
+			for _, address := range []*string{&entry.Source, &entry.Destination} {
+				if *address == "" {
+					continue
+				}
+				mac, err := net.ParseMAC(*address)
+				if err != nil {
+					return newValidationError(op, "Entries", "invalid MAC address "+*address+" in entry "+sequence_str)
+				}
+				*address = mac.String()
+			}
+			if entry.Ethertype < 0 || entry.Ethertype > 0xffff {
+				return newValidationError(op, "Ethertype", "valid range is 0-0xffff in entry "+sequence_str)
+			}
+			continue
+		}
+
+		if entry.Ethertype != 0 {
+			return newValidationError(op, "Ethertype", "only matched by MAC ACLs, entry "+sequence_str)
+		}
+		if entry.Protocol != "" {
+			if _, ok := ip_protocols[entry.Protocol]; !ok {
+				number, err := strconv.Atoi(entry.Protocol)
+				if err != nil || number < 0 || number > 255 {
+					return newValidationError(op, "Protocol", "invalid protocol "+entry.Protocol+" in entry "+sequence_str)
+				}
+			}
+		}
+		for _, address := range []*string{&entry.Source, &entry.Destination} {
+			if *address == "" || *address == "any" {
+				*address = ""
+				continue
+			}
+			prefix, ok := aclAddress(*address, list_type)
+			if !ok {
+				return newValidationError(op, "Entries", "invalid "+list_type+" address "+*address+" in entry "+sequence_str)
+			}
+			*address = prefix
+		}
+		if (entry.Source != "" && entry.SourceGroup != "") || (entry.Destination != "" && entry.DestinationGroup != "") {
+			return newValidationError(op, "Entries", "address and address group are exclusive in entry "+sequence_str)
+		}
+		if (entry.SourcePort != "" && entry.SourcePortGroup != "") || (entry.DestinationPort != "" && entry.DestinationPortGroup != "") {
+			return newValidationError(op, "Entries", "ports and port group are exclusive in entry "+sequence_str)
+		}
+		is_l4 := slices.Contains([]string{"tcp", "udp", "sctp", "6", "17", "132"}, entry.Protocol)
+		if !is_l4 && (entry.SourcePortGroup != "" || entry.DestinationPortGroup != "") {
+			return newValidationError(op, "Entries", "port groups require protocol tcp, udp or sctp in entry "+sequence_str)
+		}
+		for _, ports := range []*string{&entry.SourcePort, &entry.DestinationPort} {
+			if *ports == "" {
+				continue
+			}
+			if !is_l4 {
+				return newValidationError(op, "Entries", "ports require protocol tcp, udp or sctp in entry "+sequence_str)
+			}
+			min, max, ok := parsePortRange(*ports)
+			if !ok {
+				return newValidationError(op, "Entries", "invalid port range "+*ports+" in entry "+sequence_str)
+			}
+			*ports = formatPortRange(min, max)
+		}
+		if entry.Dscp != "" {
+			value, ok := parseDscp(entry.Dscp)
+			if !ok {
+				return newValidationError(op, "Dscp", "invalid DSCP "+entry.Dscp+" in entry "+sequence_str)
+			}
+			entry.Dscp = strconv.Itoa(value)
+		}
+	}
+
+	sort.Slice(entries, func(x, y int) bool {
+		return entries[x].Sequence < entries[y].Sequence
+	})
+	return nil
+}
+
+// checkAclGroups verifies the address and port groups referenced by entries
+// of an ACL or class of the given type exist on the given Client object, so
+// they are not pushed with dangling references. A missing group is reported
+// as *DependencyError, other errors are returned unchanged. op names the
+// calling operation in the returned error.
+func checkAclGroups(ctx context.Context, c *Client, op string, list_type string, entries []AclEntry) error {
+	resource, _, _ := strings.Cut(op, ".")
+	checked := map[string]bool{}
+	for _, entry := range entries {
+		for _, name := range []string{entry.SourceGroup, entry.DestinationGroup} {
+			if name == "" || checked["address:"+name] {
+				continue
+			}
+			group := AddressGroup{Name: name, Type: list_type}
+			err := group.Get(ctx, c)
+			if errors.Is(err, ErrNotFound) {
+				return newDependencyError(op, "missing "+list_type+" AddressGroup "+name+" - Create AddressGroup before "+resource, err)
+			}
+			if err != nil {
+				return err
+			}
+			checked["address:"+name] = true
+		}
+		for _, name := range []string{entry.SourcePortGroup, entry.DestinationPortGroup} {
+			if name == "" || checked["port:"+name] {
+				continue
+			}
+			group := PortGroup{Name: name}
+			err := group.Get(ctx, c)
+			if errors.Is(err, ErrNotFound) {
+				return newDependencyError(op, "missing PortGroup "+name+" - Create PortGroup before "+resource, err)
+			}
+			if err != nil {
+				return err
+			}
+			checked["port:"+name] = true
+		}
+	}
+	return nil
+}
+
+// aclEntriesMap returns entries of an ACL or class of the given type as sent
+// to the switch, keyed by sequence, with the action in action_key. Groups
+// are referenced by their URI for the REST version of c.
+func aclEntriesMap(c *Client, list_type string, entries []AclEntry, action_key string) map[string]interface{} {
+	groups_uri := "/rest/" + c.Version + "/system/acl_object_groups/"
+	aces := map[string]interface{}{}
+	for _, entry := range entries {
+		ace := map[string]interface{}{
+			action_key: entry.Action,
+		}
+		if entry.Log {
+			ace["log"] = true
+		}
+		if entry.Count {
+			ace["count"] = true
+		}
+		if entry.Comment != "" {
+			ace["comment"] = entry.Comment
+		}
+
+		if list_type == "mac" {
+			if entry.Source != "" {
+				ace["src_mac"] = entry.Source
+			}
+			if entry.Destination != "" {
+				ace["dst_mac"] = entry.Destination
+			}
+			if entry.Ethertype != 0 {
+				ace["ethertype"] = entry.Ethertype
+			}
+			aces[strconv.FormatInt(entry.Sequence, 10)] = ace
+			continue
+		}
+
+		if entry.Protocol != "" {
+			if number, ok := ip_protocols[entry.Protocol]; ok {
+				ace["protocol"] = number
+			} else {
+				ace["protocol"], _ = strconv.Atoi(entry.Protocol)
+			}
+		}
+		if entry.Source != "" {
+			ace["src_ip"] = aclAddressMask(entry.Source)
+		}
+		if entry.Destination != "" {
+			ace["dst_ip"] = aclAddressMask(entry.Destination)
+		}
+		if entry.SourceGroup != "" {
+			ace["src_ip_group"] = groups_uri + objectGroupKey(entry.SourceGroup, list_type)
+		}
+		if entry.DestinationGroup != "" {
+			ace["dst_ip_group"] = groups_uri + objectGroupKey(entry.DestinationGroup, list_type)
+		}
+		if entry.SourcePortGroup != "" {
+			ace["src_l4_port_group"] = groups_uri + objectGroupKey(entry.SourcePortGroup, "port")
+		}
+		if entry.DestinationPortGroup != "" {
+			ace["dst_l4_port_group"] = groups_uri + objectGroupKey(entry.DestinationPortGroup, "port")
+		}
+		if entry.SourcePort != "" {
+			ace["src_l4_port_min"], ace["src_l4_port_max"], _ = parsePortRange(entry.SourcePort)
+		}
+		if entry.DestinationPort != "" {
+			ace["dst_l4_port_min"], ace["dst_l4_port_max"], _ = parsePortRange(entry.DestinationPort)
+		}
+		if entry.Dscp != "" {
+			ace["dscp"], _ = parseDscp(entry.Dscp)
+		}
+		aces[strconv.FormatInt(entry.Sequence, 10)] = ace
+	}
+	return aces
+}
+
+// aclKey returns the escaped key of the ACL, "name,type".
+func (a *Acl) aclKey() string {
+	return url.PathEscape(a.Name) + "," + a.Type
+}
+
+// Create performs POST to create the ACL with its entries on the given
+// Client object.
+func (a *Acl) Create(ctx context.Context, c *Client) error {
+	base_uri := "system/acls"
+
+	err := a.checkValues("Acl.Create")
+	if err != nil {
+		return err
+	}
+
+	err = checkAclGroups(ctx, c, "Acl.Create", a.Type, a.Entries)
+	if err != nil {
+		return err
+	}
+
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri
+	a.uri = "/rest/" + c.Version + "/" + base_uri + "/" + a.aclKey()
+
+	postMap := map[string]interface{}{
+		"name":        a.Name,
+		"list_type":   a.Type,
+		"cfg_aces":    aclEntriesMap(c, a.Type, a.Entries, "action"),
+		"cfg_version": nextCfgVersion(),
+	}
+
+	postBody, _ := json.Marshal(postMap)
+
+	json_body := bytes.NewBuffer(postBody)
+
+	res, err := post(ctx, c, url, json_body)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusCreated {
+		return newRequestError("Acl.Create", res)
+	}
+
+	a.materialized = true
+
+	return nil
+}
+
+// Update performs PUT to replace all entries of the ACL on the given Client
+// object at once, so reordered or replaced entries never apply partially.
+func (a *Acl) Update(ctx context.Context, c *Client) error {
+	base_uri := "system/acls"
+
+	err := a.checkValues("Acl.Update")
+	if err != nil {
+		return err
+	}
+
+	err = checkAclGroups(ctx, c, "Acl.Update", a.Type, a.Entries)
+	if err != nil {
+		return err
+	}
+
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri + "/" + a.aclKey()
+
+	putMap := map[string]interface{}{
+		"cfg_aces":    aclEntriesMap(c, a.Type, a.Entries, "action"),
+		"cfg_version": nextCfgVersion(),
+	}
+
+	putBody, _ := json.Marshal(putMap)
+
+	json_body := bytes.NewBuffer(putBody)
+
+	res, err := put(ctx, c, url, json_body)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return newRequestError("Acl.Update", res)
+	}
+
+	return nil
+}
+
+// Delete performs DELETE to remove the ACL from the given Client object.
+// The switch refuses to delete an ACL that is applied.
+func (a *Acl) Delete(ctx context.Context, c *Client) error {
+	base_uri := "system/acls"
+
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri + "/" + a.aclKey()
+
+	res, err := delete(ctx, c, url)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusNoContent && res.StatusCode != http.StatusOK {
+		return newRequestError("Acl.Delete", res)
+	}
+
+	a.materialized = false
+
+	return nil
+}
+
+// Get performs GET to retrieve the ACL with its entries from the given
+// Client object.
+func (a *Acl) Get(ctx context.Context, c *Client) error {
+	base_uri := "system/acls"
+	a.uri = "/rest/" + c.Version + "/" + base_uri + "/" + a.aclKey()
+
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri + "/" + a.aclKey() + "?depth=2"
+
+	res, body, err := get(ctx, c, url)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		a.materialized = false
+		return newRequestError("Acl.Get", res)
+	}
+
+	payload := aclPayload{}
+	err = json.NewDecoder(res.Body).Decode(&payload)
+	if err != nil {
+		return fmt.Errorf("Acl.Get: decoding %s: %w", a.Name, err)
+	}
+	payload.decode(a)
+
+	if a.AclDetails == nil {
+		a.AclDetails = map[string]interface{}{}
+	}
+	for key, value := range body {
+		a.AclDetails[key] = value
+	}
+
+	a.materialized = true
+
+	return nil
+}
+
+// GetStatus returns True if the ACL exists on Client object or False if not.
+func (a *Acl) GetStatus() bool {
+	return a.materialized
+}
+
+// GetURI returns URI of the ACL.
+func (a *Acl) GetURI() string {
+	return a.uri
+}
+
+// acePayload is an entry of an ACL or class as returned by the switch.
+// Classes hold the action in Type.
+type acePayload struct {
+	Action       string  `json:"action"`
+	Type         string  `json:"type"`
+	Protocol     *int    `json:"protocol"`
+	SrcIp        *string `json:"src_ip"`
+	DstIp        *string `json:"dst_ip"`
+	SrcMac       *string `json:"src_mac"`
+	DstMac       *string `json:"dst_mac"`
+	SrcL4PortMin *int    `json:"src_l4_port_min"`
+	SrcL4PortMax *int    `json:"src_l4_port_max"`
+	DstL4PortMin *int    `json:"dst_l4_port_min"`
+	DstL4PortMax *int    `json:"dst_l4_port_max"`
+	SrcIpGroup   *string `json:"src_ip_group"`
+	DstIpGroup   *string `json:"dst_ip_group"`
+	SrcPortGroup *string `json:"src_l4_port_group"`
+	DstPortGroup *string `json:"dst_l4_port_group"`
+	Dscp         *int    `json:"dscp"`
+	Ethertype    int     `json:"ethertype"`
+	Log          bool    `json:"log"`
+	Count        bool    `json:"count"`
+	Comment      string  `json:"comment"`
+}
+
+// aclPayload is the ACL as returned by the switch.
+type aclPayload struct {
+	ListType string                `json:"list_type"`
+	CfgAces  map[string]acePayload `json:"cfg_aces"`
+}
+
+// decode sets the entries of a from the payload, sorted by Sequence.
+func (p *aclPayload) decode(a *Acl) {
+	if p.ListType != "" {
+		a.Type = p.ListType
+	}
+
+	a.Entries = decodeAclEntries(p.CfgAces)
+}
+
+// decodeAclEntries returns the entries of an ACL or class as returned by the
+// switch, sorted by Sequence.
+func decodeAclEntries(aces map[string]acePayload) []AclEntry {
+	entries := []AclEntry{}
+	for sequence_str, ace := range aces {
+		entry := AclEntry{
+			Action:    ace.Action,
+			Ethertype: ace.Ethertype,
+			Log:       ace.Log,
+			Count:     ace.Count,
+			Comment:   ace.Comment,
+		}
+		entry.Sequence, _ = strconv.ParseInt(sequence_str, 10, 64)
+
+		if ace.Protocol != nil {
+			entry.Protocol = strconv.Itoa(*ace.Protocol)
+			for name, number := range ip_protocols {
+				if number == *ace.Protocol {
+					entry.Protocol = name
+				}
+			}
+		}
+		if ace.SrcIp != nil {
+			entry.Source = aclPrefix(*ace.SrcIp)
+		}
+		if ace.DstIp != nil {
+			entry.Destination = aclPrefix(*ace.DstIp)
+		}
+		if ace.SrcMac != nil {
+			entry.Source = *ace.SrcMac
+		}
+		if ace.DstMac != nil {
+			entry.Destination = *ace.DstMac
+		}
+		if ace.SrcL4PortMin != nil && ace.SrcL4PortMax != nil {
+			entry.SourcePort = formatPortRange(*ace.SrcL4PortMin, *ace.SrcL4PortMax)
+		}
+		if ace.DstL4PortMin != nil && ace.DstL4PortMax != nil {
+			entry.DestinationPort = formatPortRange(*ace.DstL4PortMin, *ace.DstL4PortMax)
+		}
+		if ace.SrcIpGroup != nil {
+			entry.SourceGroup = objectGroupName(*ace.SrcIpGroup)
+		}
+		if ace.DstIpGroup != nil {
+			entry.DestinationGroup = objectGroupName(*ace.DstIpGroup)
+		}
+		if ace.SrcPortGroup != nil {
+			entry.SourcePortGroup = objectGroupName(*ace.SrcPortGroup)
+		}
+		if ace.DstPortGroup != nil {
+			entry.DestinationPortGroup = objectGroupName(*ace.DstPortGroup)
+		}
+		if ace.Dscp != nil {
+			entry.Dscp = strconv.Itoa(*ace.Dscp)
+		}
+		if entry.Action == "" {
+			entry.Action = ace.Type
+		}
+
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(x, y int) bool {
+		return entries[x].Sequence < entries[y].Sequence
+	})
+	return entries
+}
+
+// ListAcls performs GET to retrieve all ACLs with their entries from the
+// given Client object in a single request, sorted by name and type.
+func ListAcls(ctx context.Context, c *Client) ([]Acl, error) {
+	base_uri := "system/acls"
+	url_str := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri + "?depth=2&attributes=name,list_type,cfg_aces"
+
+	res, _, err := get(ctx, c, url_str)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, newRequestError("ListAcls", res)
+	}
+
+	payloads := map[string]aclPayload{}
+	err = json.NewDecoder(res.Body).Decode(&payloads)
+	if err != nil {
+		return nil, fmt.Errorf("ListAcls: decoding ACLs: %w", err)
+	}
+
+	acls := []Acl{}
+	for key, payload := range payloads {
+		if unescaped, err := url.PathUnescape(key); err == nil {
+			key = unescaped
+		}
+		name, acl_type, _ := strings.Cut(key, ",")
+		a := Acl{
+			Name:         name,
+			Type:         acl_type,
+			materialized: true,
+		}
+		payload.decode(&a)
+		a.uri = "/rest/" + c.Version + "/" + base_uri + "/" + a.aclKey()
+		acls = append(acls, a)
+	}
+
+	sort.Slice(acls, func(x, y int) bool {
+		if acls[x].Name != acls[y].Name {
+			return acls[x].Name < acls[y].Name
+		}
+		return acls[x].Type < acls[y].Type
+	})
+
+	return acls, nil
+}
+
+// aclAttribute returns the attribute applying an ACL of the given type in
+// the given direction, "in" or "out". Routed ACLs apply to the traffic
+// routed by a VlanInterface.
+func aclAttribute(op string, acl_type string, direction string, routed bool) (string, error) {
+	prefix := map[string]string{"ipv4": "aclv4", "ipv6": "aclv6", "mac": "aclmac"}[acl_type]
+	if prefix == "" {
+		return "", newValidationError(op, "Type", "valid options are 'ipv4', 'ipv6' or 'mac' received: "+acl_type)
+	}
+	if direction != "in" && direction != "out" {
+		return "", newValidationError(op, "direction", "valid options are 'in' or 'out' received: "+direction)
+	}
+	if routed {
+		if acl_type == "mac" {
+			return "", newValidationError(op, "Type", "MAC ACLs cannot be applied to a VlanInterface")
+		}
+		prefix += "_routed"
+	}
+	return prefix + "_" + direction + "_cfg", nil
+}
+
+// applyAcl performs PATCH to set or, if acl is nil, clear the ACL applied
+// by attribute on the row at url.
+func applyAcl(ctx context.Context, c *Client, op string, url string, attribute string, acl *Acl) error {
+	uri := ""
+	if acl != nil {
+		// Check a copy, Get would replace the entries of the caller
+		current := Acl{Name: acl.Name, Type: acl.Type}
+		err := current.Get(ctx, c)
+		if errors.Is(err, ErrNotFound) {
+			return newDependencyError(op, "missing ACL "+acl.Name+" - Create Acl first", err)
+		}
+		if err != nil {
+			return err
+		}
+		uri = current.GetURI()
+	}
+
+	return patchCfgReference(ctx, c, op, url, attribute, uri)
+}
+
+// last_cfg_version is the last version returned by nextCfgVersion.
+var last_cfg_version atomic.Int64
+
+// nextCfgVersion returns the version to send with a changed ACL, class,
+// policy or reference to one, which the switch only applies if it differs
+// from the current one. It is the time in nanoseconds, increased as needed
+// so that changes made within the same clock tick get distinct, ascending
+// versions.
+func nextCfgVersion() int64 {
+	for {
+		last := last_cfg_version.Load()
+		next := time.Now().UnixNano()
+		if next <= last {
+			next = last + 1
+		}
+		if last_cfg_version.CompareAndSwap(last, next) {
+			return next
+		}
+	}
+}
+
+// patchCfgReference performs PATCH to set the reference attribute of the row
+// at url to uri or, if uri is empty, clear it, bumping its version so the
+// switch applies the change.
+func patchCfgReference(ctx context.Context, c *Client, op string, url string, attribute string, uri string) error {
+	patchMap := map[string]interface{}{
+		attribute:              nil,
+		attribute + "_version": nextCfgVersion(),
+	}
+	if uri != "" {
+		patchMap[attribute] = uri
+	}
+
+	patchBody, _ := json.Marshal(patchMap)
+
+	json_body := bytes.NewBuffer(patchBody)
+
+	res, err := patch(ctx, c, url, json_body)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusNoContent {
+		return newRequestError(op, res)
+	}
+
+	return nil
+}
+
+// ApplyAcl performs PATCH to apply the ACL to the traffic received, direction
+// "in", or sent, direction "out", by the Interface on the given Client
+// object. It replaces any ACL of the same type applied in that direction.
+func (i *Interface) ApplyAcl(ctx context.Context, c *Client, acl *Acl, direction string) error {
+	if acl == nil {
+		return newValidationError("Interface.ApplyAcl", "Acl", "missing Acl unable to apply it")
+	}
+	attribute, err := aclAttribute("Interface.ApplyAcl", acl.Type, direction, false)
+	if err != nil {
+		return err
+	}
+
+	int_str := url.PathEscape(i.Name)
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/system/interfaces/" + int_str
+
+	return applyAcl(ctx, c, "Interface.ApplyAcl", url, attribute, acl)
+}
+
+// UnapplyAcl performs PATCH to remove the ACL of the given type, "ipv4",
+// "ipv6" or "mac", applied in direction "in" or "out" from the Interface on
+// the given Client object.
+func (i *Interface) UnapplyAcl(ctx context.Context, c *Client, acl_type string, direction string) error {
+	attribute, err := aclAttribute("Interface.UnapplyAcl", acl_type, direction, false)
+	if err != nil {
+		return err
+	}
+
+	int_str := url.PathEscape(i.Name)
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/system/interfaces/" + int_str
+
+	return applyAcl(ctx, c, "Interface.UnapplyAcl", url, attribute, nil)
+}
+
+// ApplyAcl performs PATCH to apply the ACL to the traffic of the VLAN in
+// direction "in" or "out" on the given Client object. It replaces any ACL
+// of the same type applied in that direction.
+func (v *Vlan) ApplyAcl(ctx context.Context, c *Client, acl *Acl, direction string) error {
+	if acl == nil {
+		return newValidationError("Vlan.ApplyAcl", "Acl", "missing Acl unable to apply it")
+	}
+	attribute, err := aclAttribute("Vlan.ApplyAcl", acl.Type, direction, false)
+	if err != nil {
+		return err
+	}
+
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/system/vlans/" + strconv.Itoa(v.VlanId)
+
+	return applyAcl(ctx, c, "Vlan.ApplyAcl", url, attribute, acl)
+}
+
+// UnapplyAcl performs PATCH to remove the ACL of the given type, "ipv4",
+// "ipv6" or "mac", applied in direction "in" or "out" from the VLAN on the
+// given Client object.
+func (v *Vlan) UnapplyAcl(ctx context.Context, c *Client, acl_type string, direction string) error {
+	attribute, err := aclAttribute("Vlan.UnapplyAcl", acl_type, direction, false)
+	if err != nil {
+		return err
+	}
+
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/system/vlans/" + strconv.Itoa(v.VlanId)
+
+	return applyAcl(ctx, c, "Vlan.UnapplyAcl", url, attribute, nil)
+}
+
+// ApplyAcl performs PATCH to apply the IPv4 or IPv6 ACL to the traffic
+// routed by the VlanInterface in direction "in" or "out" on the given Client
+// object. It replaces any ACL of the same type applied in that direction.
+func (v *VlanInterface) ApplyAcl(ctx context.Context, c *Client, acl *Acl, direction string) error {
+	if acl == nil {
+		return newValidationError("VlanInterface.ApplyAcl", "Acl", "missing Acl unable to apply it")
+	}
+	attribute, err := aclAttribute("VlanInterface.ApplyAcl", acl.Type, direction, true)
+	if err != nil {
+		return err
+	}
+
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/system/interfaces/vlan" + strconv.Itoa(v.Vlan.VlanId)
+
+	return applyAcl(ctx, c, "VlanInterface.ApplyAcl", url, attribute, acl)
+}
+
+// UnapplyAcl performs PATCH to remove the routed ACL of the given type,
+// "ipv4" or "ipv6", applied in direction "in" or "out" from the
+// VlanInterface on the given Client object.
+func (v *VlanInterface) UnapplyAcl(ctx context.Context, c *Client, acl_type string, direction string) error {
+	attribute, err := aclAttribute("VlanInterface.UnapplyAcl", acl_type, direction, true)
+	if err != nil {
+		return err
+	}
+
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/system/interfaces/vlan" + strconv.Itoa(v.Vlan.VlanId)
+
+	return applyAcl(ctx, c, "VlanInterface.UnapplyAcl", url, attribute, nil)
+}
@@ -0,0 +1,207 @@
+package aoscxgo_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aruba/aoscxgo"
+)
+
+// cfgVersions returns the cfg_version sent by each request with one.
+func cfgVersions(c *aoscxgo.Client, attribute string) *[]int64 {
+	versions := &[]int64{}
+	c.RequestHook = func(ctx context.Context, info *aoscxgo.RequestInfo) {
+		body := map[string]json.Number{}
+		decoder := json.NewDecoder(strings.NewReader(info.Body))
+		decoder.UseNumber()
+		decoder.Decode(&body)
+		if version, err := body[attribute].Int64(); err == nil {
+			*versions = append(*versions, version)
+		}
+	}
+	return versions
+}
+
+func TestAclCRUD(t *testing.T) {
+	_, c := connect(t)
+	ctx := context.Background()
+	versions := cfgVersions(c, "cfg_version")
+
+	acl := aoscxgo.Acl{
+		Name: "servers",
+		Type: "ipv4",
+		Entries: []aoscxgo.AclEntry{
+			{Sequence: 20, Action: "deny", Log: true},
+			{Sequence: 10, Action: "permit", Protocol: "tcp", Source: "10.0.0.5/24", DestinationPort: "443"},
+		},
+	}
+	err := acl.Create(ctx, c)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	got := aoscxgo.Acl{Name: "servers", Type: "ipv4"}
+	err = got.Get(ctx, c)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	want := []aoscxgo.AclEntry{
+		{Sequence: 10, Action: "permit", Protocol: "tcp", Source: "10.0.0.0/24", DestinationPort: "443"},
+		{Sequence: 20, Action: "deny", Log: true},
+	}
+	if len(got.Entries) != 2 || got.Entries[0] != want[0] || got.Entries[1] != want[1] {
+		t.Errorf("Get = %+v", got.Entries)
+	}
+
+	// Update replaces all entries, those left out are removed
+	acl.Entries = []aoscxgo.AclEntry{
+		{Sequence: 5, Action: "permit", Protocol: "udp", DestinationPort: "53"},
+		{Sequence: 20, Action: "deny"},
+	}
+	err = acl.Update(ctx, c)
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	err = acl.Update(ctx, c)
+	if err != nil {
+		t.Fatalf("Update again: %v", err)
+	}
+	err = got.Get(ctx, c)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if len(got.Entries) != 2 || got.Entries[0].Sequence != 5 || got.Entries[1] != (aoscxgo.AclEntry{Sequence: 20, Action: "deny"}) {
+		t.Errorf("entries after Update = %+v", got.Entries)
+	}
+
+	// The switch only applies a changed ACL with a new version
+	if len(*versions) != 3 || (*versions)[0] >= (*versions)[1] || (*versions)[1] >= (*versions)[2] {
+		t.Errorf("cfg_version sent = %v, want ascending", *versions)
+	}
+
+	err = acl.Delete(ctx, c)
+	if err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	err = got.Get(ctx, c)
+	if !errors.Is(err, aoscxgo.ErrNotFound) {
+		t.Errorf("Get after Delete = %v, want ErrNotFound", err)
+	}
+}
+
+func TestAclValidation(t *testing.T) {
+	_, c := connect(t)
+	ctx := context.Background()
+
+	for _, acl := range []aoscxgo.Acl{
+		{Name: "a/b", Type: "ipv4"},
+		{Name: "acl", Type: "ip"},
+		{Name: "acl", Type: "ipv4", Entries: []aoscxgo.AclEntry{{Sequence: 0, Action: "permit"}}},
+		{Name: "acl", Type: "ipv4", Entries: []aoscxgo.AclEntry{{Sequence: 1, Action: "permit"}, {Sequence: 1, Action: "deny"}}},
+		{Name: "acl", Type: "ipv4", Entries: []aoscxgo.AclEntry{{Sequence: 1, Action: "allow"}}},
+		{Name: "acl", Type: "ipv4", Entries: []aoscxgo.AclEntry{{Sequence: 1, Action: "permit", Source: "2001:db8::/64"}}},
+		{Name: "acl", Type: "ipv4", Entries: []aoscxgo.AclEntry{{Sequence: 1, Action: "permit", DestinationPort: "80"}}},
+		{Name: "acl", Type: "mac", Entries: []aoscxgo.AclEntry{{Sequence: 1, Action: "permit", Protocol: "tcp"}}},
+	} {
+		err := acl.Create(ctx, c)
+		if !errors.Is(err, aoscxgo.ErrValidation) {
+			t.Errorf("Create(%+v) = %v, want ErrValidation", acl, err)
+		}
+	}
+}
+
+func TestListAcls(t *testing.T) {
+	srv, c := connect(t)
+	ctx := context.Background()
+
+	acl := aoscxgo.Acl{Name: "servers", Type: "ipv6", Entries: []aoscxgo.AclEntry{{Sequence: 1, Action: "permit"}}}
+	err := acl.Create(ctx, c)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	// The switch escapes the names in the keys of the ACLs
+	srv.SetObject("system/acls", "guest%20wifi,mac", map[string]interface{}{
+		"name":      "guest wifi",
+		"list_type": "mac",
+		"cfg_aces":  map[string]interface{}{"1": map[string]interface{}{"action": "deny"}},
+	})
+
+	acls, err := aoscxgo.ListAcls(ctx, c)
+	if err != nil {
+		t.Fatalf("ListAcls: %v", err)
+	}
+	if len(acls) != 2 || acls[0].Name != "guest wifi" || acls[0].Type != "mac" || len(acls[0].Entries) != 1 ||
+		acls[1].Name != "servers" || acls[1].Type != "ipv6" || !acls[1].GetStatus() {
+		t.Errorf("ListAcls = %+v", acls)
+	}
+	if acls[0].GetURI() != "/rest/"+c.Version+"/system/acls/guest%20wifi,mac" {
+		t.Errorf("GetURI = %s", acls[0].GetURI())
+	}
+}
+
+func TestApplyAcl(t *testing.T) {
+	srv, c := connect(t)
+	ctx := context.Background()
+	srv.AddInterface("1/1/1")
+	i := aoscxgo.Interface{Name: "1/1/1"}
+
+	acl := aoscxgo.Acl{Name: "servers", Type: "ipv4", Entries: []aoscxgo.AclEntry{{Sequence: 1, Action: "permit"}}}
+	err := acl.Create(ctx, c)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	// Applying leaves the entries of the caller alone
+	desired := aoscxgo.Acl{Name: "servers", Type: "ipv4", Entries: []aoscxgo.AclEntry{{Sequence: 2, Action: "deny"}}}
+	err = i.ApplyAcl(ctx, c, &desired, "in")
+	if err != nil {
+		t.Fatalf("ApplyAcl: %v", err)
+	}
+	if len(desired.Entries) != 1 || desired.Entries[0].Sequence != 2 {
+		t.Errorf("ApplyAcl changed the entries to %+v", desired.Entries)
+	}
+
+	obj, _ := srv.Object("system/interfaces", "1/1/1")
+	if obj["aclv4_in_cfg"] != "/rest/"+c.Version+"/system/acls/servers,ipv4" || obj["aclv4_in_cfg_version"] == nil {
+		t.Errorf("interface = %v", obj)
+	}
+
+	err = i.UnapplyAcl(ctx, c, "ipv4", "in")
+	if err != nil {
+		t.Fatalf("UnapplyAcl: %v", err)
+	}
+	obj, _ = srv.Object("system/interfaces", "1/1/1")
+	if obj["aclv4_in_cfg"] != nil {
+		t.Errorf("aclv4_in_cfg after UnapplyAcl = %v", obj["aclv4_in_cfg"])
+	}
+
+	missing := aoscxgo.Acl{Name: "missing", Type: "ipv4"}
+	err = i.ApplyAcl(ctx, c, &missing, "in")
+	if !errors.Is(err, aoscxgo.ErrValidation) || !errors.Is(err, aoscxgo.ErrNotFound) {
+		t.Errorf("ApplyAcl of a missing ACL = %v, want ErrValidation and ErrNotFound", err)
+	}
+}
+
+func TestApplyNilAcl(t *testing.T) {
+	_, c := connect(t)
+	ctx := context.Background()
+
+	i := aoscxgo.Interface{Name: "1/1/1"}
+	err := i.ApplyAcl(ctx, c, nil, "in")
+	if !errors.Is(err, aoscxgo.ErrValidation) {
+		t.Errorf("Interface.ApplyAcl(nil) = %v, want ErrValidation", err)
+	}
+	v := aoscxgo.Vlan{VlanId: 10}
+	err = v.ApplyAcl(ctx, c, nil, "in")
+	if !errors.Is(err, aoscxgo.ErrValidation) {
+		t.Errorf("Vlan.ApplyAcl(nil) = %v, want ErrValidation", err)
+	}
+	vi := aoscxgo.VlanInterface{Vlan: v}
+	err = vi.ApplyAcl(ctx, c, nil, "in")
+	if !errors.Is(err, aoscxgo.ErrValidation) {
+		t.Errorf("VlanInterface.ApplyAcl(nil) = %v, want ErrValidation", err)
+	}
+}
@@ -0,0 +1,267 @@
+package aoscxtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/aruba/aoscxgo"
+)
+
+// Mode selects whether a Recorder records or replays.
+type Mode int
+
+const (
+	// Record sends requests to the switch and records them.
+	Record Mode = iota
+	// Replay answers requests from a golden file without a switch.
+	Replay
+)
+
+// scrubbed replaces credentials, cookies, CSRF tokens and secrets in
+// golden files.
+const scrubbed = aoscxgo.Redacted
+
+// Recorder is an http.RoundTripper for Client.Transport that records the
+// traffic with a switch to a golden file, or replays it from one:
+//
+//	rec, err := aoscxtest.NewRecorder("testdata/vlan.json", aoscxtest.Record, nil)
+//	...
+//	sw, err := aoscxgo.Connect(ctx, &aoscxgo.Client{
+//		Hostname:  "10.0.0.1",
+//		Username:  "admin",
+//		Password:  "admin",
+//		Transport: rec,
+//	})
+//	...
+//	err = rec.Save()
+//
+// In Replay mode the same flow runs against any Hostname. Requests are
+// matched in order on method, path, query and body, so repeated requests
+// such as dryrun polling are answered with the responses recorded for them.
+// Passwords, cookies, CSRF tokens and secrets are scrubbed before they are
+// recorded.
+type Recorder struct {
+	mode         Mode
+	path         string
+	transport    http.RoundTripper
+	mu           sync.Mutex
+	interactions []Interaction
+	used         []bool
+}
+
+// Interaction is a recorded request and its response.
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest is a recorded request. URL holds the path and query only
+// so recordings replay against any host.
+type RecordedRequest struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+	Body   string `json:"body,omitempty"`
+}
+
+// RecordedResponse is a recorded response.
+type RecordedResponse struct {
+	StatusCode int                 `json:"status_code"`
+	Status     string              `json:"status"`
+	Header     map[string][]string `json:"header,omitempty"`
+	Body       string              `json:"body,omitempty"`
+}
+
+// NewRecorder returns a Recorder for the golden file at path. In Record
+// mode requests are sent with transport, http.DefaultTransport if nil, and
+// Save writes them to the file. In Replay mode the file is read.
+func NewRecorder(path string, mode Mode, transport http.RoundTripper) (*Recorder, error) {
+	r := &Recorder{
+		mode:      mode,
+		path:      path,
+		transport: transport,
+	}
+	if r.transport == nil {
+		r.transport = http.DefaultTransport
+	}
+
+	if mode == Replay {
+		contents, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading golden file: %w", err)
+		}
+		err = json.Unmarshal(contents, &r.interactions)
+		if err != nil {
+			return nil, fmt.Errorf("parsing golden file %s: %w", path, err)
+		}
+		r.used = make([]bool, len(r.interactions))
+	}
+
+	return r, nil
+}
+
+// Interactions returns the recorded or loaded interactions.
+func (r *Recorder) Interactions() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]Interaction(nil), r.interactions...)
+}
+
+// Save writes the recorded interactions to the golden file. It does nothing
+// in Replay mode.
+func (r *Recorder) Save() error {
+	if r.mode != Record {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var contents bytes.Buffer
+	encoder := json.NewEncoder(&contents)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	err := encoder.Encode(r.interactions)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(r.path, contents.Bytes(), 0644)
+}
+
+// RoundTrip records or replays req.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	req_body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	recorded_req := RecordedRequest{
+		Method: req.Method,
+		URL:    req.URL.RequestURI(),
+		Body:   scrubBody(req_body, req.Header.Get("Content-Type")),
+	}
+
+	if r.mode == Replay {
+		return r.replay(req, recorded_req)
+	}
+
+	res, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	res_body, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = io.NopCloser(bytes.NewReader(res_body))
+
+	r.mu.Lock()
+	r.interactions = append(r.interactions, Interaction{
+		Request: recorded_req,
+		Response: RecordedResponse{
+			StatusCode: res.StatusCode,
+			Status:     res.Status,
+			Header:     scrubHeader(res.Header),
+			Body:       scrubBody(res_body, res.Header.Get("Content-Type")),
+		},
+	})
+	r.mu.Unlock()
+
+	return res, nil
+}
+
+// replay answers req with the first unused interaction matching it.
+func (r *Recorder) replay(req *http.Request, recorded_req RecordedRequest) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for index, interaction := range r.interactions {
+		if r.used[index] || interaction.Request != recorded_req {
+			continue
+		}
+		r.used[index] = true
+
+		recorded_res := interaction.Response
+		header := http.Header{}
+		for key, values := range recorded_res.Header {
+			header[http.CanonicalHeaderKey(key)] = append([]string(nil), values...)
+		}
+
+		return &http.Response{
+			StatusCode:    recorded_res.StatusCode,
+			Status:        recorded_res.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          io.NopCloser(strings.NewReader(recorded_res.Body)),
+			ContentLength: int64(len(recorded_res.Body)),
+			Request:       req,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("no recorded response for %s %s in %s", recorded_req.Method, recorded_req.URL, r.path)
+}
+
+// readRequestBody returns the body of req, leaving it readable.
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+// scrubHeader returns a copy of the response header with the session
+// cookie and CSRF token replaced.
+func scrubHeader(header http.Header) map[string][]string {
+	scrubbed_header := map[string][]string{}
+	for key, values := range header {
+		switch http.CanonicalHeaderKey(key) {
+		case "Set-Cookie":
+			for _, value := range values {
+				cookie_name, _, _ := strings.Cut(value, "=")
+				scrubbed_header[key] = append(scrubbed_header[key], cookie_name+"="+scrubbed+"; Path=/")
+			}
+		case "X-Csrf-Token":
+			scrubbed_header[key] = []string{scrubbed}
+		case "Date", "Content-Length":
+			// Date differs on every run and would churn golden files, the
+			// length no longer matches a scrubbed body
+		default:
+			scrubbed_header[key] = append([]string(nil), values...)
+		}
+	}
+	return scrubbed_header
+}
+
+// scrubBody returns body with credentials and secrets replaced, based on
+// its content type. The username is scrubbed from the login form in addition
+// to the secrets redacted by aoscxgo.RedactBody.
+func scrubBody(body []byte, content_type string) string {
+	if strings.HasPrefix(content_type, "application/x-www-form-urlencoded") {
+		form, err := url.ParseQuery(string(body))
+		if err == nil && form.Has("username") {
+			form.Set("username", scrubbed)
+			body = []byte(form.Encode())
+		}
+	}
+
+	return aoscxgo.RedactBody(body, content_type)
+}
@@ -0,0 +1,925 @@
+// Package aoscxtest provides a fake AOS-CX switch for testing code that uses
+// aoscxgo without real hardware.
+//
+// The Server answers the REST API over TLS from in-memory state. It
+// implements login and logout with CSRF tokens, the system/vlans,
+// system/interfaces, interface ip6_addresses, system/vsx, system/vrfs,
+// system/acls, system/acl_object_groups and QoS tables, the static route,
+// OSPF and BGP tables of VRFs and the running configuration including
+// dryrun validation and apply:
+//
+//	srv := aoscxtest.NewServer()
+//	defer srv.Close()
+//
+//	sw, err := aoscxgo.Connect(ctx, srv.Client())
+//	...
+//	vlan := aoscxgo.Vlan{VlanId: 10, Name: "users"}
+//	err = vlan.Create(ctx, sw)
+//
+//	obj, ok := srv.Object("system/vlans", "10")
+package aoscxtest
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/aruba/aoscxgo"
+)
+
+// Default settings of a new Server.
+const (
+	DefaultUsername        = "admin"
+	DefaultPassword        = "admin"
+	DefaultVersion         = "v10.09"
+	DefaultFirmwareVersion = "FL.10.09.1000"
+	DefaultPlatform        = "6300"
+)
+
+// Server is a fake AOS-CX switch. Settings may be changed before the first
+// request, state may be inspected and seeded at any time. All methods are
+// safe for concurrent use.
+type Server struct {
+	*httptest.Server
+
+	// Credentials accepted by login.
+	Username string
+	Password string
+	// Versions lists the REST versions offered, the last is reported as
+	// the latest.
+	Versions []string
+	// Reported by the system table.
+	Hostname        string
+	FirmwareVersion string
+	Platform        string
+	// DryrunPolls is the number of status requests answered with the
+	// "running" state before a dryrun completes. Note that aoscxgo waits
+	// two seconds between polls.
+	DryrunPolls int
+	// ValidateConfig, when set, is called with the configuration of a
+	// dryrun. Returned errors fail the dryrun, nothing is applied.
+	ValidateConfig func(config string) []aoscxgo.ConfigLineError
+
+	mu             sync.Mutex
+	sessions       map[string]string
+	tables         map[string]map[string]map[string]interface{}
+	system         map[string]interface{}
+	running_config string
+	dryrun         *dryrun
+}
+
+// dryrun is the state of the last configuration dryrun.
+type dryrun struct {
+	mode       string
+	config     string
+	polls_left int
+	errors     []aoscxgo.ConfigLineError
+}
+
+// collections maps the tables of the fake switch to the attribute holding
+// the key of their rows. A "*" matches any key of the parent table. Keys of
+// several attributes are listed comma separated and joined by a comma, such
+// as "MYACL,ipv4" for ACLs. Tables without key attribute hold a single row
+// with the empty key, created by POST to the table itself such as
+// system/vsx.
+var collections = map[string]string{
+	"system/vlans":                                           "id",
+	"system/interfaces":                                      "name",
+	"system/interfaces/*/ip6_addresses":                      "address",
+	"system/vsx":                                             "",
+	"system/vrfs":                                            "name",
+	"system/acls":                                            "name,list_type",
+	"system/acl_object_groups":                               "name,object_type",
+	"system/classes":                                         "name,type",
+	"system/policies":                                        "name",
+	"system/q_profiles":                                      "name",
+	"system/q_profiles/*/q_profile_entries":                  "queue_number",
+	"system/qos":                                             "name",
+	"system/qos/*/queues":                                    "queue_number",
+	"system/qos_dscp_map_entries":                            "code_point",
+	"system/qos_cos_map_entries":                             "code_point",
+	"system/vrfs/*/static_routes":                            "prefix",
+	"system/vrfs/*/static_routes/*/static_nexthops":          "id",
+	"system/vrfs/*/ospf_routers":                             "instance_tag",
+	"system/vrfs/*/ospf_routers/*/areas":                     "area_id",
+	"system/vrfs/*/ospf_routers/*/areas/*/ospf_interfaces":   "name",
+	"system/vrfs/*/ospfv3_routers":                           "instance_tag",
+	"system/vrfs/*/ospfv3_routers/*/areas":                   "area_id",
+	"system/vrfs/*/ospfv3_routers/*/areas/*/ospf_interfaces": "name",
+	"system/vrfs/*/bgp_routers":                              "asn",
+	"system/vrfs/*/bgp_routers/*/bgp_neighbors":              "ip_or_ifname_or_group_name",
+	"system/vrfs/*/bgp_routers/*/bgp_networks":               "ip_prefix",
+}
+
+// NewServer starts a fake switch with the default settings holding VLAN 1,
+// the default and mgmt VRFs and the default DSCP and CoS maps. Close it when
+// done.
+func NewServer() *Server {
+	s := &Server{
+		Username:        DefaultUsername,
+		Password:        DefaultPassword,
+		Versions:        []string{"v10.08", DefaultVersion},
+		Hostname:        "aoscxtest",
+		FirmwareVersion: DefaultFirmwareVersion,
+		Platform:        DefaultPlatform,
+		sessions:        map[string]string{},
+		tables:          map[string]map[string]map[string]interface{}{},
+		system:          map[string]interface{}{},
+	}
+	s.SetObject("system/vlans", "1", map[string]interface{}{
+		"id":          1,
+		"name":        "DEFAULT_VLAN_1",
+		"type":        "static",
+		"admin":       "up",
+		"description": "",
+	})
+	for _, vrf := range []string{"default", "mgmt"} {
+		s.SetObject("system/vrfs", vrf, map[string]interface{}{
+			"name": vrf,
+		})
+	}
+	for code_point := 0; code_point <= 63; code_point++ {
+		s.SetObject("system/qos_dscp_map_entries", strconv.Itoa(code_point), map[string]interface{}{
+			"code_point":     code_point,
+			"local_priority": code_point / 8,
+			"color":          "green",
+		})
+	}
+	for code_point := 0; code_point <= 7; code_point++ {
+		local_priority := code_point
+		if code_point <= 1 {
+			local_priority = 1 - code_point
+		}
+		s.SetObject("system/qos_cos_map_entries", strconv.Itoa(code_point), map[string]interface{}{
+			"code_point":     code_point,
+			"local_priority": local_priority,
+			"color":          "green",
+		})
+	}
+	s.Server = httptest.NewTLSServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Client returns an unconnected Client for the Server using its
+// credentials, to be passed to aoscxgo.Connect.
+func (s *Server) Client() *aoscxgo.Client {
+	return &aoscxgo.Client{
+		Hostname:   s.Listener.Addr().String(),
+		Username:   s.Username,
+		Password:   s.Password,
+		HTTPClient: s.Server.Client(),
+	}
+}
+
+// Object returns a copy of the row of the given table, e.g.
+// Object("system/interfaces", "1/1/1"). Keys of nested tables are path
+// escaped, e.g. "system/interfaces/1%2F1%2F1/ip6_addresses". The row of a
+// single row table such as system/vsx has the empty key.
+func (s *Server) Object(collection string, id string) (map[string]interface{}, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	obj, ok := s.tables[collection][id]
+	if !ok {
+		return nil, false
+	}
+	return copyObject(obj), true
+}
+
+// System returns a copy of the system row, holding the attributes set by
+// PATCH such as qos_config.
+func (s *Server) System() map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return copyObject(s.systemObject())
+}
+
+// Objects returns the keys of the rows of the given table, sorted.
+func (s *Server) Objects(collection string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var ids []string
+	for id := range s.tables[collection] {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// SetObject creates or replaces the row of the given table.
+func (s *Server) SetObject(collection string, id string, obj map[string]interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.setObject(collection, id, normalize(obj).(map[string]interface{}))
+}
+
+// DeleteObject removes the row of the given table and its nested tables.
+func (s *Server) DeleteObject(collection string, id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.deleteObject(collection, id)
+}
+
+// AddInterface creates a physical interface, as ports exist on a switch
+// without configuration.
+func (s *Server) AddInterface(name string) {
+	s.SetObject("system/interfaces", name, map[string]interface{}{
+		"name":  name,
+		"type":  "system",
+		"admin": "down",
+	})
+}
+
+// RunningConfig returns the running configuration.
+func (s *Server) RunningConfig() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.running_config
+}
+
+// SetRunningConfig replaces the running configuration.
+func (s *Server) SetRunningConfig(config string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.running_config = config
+}
+
+// ExpireSessions closes all sessions, as the switch does after the session
+// idle timeout. Requests of connected Clients are rejected until they log in
+// again.
+func (s *Server) ExpireSessions() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sessions = map[string]string{}
+}
+
+// serveHTTP dispatches requests to the REST API.
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	segments := strings.Split(strings.Trim(r.URL.EscapedPath(), "/"), "/")
+	if segments[0] != "rest" {
+		http.Error(w, "Not Found", http.StatusNotFound)
+		return
+	}
+	if len(segments) == 1 {
+		s.serveVersions(w, r)
+		return
+	}
+
+	version := segments[1]
+	if !s.offers(version) {
+		http.Error(w, "Not Found", http.StatusNotFound)
+		return
+	}
+
+	path := strings.Join(segments[2:], "/")
+	if path == "login" {
+		s.serveLogin(w, r)
+		return
+	}
+
+	if !s.authorized(w, r) {
+		return
+	}
+
+	switch {
+	case path == "logout":
+		s.serveLogout(w, r)
+	case path == "system":
+		s.serveSystem(w, r)
+	case path == "configs/running-config":
+		s.serveRunningConfig(w, r)
+	default:
+		s.serveTable(w, r, version, segments[2:])
+	}
+}
+
+// offers returns true if the Server offers the REST version.
+func (s *Server) offers(version string) bool {
+	for _, offered := range s.Versions {
+		if offered == version {
+			return true
+		}
+	}
+	return false
+}
+
+// serveVersions answers version discovery on /rest.
+func (s *Server) serveVersions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body := map[string]interface{}{}
+	for _, version := range s.Versions {
+		body[version] = map[string]interface{}{
+			"version": version,
+			"prefix":  "/rest/" + version,
+		}
+	}
+	if len(s.Versions) > 0 {
+		latest := s.Versions[len(s.Versions)-1]
+		body["latest"] = map[string]interface{}{
+			"version": latest,
+			"prefix":  "/rest/" + latest,
+		}
+	}
+	writeJSON(w, http.StatusOK, body)
+}
+
+// serveLogin checks the form encoded credentials and opens a session.
+func (s *Server) serveLogin(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	err := r.ParseForm()
+	if err != nil {
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+	if r.PostForm.Get("username") != s.Username || r.PostForm.Get("password") != s.Password {
+		http.Error(w, "Login failed: authentication failure", http.StatusUnauthorized)
+		return
+	}
+
+	session_id := randomToken()
+	csrf := randomToken()
+	s.sessions[session_id] = csrf
+
+	http.SetCookie(w, &http.Cookie{Name: "id", Value: session_id, Path: "/", Secure: true, HttpOnly: true})
+	if r.Header.Get("x-use-csrf-token") == "true" {
+		w.Header().Set("X-Csrf-Token", csrf)
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// authorized checks the session cookie, and the CSRF token on requests
+// changing state. A 401 is written if either is invalid.
+func (s *Server) authorized(w http.ResponseWriter, r *http.Request) bool {
+	cookie, err := r.Cookie("id")
+	if err != nil {
+		http.Error(w, "Unauthorized session", http.StatusUnauthorized)
+		return false
+	}
+	csrf, ok := s.sessions[cookie.Value]
+	if !ok {
+		http.Error(w, "Unauthorized session", http.StatusUnauthorized)
+		return false
+	}
+	if r.Method != "GET" && r.Header.Get("x-csrf-token") != csrf {
+		http.Error(w, "CSRF token is missing or invalid", http.StatusUnauthorized)
+		return false
+	}
+	return true
+}
+
+// serveLogout closes the session.
+func (s *Server) serveLogout(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	cookie, _ := r.Cookie("id")
+	delete(s.sessions, cookie.Value)
+	w.WriteHeader(http.StatusOK)
+}
+
+// serveSystem answers GET and PATCH on the system table.
+func (s *Server) serveSystem(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+		writeJSON(w, http.StatusOK, selectAttributes(s.systemObject(), r.URL.Query()))
+	case "PATCH":
+		update, err := readObject(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		for key, value := range update {
+			s.system[key] = value
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// systemObject returns the system row with the reported settings.
+func (s *Server) systemObject() map[string]interface{} {
+	system := map[string]interface{}{}
+	for key, value := range s.system {
+		system[key] = value
+	}
+	system["hostname"] = s.Hostname
+	system["firmware_version"] = s.FirmwareVersion
+	system["platform_name"] = s.Platform
+	return system
+}
+
+// serveRunningConfig answers the running configuration as text and runs
+// dryruns. A dryrun is started with POST ?dryrun=validate or
+// ?dryrun=apply and its state polled with GET ?dryrun.
+func (s *Server) serveRunningConfig(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	_, is_dryrun := query["dryrun"]
+
+	switch {
+	case r.Method == "GET" && is_dryrun:
+		if s.dryrun == nil {
+			http.Error(w, "No dryrun request found", http.StatusNotFound)
+			return
+		}
+		writeJSON(w, http.StatusOK, s.dryrunStatus())
+
+	case r.Method == "GET":
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusOK)
+		io.WriteString(w, s.running_config)
+
+	case r.Method == "POST" && is_dryrun:
+		mode := query.Get("dryrun")
+		if mode != "validate" && mode != "apply" {
+			http.Error(w, "Invalid dryrun mode "+mode, http.StatusBadRequest)
+			return
+		}
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "Bad Request", http.StatusBadRequest)
+			return
+		}
+		s.dryrun = &dryrun{
+			mode:       mode,
+			config:     string(body),
+			polls_left: s.DryrunPolls,
+		}
+		if s.ValidateConfig != nil {
+			s.dryrun.errors = s.ValidateConfig(s.dryrun.config)
+		}
+		w.WriteHeader(http.StatusAccepted)
+
+	case r.Method == "PUT":
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "Bad Request", http.StatusBadRequest)
+			return
+		}
+		s.running_config = string(body)
+		w.WriteHeader(http.StatusOK)
+
+	default:
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// dryrunStatus returns the state of the dryrun, completing it once the
+// configured number of polls is reached. A successful apply replaces the
+// running configuration.
+func (s *Server) dryrunStatus() map[string]interface{} {
+	d := s.dryrun
+	if d.polls_left > 0 {
+		d.polls_left--
+		return map[string]interface{}{"state": "running", "mode": d.mode}
+	}
+
+	if len(d.errors) > 0 {
+		errors_list := []interface{}{}
+		for _, line_error := range d.errors {
+			errors_list = append(errors_list, map[string]interface{}{
+				"line":    line_error.Line,
+				"message": line_error.Message,
+			})
+		}
+		return map[string]interface{}{"state": "error", "mode": d.mode, "errors": errors_list}
+	}
+
+	if d.mode == "apply" {
+		s.running_config = d.config
+	}
+	return map[string]interface{}{"state": "success", "mode": d.mode}
+}
+
+// serveTable answers requests on the in-memory tables, segments being the
+// escaped path below the REST version.
+func (s *Server) serveTable(w http.ResponseWriter, r *http.Request, version string, segments []string) {
+	collection, id, ok := s.resolve(segments)
+	if !ok {
+		http.Error(w, "Not Found", http.StatusNotFound)
+		return
+	}
+
+	if id == "" && collections[pattern(collection)] == "" {
+		s.serveSingleton(w, r, collection)
+		return
+	}
+
+	if id == "" {
+		switch r.Method {
+		case "GET":
+			s.getCollection(w, r, version, collection)
+		case "POST":
+			s.postCollection(w, r, collection)
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+
+	s.serveObject(w, r, collection, id)
+}
+
+// serveSingleton answers requests on a table holding a single row, which
+// POST creates.
+func (s *Server) serveSingleton(w http.ResponseWriter, r *http.Request, collection string) {
+	if r.Method != "POST" {
+		s.serveObject(w, r, collection, "")
+		return
+	}
+
+	obj, err := readObject(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if _, exists := s.tables[collection][""]; exists {
+		http.Error(w, "Object already exists", http.StatusConflict)
+		return
+	}
+
+	s.setObject(collection, "", obj)
+	w.WriteHeader(http.StatusCreated)
+}
+
+// serveObject answers requests on the row of a table.
+func (s *Server) serveObject(w http.ResponseWriter, r *http.Request, collection string, id string) {
+	obj, exists := s.tables[collection][id]
+	if !exists {
+		http.Error(w, "Object not found", http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+		writeJSON(w, http.StatusOK, render(selectAttributes(obj, r.URL.Query())))
+	case "PUT", "PATCH":
+		update, err := readObject(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if r.Method == "PUT" {
+			if keys := collections[pattern(collection)]; keys != "" {
+				for _, key := range strings.Split(keys, ",") {
+					update[key] = obj[key]
+				}
+			}
+			s.tables[collection][id] = update
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		for key, value := range update {
+			obj[key] = value
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case "DELETE":
+		s.deleteObject(collection, id)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// resolve maps an escaped path to a table and the unescaped key of a row,
+// which is empty for the table itself. Rows of parent tables must exist.
+func (s *Server) resolve(segments []string) (string, string, bool) {
+	for length := len(segments); length > 0; length-- {
+		collection := strings.Join(segments[:length], "/")
+		if _, ok := collections[pattern(collection)]; !ok {
+			continue
+		}
+
+		// Rows holding a nested table must exist
+		for index := 2; index < length; index += 2 {
+			parent := strings.Join(segments[:index], "/")
+			parent_id, err := url.PathUnescape(segments[index])
+			if err != nil {
+				return "", "", false
+			}
+			if _, ok := s.tables[parent][parent_id]; !ok {
+				return "", "", false
+			}
+		}
+
+		switch len(segments) - length {
+		case 0:
+			return collection, "", true
+		case 1:
+			id, err := url.PathUnescape(segments[length])
+			if err != nil {
+				return "", "", false
+			}
+			return collection, id, true
+		}
+		return "", "", false
+	}
+	return "", "", false
+}
+
+// pattern replaces the row keys of parent tables in collection by "*".
+func pattern(collection string) string {
+	segments := strings.Split(collection, "/")
+	for index := 2; index < len(segments); index += 2 {
+		segments[index] = "*"
+	}
+	return strings.Join(segments, "/")
+}
+
+// getCollection answers a table. With the default depth of 1 rows are
+// listed by URI, with depth 2 or more the rows themselves are listed
+// limited to the requested attributes. Each further level of depth adds the
+// rows of the nested tables as attributes named after them, such as
+// static_nexthops of static routes. The filter parameter limits the rows to
+// those matching all of its comma separated attribute:value pairs.
+func (s *Server) getCollection(w http.ResponseWriter, r *http.Request, version string, collection string) {
+	query := r.URL.Query()
+	depth := 1
+	if query.Get("depth") != "" {
+		value, err := strconv.Atoi(query.Get("depth"))
+		if err != nil || value < 1 {
+			http.Error(w, "Invalid depth "+query.Get("depth"), http.StatusBadRequest)
+			return
+		}
+		depth = value
+	}
+
+	filters, err := parseFilter(query.Get("filter"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	body := map[string]interface{}{}
+	for id, obj := range s.tables[collection] {
+		if !matchFilter(obj, filters) {
+			continue
+		}
+		if depth == 1 {
+			body[id] = "/rest/" + version + "/" + collection + "/" + url.PathEscape(id)
+		} else {
+			body[id] = render(selectAttributes(s.nest(collection, id, obj, depth-2), query))
+		}
+	}
+	writeJSON(w, http.StatusOK, body)
+}
+
+// nest returns obj with the rows of the tables nested below it added as
+// attributes, down to the given number of levels.
+func (s *Server) nest(collection string, id string, obj map[string]interface{}, levels int) map[string]interface{} {
+	if levels < 1 {
+		return obj
+	}
+
+	nested := map[string]interface{}{}
+	for key, value := range obj {
+		nested[key] = value
+	}
+
+	prefix := pattern(collection) + "/*/"
+	for table := range collections {
+		name := strings.TrimPrefix(table, prefix)
+		if name == table || strings.Contains(name, "/") {
+			continue
+		}
+
+		child := collection + "/" + url.PathEscape(id) + "/" + name
+		rows := map[string]interface{}{}
+		for child_id, child_obj := range s.tables[child] {
+			rows[child_id] = render(s.nest(child, child_id, child_obj, levels-1))
+		}
+		nested[name] = rows
+	}
+	return nested
+}
+
+// parseFilter parses a filter parameter such as "type:system,admin:up".
+func parseFilter(filter string) (map[string]string, error) {
+	filters := map[string]string{}
+	if filter == "" {
+		return filters, nil
+	}
+
+	for _, pair := range strings.Split(filter, ",") {
+		attribute, value, ok := strings.Cut(pair, ":")
+		if !ok || attribute == "" {
+			return nil, fmt.Errorf("Invalid filter %s", filter)
+		}
+		filters[attribute] = value
+	}
+	return filters, nil
+}
+
+// matchFilter returns true if the attributes of obj hold the filtered
+// values.
+func matchFilter(obj map[string]interface{}, filters map[string]string) bool {
+	for attribute, value := range filters {
+		if fmt.Sprintf("%v", obj[attribute]) != value {
+			return false
+		}
+	}
+	return true
+}
+
+// postCollection creates a row from the JSON body.
+func (s *Server) postCollection(w http.ResponseWriter, r *http.Request, collection string) {
+	obj, err := readObject(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var key_values []string
+	for _, key := range strings.Split(collections[pattern(collection)], ",") {
+		key_value := formatKey(obj[key])
+		if key_value == "" {
+			http.Error(w, "Missing attribute "+key, http.StatusBadRequest)
+			return
+		}
+		key_values = append(key_values, key_value)
+	}
+	id := strings.Join(key_values, ",")
+	if _, exists := s.tables[collection][id]; exists {
+		http.Error(w, "Object already exists", http.StatusConflict)
+		return
+	}
+
+	s.setObject(collection, id, obj)
+	w.WriteHeader(http.StatusCreated)
+}
+
+// setObject stores a row, creating the table if needed.
+func (s *Server) setObject(collection string, id string, obj map[string]interface{}) {
+	if s.tables[collection] == nil {
+		s.tables[collection] = map[string]map[string]interface{}{}
+	}
+	s.tables[collection][id] = obj
+}
+
+// deleteObject removes a row and the tables nested below it.
+func (s *Server) deleteObject(collection string, id string) {
+	delete(s.tables[collection], id)
+
+	prefix := collection + "/" + url.PathEscape(id) + "/"
+	for nested := range s.tables {
+		if strings.HasPrefix(nested, prefix) {
+			delete(s.tables, nested)
+		}
+	}
+}
+
+// readObject decodes the JSON object in the request body.
+func readObject(r *http.Request) (map[string]interface{}, error) {
+	obj := map[string]interface{}{}
+	err := json.NewDecoder(r.Body).Decode(&obj)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid JSON body: %v", err)
+	}
+	return obj, nil
+}
+
+// formatKey returns the row key held by a key attribute value.
+func formatKey(value interface{}) string {
+	switch typed := value.(type) {
+	case string:
+		return typed
+	case float64:
+		return strconv.FormatFloat(typed, 'f', -1, 64)
+	case int:
+		return strconv.Itoa(typed)
+	}
+	return ""
+}
+
+// selectAttributes limits obj to the comma separated attributes query
+// parameter, if given.
+func selectAttributes(obj map[string]interface{}, query url.Values) map[string]interface{} {
+	if query.Get("attributes") == "" {
+		return obj
+	}
+
+	selected := map[string]interface{}{}
+	for _, attribute := range strings.Split(query.Get("attributes"), ",") {
+		if value, ok := obj[attribute]; ok {
+			selected[attribute] = value
+		}
+	}
+	return selected
+}
+
+// render returns a copy of obj as the switch returns it, with references
+// written as URIs converted to maps of the referenced keys to their URIs,
+// e.g. "vrf": {"default": "/rest/v10.09/system/vrfs/default"}.
+func render(obj map[string]interface{}) map[string]interface{} {
+	rendered := map[string]interface{}{}
+	for key, value := range obj {
+		switch typed := value.(type) {
+		case string:
+			if isReference(typed) {
+				rendered[key] = referenceMap([]interface{}{typed})
+				continue
+			}
+		case []interface{}:
+			if len(typed) > 0 && allReferences(typed) {
+				rendered[key] = referenceMap(typed)
+				continue
+			}
+		}
+		rendered[key] = value
+	}
+	return rendered
+}
+
+// isReference returns true if value is the URI of a row.
+func isReference(value string) bool {
+	return strings.HasPrefix(value, "/rest/")
+}
+
+// allReferences returns true if all values are URIs of rows.
+func allReferences(values []interface{}) bool {
+	for _, value := range values {
+		str, ok := value.(string)
+		if !ok || !isReference(str) {
+			return false
+		}
+	}
+	return true
+}
+
+// referenceMap maps the keys of the referenced rows to their URIs.
+func referenceMap(uris []interface{}) map[string]interface{} {
+	references := map[string]interface{}{}
+	for _, uri := range uris {
+		str := uri.(string)
+		key := str[strings.LastIndex(str, "/")+1:]
+		if unescaped, err := url.PathUnescape(key); err == nil {
+			key = unescaped
+		}
+		references[key] = str
+	}
+	return references
+}
+
+// normalize converts value to the types produced by decoding JSON so
+// seeded rows compare equal to created ones.
+func normalize(value interface{}) interface{} {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+	var decoded interface{}
+	if json.Unmarshal(encoded, &decoded) != nil {
+		return value
+	}
+	return decoded
+}
+
+// copyObject returns a deep copy of obj.
+func copyObject(obj map[string]interface{}) map[string]interface{} {
+	copied, _ := normalize(obj).(map[string]interface{})
+	return copied
+}
+
+// writeJSON writes body as a JSON response.
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// randomToken returns a random hex string for session cookies and CSRF
+// tokens.
+func randomToken() string {
+	buf := make([]byte, 16)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
@@ -0,0 +1,569 @@
+package aoscxgo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+
+	"golang.org/x/exp/slices"
+)
+
+// BgpNeighbor is a neighbor or peer group of a BgpRouter, identified by
+// Name.
+type BgpNeighbor struct {
+
+	// Connection properties.
+	// Vrf and Asn identify the BgpRouter, Vrf defaults to "default".
+	Vrf string `json:"vrf"`
+	Asn int64  `json:"asn"`
+	// Name is the IP address of the neighbor, the interface of an
+	// unnumbered neighbor or the name of a peer group.
+	Name string `json:"name"`
+	// IsPeerGroup makes this a peer group, whose settings are inherited by
+	// the neighbors listing it as PeerGroup.
+	IsPeerGroup bool `json:"is_peer_group"`
+	// PeerGroup is the peer group of the neighbor, if any.
+	PeerGroup string `json:"peer_group"`
+	// RemoteAs is the AS of the neighbor, it may be inherited from the
+	// PeerGroup.
+	RemoteAs    int64  `json:"remote_as"`
+	Description string `json:"description"`
+	Shutdown    bool   `json:"shutdown"`
+	// UpdateSource is the interface whose address is used for the session,
+	// e.g. "loopback0".
+	UpdateSource string `json:"update_source"`
+	Bfd          bool   `json:"bfd"`
+	// Password enables MD5 authentication. It is not returned by Get and
+	// an empty Password keeps the configured one on Update.
+	Password string `json:"password"`
+	// ClearPassword removes the configured password on Update.
+	ClearPassword bool `json:"clear_password"`
+	// KeepaliveTimer and HoldTimer in seconds, the router defaults if zero.
+	KeepaliveTimer int `json:"keepalive_timer"`
+	HoldTimer      int `json:"hold_timer"`
+	// AddressFamilies lists the address families the neighbor is activated
+	// in with their route-maps.
+	AddressFamilies []BgpAddressFamily `json:"address_families"`
+
+	BgpNeighborDetails map[string]interface{} `json:"details"`
+	materialized       bool
+	uri                string
+}
+
+// BgpAddressFamily activates a BgpNeighbor in an address family.
+type BgpAddressFamily struct {
+	// Name is "ipv4-unicast", "ipv6-unicast" or "l2vpn-evpn".
+	Name string `json:"name"`
+	// RouteMapIn and RouteMapOut are the names of the route-maps applied
+	// to routes received from and sent to the neighbor, if any.
+	RouteMapIn  string `json:"route_map_in"`
+	RouteMapOut string `json:"route_map_out"`
+}
+
+// BgpNeighborChanges lists the neighbors changed by ReconcileBgpNeighbors.
+type BgpNeighborChanges struct {
+	Created []string `json:"created"`
+	Updated []string `json:"updated"`
+	Deleted []string `json:"deleted"`
+}
+
+// normalizeKey validates the keys identifying the neighbor and sets their
+// defaults.
+func (n *BgpNeighbor) normalizeKey(op string) error {
+	err := normalizeBgp(op, &n.Vrf, n.Asn)
+	if err != nil {
+		return err
+	}
+	if n.Name == "" {
+		return newValidationError(op, "Name", "missing required value Name")
+	}
+	return nil
+}
+
+// normalize validates the neighbor settings and sets its defaults. op names
+// the calling operation in the returned error.
+func (n *BgpNeighbor) normalize(op string) error {
+	err := n.normalizeKey(op)
+	if err != nil {
+		return err
+	}
+
+	if !n.IsPeerGroup {
+		ip := net.ParseIP(n.Name)
+		if ip != nil {
+			n.Name = ip.String()
+		} else if !checkRoutedName(n.Name) {
+			return newValidationError(op, "Name", "expected IP address or interface received: "+n.Name)
+		}
+		if n.RemoteAs == 0 && n.PeerGroup == "" {
+			return newValidationError(op, "RemoteAs", "missing RemoteAs or PeerGroup")
+		}
+	} else if n.PeerGroup != "" {
+		return newValidationError(op, "PeerGroup", "a peer group cannot be member of a peer group")
+	}
+
+	if n.RemoteAs < 0 || n.RemoteAs > 4294967295 {
+		return newValidationError(op, "RemoteAs", "valid range is 1-4294967295 received: "+strconv.FormatInt(n.RemoteAs, 10))
+	}
+	if n.ClearPassword && n.Password != "" {
+		return newValidationError(op, "ClearPassword", "cannot clear and set the password at once")
+	}
+	if n.UpdateSource != "" && !checkRoutedName(n.UpdateSource) {
+		return newValidationError(op, "UpdateSource", "invalid interface name: "+n.UpdateSource)
+	}
+	if n.HoldTimer != 0 && (n.HoldTimer < 3 || n.HoldTimer > 65535) {
+		return newValidationError(op, "HoldTimer", "valid range is 0 or 3-65535 received: "+strconv.Itoa(n.HoldTimer))
+	}
+	if n.KeepaliveTimer < 0 || n.KeepaliveTimer > 65535 || (n.HoldTimer != 0 && n.KeepaliveTimer >= n.HoldTimer) {
+		return newValidationError(op, "KeepaliveTimer", "must be less than HoldTimer received: "+strconv.Itoa(n.KeepaliveTimer))
+	}
+
+	seen := map[string]bool{}
+	for _, family := range n.AddressFamilies {
+		if !slices.Contains([]string{"ipv4-unicast", "ipv6-unicast", "l2vpn-evpn"}, family.Name) {
+			return newValidationError(op, "AddressFamilies", "valid options are 'ipv4-unicast', 'ipv6-unicast' or 'l2vpn-evpn' received: "+family.Name)
+		}
+		if seen[family.Name] {
+			return newValidationError(op, "AddressFamilies", "duplicate address family "+family.Name)
+		}
+		seen[family.Name] = true
+	}
+	sort.Slice(n.AddressFamilies, func(a, b int) bool {
+		return n.AddressFamilies[a].Name < n.AddressFamilies[b].Name
+	})
+	return nil
+}
+
+// neighborMap returns the attributes of the neighbor to send to the switch.
+func (n *BgpNeighbor) neighborMap(c *Client) map[string]interface{} {
+	base_uri := "/rest/" + c.Version + "/" + bgpRoutersURI(n.Vrf) + "/" + strconv.FormatInt(n.Asn, 10)
+
+	activate := map[string]interface{}{}
+	route_map_in := map[string]interface{}{}
+	route_map_out := map[string]interface{}{}
+	for _, family := range n.AddressFamilies {
+		activate[family.Name] = true
+		if family.RouteMapIn != "" {
+			route_map_in[family.Name] = "/rest/" + c.Version + "/system/route_maps/" + url.PathEscape(family.RouteMapIn)
+		}
+		if family.RouteMapOut != "" {
+			route_map_out[family.Name] = "/rest/" + c.Version + "/system/route_maps/" + url.PathEscape(family.RouteMapOut)
+		}
+	}
+
+	timers := map[string]interface{}{}
+	if n.KeepaliveTimer != 0 {
+		timers["keepalive"] = n.KeepaliveTimer
+	}
+	if n.HoldTimer != 0 {
+		timers["holdtime"] = n.HoldTimer
+	}
+
+	neighborMap := map[string]interface{}{
+		"description":   n.Description,
+		"shutdown":      n.Shutdown,
+		"bfd_enable":    n.Bfd,
+		"timers":        timers,
+		"activate":      activate,
+		"route_map_in":  route_map_in,
+		"route_map_out": route_map_out,
+	}
+
+	if n.RemoteAs != 0 {
+		neighborMap["remote_as"] = n.RemoteAs
+	} else {
+		neighborMap["remote_as"] = nil
+	}
+	if n.PeerGroup != "" {
+		neighborMap["bgp_peer_group"] = base_uri + "/bgp_neighbors/" + url.PathEscape(n.PeerGroup)
+	} else {
+		neighborMap["bgp_peer_group"] = nil
+	}
+	if n.UpdateSource != "" {
+		neighborMap["local_interface"] = "/rest/" + c.Version + "/system/interfaces/" + url.PathEscape(n.UpdateSource)
+	} else {
+		neighborMap["local_interface"] = nil
+	}
+	if n.Password != "" {
+		neighborMap["password"] = n.Password
+	} else if n.ClearPassword {
+		neighborMap["password"] = nil
+	}
+	return neighborMap
+}
+
+// Create performs POST to create the neighbor on the given Client object.
+// The BgpRouter and the PeerGroup must exist.
+func (n *BgpNeighbor) Create(ctx context.Context, c *Client) error {
+	err := n.normalize("BgpNeighbor.Create")
+	if err != nil {
+		return err
+	}
+
+	base_uri := bgpRoutersURI(n.Vrf) + "/" + strconv.FormatInt(n.Asn, 10) + "/bgp_neighbors"
+	neighbor_str := url.PathEscape(n.Name)
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri
+	n.uri = "/rest/" + c.Version + "/" + base_uri + "/" + neighbor_str
+
+	postMap := n.neighborMap(c)
+	postMap["ip_or_ifname_or_group_name"] = n.Name
+	postMap["is_peer_group"] = n.IsPeerGroup
+
+	postBody, _ := json.Marshal(postMap)
+
+	json_body := bytes.NewBuffer(postBody)
+
+	res, err := post(ctx, c, url, json_body)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusCreated {
+		return newRequestError("BgpNeighbor.Create", res)
+	}
+
+	n.materialized = true
+
+	return nil
+}
+
+// Update performs PATCH to update the neighbor on the given Client object.
+func (n *BgpNeighbor) Update(ctx context.Context, c *Client) error {
+	err := n.normalize("BgpNeighbor.Update")
+	if err != nil {
+		return err
+	}
+
+	neighbor_str := url.PathEscape(n.Name)
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + bgpRoutersURI(n.Vrf) + "/" +
+		strconv.FormatInt(n.Asn, 10) + "/bgp_neighbors/" + neighbor_str
+
+	patchBody, _ := json.Marshal(n.neighborMap(c))
+
+	json_body := bytes.NewBuffer(patchBody)
+
+	res, err := patch(ctx, c, url, json_body)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusNoContent {
+		return newRequestError("BgpNeighbor.Update", res)
+	}
+
+	return nil
+}
+
+// Delete performs DELETE to remove the neighbor from the given Client
+// object.
+func (n *BgpNeighbor) Delete(ctx context.Context, c *Client) error {
+	err := n.normalizeKey("BgpNeighbor.Delete")
+	if err != nil {
+		return err
+	}
+
+	neighbor_str := url.PathEscape(n.Name)
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + bgpRoutersURI(n.Vrf) + "/" +
+		strconv.FormatInt(n.Asn, 10) + "/bgp_neighbors/" + neighbor_str
+
+	res, err := delete(ctx, c, url)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusNoContent && res.StatusCode != http.StatusOK {
+		return newRequestError("BgpNeighbor.Delete", res)
+	}
+
+	n.materialized = false
+
+	return nil
+}
+
+// Get performs GET to retrieve the neighbor from the given Client object.
+func (n *BgpNeighbor) Get(ctx context.Context, c *Client) error {
+	err := n.normalizeKey("BgpNeighbor.Get")
+	if err != nil {
+		return err
+	}
+
+	base_uri := bgpRoutersURI(n.Vrf) + "/" + strconv.FormatInt(n.Asn, 10) + "/bgp_neighbors"
+	neighbor_str := url.PathEscape(n.Name)
+	n.uri = "/rest/" + c.Version + "/" + base_uri + "/" + neighbor_str
+
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri + "/" + neighbor_str
+
+	res, body, err := get(ctx, c, url)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		n.materialized = false
+		return newRequestError("BgpNeighbor.Get", res)
+	}
+
+	payload := bgpNeighborPayload{}
+	err = json.NewDecoder(res.Body).Decode(&payload)
+	if err != nil {
+		return fmt.Errorf("BgpNeighbor.Get: decoding %s: %w", n.Name, err)
+	}
+	payload.decode(n)
+
+	if n.BgpNeighborDetails == nil {
+		n.BgpNeighborDetails = map[string]interface{}{}
+	}
+	for key, value := range body {
+		n.BgpNeighborDetails[key] = value
+	}
+
+	n.materialized = true
+
+	return nil
+}
+
+// GetStatus returns True if the neighbor exists on Client object or False
+// if not.
+func (n *BgpNeighbor) GetStatus() bool {
+	return n.materialized
+}
+
+// GetURI returns URI of the neighbor.
+func (n *BgpNeighbor) GetURI() string {
+	return n.uri
+}
+
+// bgpNeighborPayload is the neighbor as returned by the switch.
+type bgpNeighborPayload struct {
+	IsPeerGroup    bool                       `json:"is_peer_group"`
+	RemoteAs       *int64                     `json:"remote_as"`
+	Description    *string                    `json:"description"`
+	Shutdown       bool                       `json:"shutdown"`
+	BgpPeerGroup   json.RawMessage            `json:"bgp_peer_group"`
+	LocalInterface json.RawMessage            `json:"local_interface"`
+	BfdEnable      bool                       `json:"bfd_enable"`
+	Timers         map[string]int             `json:"timers"`
+	Activate       map[string]bool            `json:"activate"`
+	RouteMapIn     map[string]json.RawMessage `json:"route_map_in"`
+	RouteMapOut    map[string]json.RawMessage `json:"route_map_out"`
+}
+
+// decode sets the fields of n from the payload.
+func (p *bgpNeighborPayload) decode(n *BgpNeighbor) {
+	n.IsPeerGroup = p.IsPeerGroup
+	n.RemoteAs = 0
+	if p.RemoteAs != nil {
+		n.RemoteAs = *p.RemoteAs
+	}
+	n.Description = ""
+	if p.Description != nil {
+		n.Description = *p.Description
+	}
+	n.Shutdown = p.Shutdown
+	n.Bfd = p.BfdEnable
+	n.KeepaliveTimer = p.Timers["keepalive"]
+	n.HoldTimer = p.Timers["holdtime"]
+
+	n.PeerGroup = ""
+	if keys := referenceKeys(p.BgpPeerGroup); len(keys) > 0 {
+		n.PeerGroup = keys[0]
+	}
+	n.UpdateSource = ""
+	if keys := referenceKeys(p.LocalInterface); len(keys) > 0 {
+		n.UpdateSource = keys[0]
+	}
+
+	n.AddressFamilies = []BgpAddressFamily{}
+	for name, active := range p.Activate {
+		if !active {
+			continue
+		}
+		family := BgpAddressFamily{Name: name}
+		if keys := referenceKeys(p.RouteMapIn[name]); len(keys) > 0 {
+			family.RouteMapIn = keys[0]
+		}
+		if keys := referenceKeys(p.RouteMapOut[name]); len(keys) > 0 {
+			family.RouteMapOut = keys[0]
+		}
+		n.AddressFamilies = append(n.AddressFamilies, family)
+	}
+	sort.Slice(n.AddressFamilies, func(a, b int) bool {
+		return n.AddressFamilies[a].Name < n.AddressFamilies[b].Name
+	})
+}
+
+// ListBgpNeighbors performs GET to retrieve the neighbors and peer groups of
+// the BgpRouter of a VRF, "default" if empty, from the given Client object,
+// sorted by name.
+func ListBgpNeighbors(ctx context.Context, c *Client, vrf string, asn int64) ([]BgpNeighbor, error) {
+	err := normalizeBgp("ListBgpNeighbors", &vrf, asn)
+	if err != nil {
+		return nil, err
+	}
+
+	base_uri := bgpRoutersURI(vrf) + "/" + strconv.FormatInt(asn, 10) + "/bgp_neighbors"
+	url_str := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri +
+		"?depth=2&attributes=ip_or_ifname_or_group_name,is_peer_group,remote_as,description,shutdown," +
+		"bgp_peer_group,local_interface,bfd_enable,timers,activate,route_map_in,route_map_out"
+
+	res, _, err := get(ctx, c, url_str)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, newRequestError("ListBgpNeighbors", res)
+	}
+
+	payloads := map[string]bgpNeighborPayload{}
+	err = json.NewDecoder(res.Body).Decode(&payloads)
+	if err != nil {
+		return nil, fmt.Errorf("ListBgpNeighbors: decoding neighbors: %w", err)
+	}
+
+	neighbors := []BgpNeighbor{}
+	for name, payload := range payloads {
+		n := BgpNeighbor{
+			Vrf:          vrf,
+			Asn:          asn,
+			Name:         name,
+			materialized: true,
+			uri:          "/rest/" + c.Version + "/" + base_uri + "/" + url.PathEscape(name),
+		}
+		payload.decode(&n)
+		neighbors = append(neighbors, n)
+	}
+
+	sort.Slice(neighbors, func(a, b int) bool {
+		return neighbors[a].Name < neighbors[b].Name
+	})
+
+	return neighbors, nil
+}
+
+// equal returns true if the settings of other, the desired neighbor, match
+// those returned by Get. The password is not returned, so other never
+// matches when it sets or clears the password.
+func (n *BgpNeighbor) equal(other *BgpNeighbor) bool {
+	return other.Password == "" && !other.ClearPassword && n.IsPeerGroup == other.IsPeerGroup && n.PeerGroup == other.PeerGroup &&
+		n.RemoteAs == other.RemoteAs && n.Description == other.Description &&
+		n.Shutdown == other.Shutdown && n.UpdateSource == other.UpdateSource &&
+		n.Bfd == other.Bfd && n.KeepaliveTimer == other.KeepaliveTimer &&
+		n.HoldTimer == other.HoldTimer && slices.Equal(n.AddressFamilies, other.AddressFamilies)
+}
+
+// ReconcileBgpNeighbors makes the neighbors and peer groups of the BgpRouter
+// of a VRF, "default" if empty, match the given ones by Name: missing ones
+// are created, differing ones updated and others deleted. Peer groups are
+// created before and deleted after their members. Neighbors already as
+// desired are not changed, except that neighbors setting Password or
+// ClearPassword are always updated as the switch does not return passwords.
+// The returned error joins the errors of all failed neighbors.
+func ReconcileBgpNeighbors(ctx context.Context, c *Client, vrf string, asn int64, neighbors []BgpNeighbor) (BgpNeighborChanges, error) {
+	op := "ReconcileBgpNeighbors"
+	changes := BgpNeighborChanges{}
+
+	err := normalizeBgp(op, &vrf, asn)
+	if err != nil {
+		return changes, err
+	}
+
+	desired := map[string]BgpNeighbor{}
+	for _, n := range neighbors {
+		if n.Vrf == "" {
+			n.Vrf = vrf
+		}
+		if n.Asn == 0 {
+			n.Asn = asn
+		}
+		if n.Vrf != vrf || n.Asn != asn {
+			return changes, newValidationError(op, "Vrf", "neighbor "+n.Name+" is not of the router of VRF "+vrf)
+		}
+		n.AddressFamilies = append([]BgpAddressFamily(nil), n.AddressFamilies...)
+		err := n.normalize(op)
+		if err != nil {
+			return changes, err
+		}
+		if _, ok := desired[n.Name]; ok {
+			return changes, newValidationError(op, "Name", "duplicate neighbor "+n.Name)
+		}
+		desired[n.Name] = n
+	}
+
+	existing_neighbors, err := ListBgpNeighbors(ctx, c, vrf, asn)
+	if err != nil {
+		return changes, err
+	}
+	existing := map[string]BgpNeighbor{}
+	for _, n := range existing_neighbors {
+		existing[n.Name] = n
+	}
+
+	var errs []error
+
+	// Members before peer groups, a peer group in use cannot be deleted
+	sort.SliceStable(existing_neighbors, func(a, b int) bool {
+		return !existing_neighbors[a].IsPeerGroup && existing_neighbors[b].IsPeerGroup
+	})
+	for _, n := range existing_neighbors {
+		if _, ok := desired[n.Name]; ok {
+			continue
+		}
+		err := n.Delete(ctx, c)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: deleting %s: %w", op, n.Name, err))
+			continue
+		}
+		changes.Deleted = append(changes.Deleted, n.Name)
+	}
+
+	// Peer groups before their members
+	names := []string{}
+	for name := range desired {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(a, b int) bool {
+		if desired[names[a]].IsPeerGroup != desired[names[b]].IsPeerGroup {
+			return desired[names[a]].IsPeerGroup
+		}
+		return names[a] < names[b]
+	})
+
+	for _, name := range names {
+		n := desired[name]
+		current, ok := existing[name]
+		if !ok {
+			err := n.Create(ctx, c)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: creating %s: %w", op, name, err))
+				continue
+			}
+			changes.Created = append(changes.Created, name)
+			continue
+		}
+
+		if current.equal(&n) {
+			continue
+		}
+		if current.IsPeerGroup != n.IsPeerGroup {
+			errs = append(errs, fmt.Errorf("%s: %s: %w", op, name,
+				newValidationError(op, "IsPeerGroup", "cannot change between neighbor and peer group")))
+			continue
+		}
+		err := n.Update(ctx, c)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: updating %s: %w", op, name, err))
+			continue
+		}
+		changes.Updated = append(changes.Updated, name)
+	}
+
+	return changes, errors.Join(errs...)
+}
@@ -0,0 +1,193 @@
+package aoscxgo_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aruba/aoscxgo"
+)
+
+// bgpRouter creates the BGP router of the default VRF with AS 65001.
+func bgpRouter(t *testing.T, c *aoscxgo.Client) {
+	t.Helper()
+	router := aoscxgo.BgpRouter{Asn: 65001}
+	err := router.Create(context.Background(), c)
+	if err != nil {
+		t.Fatalf("BgpRouter.Create: %v", err)
+	}
+}
+
+func TestBgpNeighborCRUD(t *testing.T) {
+	srv, c := connect(t)
+	ctx := context.Background()
+	srv.AddInterface("loopback0")
+	bgpRouter(t, c)
+
+	group := aoscxgo.BgpNeighbor{Asn: 65001, Name: "spines", IsPeerGroup: true, RemoteAs: 65000}
+	err := group.Create(ctx, c)
+	if err != nil {
+		t.Fatalf("Create peer group: %v", err)
+	}
+
+	neighbor := aoscxgo.BgpNeighbor{
+		Asn:            65001,
+		Name:           "10.0.0.1",
+		PeerGroup:      "spines",
+		Description:    "spine1",
+		UpdateSource:   "loopback0",
+		Bfd:            true,
+		Password:       "secret",
+		KeepaliveTimer: 10,
+		HoldTimer:      30,
+		AddressFamilies: []aoscxgo.BgpAddressFamily{
+			{Name: "l2vpn-evpn"},
+			{Name: "ipv4-unicast", RouteMapIn: "in", RouteMapOut: "out"},
+		},
+	}
+	err = neighbor.Create(ctx, c)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	got := aoscxgo.BgpNeighbor{Asn: 65001, Name: "10.0.0.1"}
+	err = got.Get(ctx, c)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.IsPeerGroup || got.PeerGroup != "spines" || got.RemoteAs != 0 || got.Description != "spine1" ||
+		got.UpdateSource != "loopback0" || !got.Bfd || got.Password != "" ||
+		got.KeepaliveTimer != 10 || got.HoldTimer != 30 || len(got.AddressFamilies) != 2 ||
+		got.AddressFamilies[0] != (aoscxgo.BgpAddressFamily{Name: "ipv4-unicast", RouteMapIn: "in", RouteMapOut: "out"}) ||
+		got.AddressFamilies[1] != (aoscxgo.BgpAddressFamily{Name: "l2vpn-evpn"}) {
+		t.Errorf("Get = %+v", got)
+	}
+
+	neighbor.Shutdown = true
+	neighbor.AddressFamilies = []aoscxgo.BgpAddressFamily{{Name: "l2vpn-evpn"}}
+	err = neighbor.Update(ctx, c)
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	obj, _ := srv.Object("system/vrfs/default/bgp_routers/65001/bgp_neighbors", "10.0.0.1")
+	if obj["password"] != "secret" {
+		t.Errorf("password after Update = %v, want it kept", obj["password"])
+	}
+
+	neighbor.Password = ""
+	neighbor.ClearPassword = true
+	err = neighbor.Update(ctx, c)
+	if err != nil {
+		t.Fatalf("Update clearing the password: %v", err)
+	}
+	obj, _ = srv.Object("system/vrfs/default/bgp_routers/65001/bgp_neighbors", "10.0.0.1")
+	if obj["password"] != nil {
+		t.Errorf("password after ClearPassword = %v, want none", obj["password"])
+	}
+
+	neighbors, err := aoscxgo.ListBgpNeighbors(ctx, c, "", 65001)
+	if err != nil {
+		t.Fatalf("ListBgpNeighbors: %v", err)
+	}
+	if len(neighbors) != 2 || neighbors[0].Name != "10.0.0.1" || !neighbors[0].Shutdown ||
+		len(neighbors[0].AddressFamilies) != 1 || neighbors[1].Name != "spines" || !neighbors[1].IsPeerGroup {
+		t.Errorf("ListBgpNeighbors = %+v", neighbors)
+	}
+
+	err = neighbor.Delete(ctx, c)
+	if err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	err = got.Get(ctx, c)
+	if !errors.Is(err, aoscxgo.ErrNotFound) {
+		t.Errorf("Get after Delete = %v, want ErrNotFound", err)
+	}
+}
+
+func TestBgpNeighborValidation(t *testing.T) {
+	_, c := connect(t)
+	ctx := context.Background()
+
+	for _, neighbor := range []aoscxgo.BgpNeighbor{
+		{Asn: 65001, RemoteAs: 65000},
+		{Asn: 65001, Name: "10.0.0.1"},
+		{Asn: 65001, Name: "spines", IsPeerGroup: true, PeerGroup: "others"},
+		{Asn: 65001, Name: "10.0.0.1", RemoteAs: 65000, HoldTimer: 2},
+		{Asn: 65001, Name: "10.0.0.1", RemoteAs: 65000, KeepaliveTimer: 30, HoldTimer: 30},
+		{Asn: 65001, Name: "10.0.0.1", RemoteAs: 65000, AddressFamilies: []aoscxgo.BgpAddressFamily{{Name: "vpnv4"}}},
+		{Asn: 65001, Name: "10.0.0.1", RemoteAs: 65000, Password: "secret", ClearPassword: true},
+	} {
+		err := neighbor.Create(ctx, c)
+		if !errors.Is(err, aoscxgo.ErrValidation) {
+			t.Errorf("Create(%+v) = %v, want ErrValidation", neighbor, err)
+		}
+	}
+}
+
+func TestReconcileBgpNeighbors(t *testing.T) {
+	_, c := connect(t)
+	ctx := context.Background()
+	bgpRouter(t, c)
+
+	neighbors := []aoscxgo.BgpNeighbor{
+		{Name: "10.0.0.1", PeerGroup: "spines"},
+		{Name: "10.0.0.2", PeerGroup: "spines", Password: "secret"},
+		{Name: "spines", IsPeerGroup: true, RemoteAs: 65000},
+	}
+	changes, err := aoscxgo.ReconcileBgpNeighbors(ctx, c, "", 65001, neighbors)
+	if err != nil {
+		t.Fatalf("ReconcileBgpNeighbors: %v", err)
+	}
+	// The peer group is created before its members
+	if strings.Join(changes.Created, ",") != "spines,10.0.0.1,10.0.0.2" || changes.Updated != nil || changes.Deleted != nil {
+		t.Errorf("changes = %+v", changes)
+	}
+
+	var requests []string
+	c.RequestHook = func(ctx context.Context, info *aoscxgo.RequestInfo) {
+		if info.Method != "GET" {
+			requests = append(requests, info.Method+" "+info.URL)
+		}
+	}
+	changes, err = aoscxgo.ReconcileBgpNeighbors(ctx, c, "", 65001, neighbors)
+	c.RequestHook = nil
+	if err != nil {
+		t.Fatalf("ReconcileBgpNeighbors again: %v", err)
+	}
+	// Only the neighbor with a password is sent again, as the switch does
+	// not return it
+	if changes.Created != nil || strings.Join(changes.Updated, ",") != "10.0.0.2" || changes.Deleted != nil ||
+		len(requests) != 1 || !strings.HasPrefix(requests[0], "PATCH ") || !strings.HasSuffix(requests[0], "/bgp_neighbors/10.0.0.2") {
+		t.Errorf("reconciling again changed %+v with requests %v", changes, requests)
+	}
+
+	changes, err = aoscxgo.ReconcileBgpNeighbors(ctx, c, "", 65001, []aoscxgo.BgpNeighbor{
+		{Name: "10.0.0.1", RemoteAs: 65002, Description: "external"},
+	})
+	if err != nil {
+		t.Fatalf("ReconcileBgpNeighbors: %v", err)
+	}
+	// Members are deleted before their peer group
+	if changes.Created != nil || strings.Join(changes.Updated, ",") != "10.0.0.1" ||
+		strings.Join(changes.Deleted, ",") != "10.0.0.2,spines" {
+		t.Errorf("changes = %+v", changes)
+	}
+
+	got, err := aoscxgo.ListBgpNeighbors(ctx, c, "", 65001)
+	if err != nil {
+		t.Fatalf("ListBgpNeighbors: %v", err)
+	}
+	if len(got) != 1 || got[0].RemoteAs != 65002 || got[0].PeerGroup != "" || got[0].Description != "external" {
+		t.Errorf("ListBgpNeighbors = %+v", got)
+	}
+
+	_, err = aoscxgo.ReconcileBgpNeighbors(ctx, c, "", 65001, []aoscxgo.BgpNeighbor{
+		{Name: "10.0.0.1", RemoteAs: 65002},
+		{Name: "10.0.0.1", RemoteAs: 65003},
+	})
+	if !errors.Is(err, aoscxgo.ErrValidation) {
+		t.Errorf("ReconcileBgpNeighbors with duplicates = %v, want ErrValidation", err)
+	}
+}
@@ -0,0 +1,363 @@
+package aoscxgo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// BgpRouter is the BGP router of a VRF. Neighbors are configured with
+// BgpNeighbor.
+type BgpRouter struct {
+
+	// Connection properties.
+	// Vrf is the VRF of the router, defaults to "default".
+	Vrf string `json:"vrf"`
+	// Asn is the local AS number, the same in every VRF of a switch.
+	Asn      int64       `json:"asn"`
+	RouterId string      `json:"router_id"`
+	Bestpath BgpBestpath `json:"bestpath"`
+	// Networks lists the IPv4 and IPv6 prefixes announced by the router
+	// with network statements in their address family, e.g. "10.0.0.0/24".
+	Networks []string `json:"networks"`
+
+	BgpRouterDetails map[string]interface{} `json:"details"`
+	materialized     bool
+	uri              string
+}
+
+// BgpBestpath holds the best path selection options of a BgpRouter.
+type BgpBestpath struct {
+	// AlwaysCompareMed compares the MED of paths from different ASs.
+	AlwaysCompareMed bool `json:"always_compare_med"`
+	// AsPathMultipathRelax allows multipath over paths from different ASs
+	// with AS paths of the same length.
+	AsPathMultipathRelax bool `json:"as_path_multipath_relax"`
+	// CompareRouterId prefers the path with the lowest router ID over the
+	// oldest path.
+	CompareRouterId bool `json:"compare_router_id"`
+	// MedMissingAsWorst treats a missing MED as the worst.
+	MedMissingAsWorst bool `json:"med_missing_as_worst"`
+}
+
+// bgpRoutersURI returns the URI of the BGP routers of the VRF below the REST
+// version.
+func bgpRoutersURI(vrf string) string {
+	return "system/vrfs/" + url.PathEscape(vrf) + "/bgp_routers"
+}
+
+// normalizeBgp validates the VRF and ASN shared by BGP resources and sets
+// their defaults.
+func normalizeBgp(op string, vrf *string, asn int64) error {
+	if *vrf == "" {
+		*vrf = "default"
+	}
+	if asn < 1 || asn > 4294967295 {
+		return newValidationError(op, "Asn", "valid range is 1-4294967295 received: "+strconv.FormatInt(asn, 10))
+	}
+	return nil
+}
+
+// normalize validates the router settings and sets its defaults, with
+// Networks in canonical form. op names the calling operation in the
+// returned error.
+func (b *BgpRouter) normalize(op string) error {
+	err := normalizeBgp(op, &b.Vrf, b.Asn)
+	if err != nil {
+		return err
+	}
+	if b.RouterId != "" && net.ParseIP(b.RouterId).To4() == nil {
+		return newValidationError(op, "RouterId", "expected IPv4 address received: "+b.RouterId)
+	}
+
+	seen := map[string]bool{}
+	for index, prefix := range b.Networks {
+		ip, network, err := net.ParseCIDR(prefix)
+		if err != nil || !ip.Equal(network.IP) {
+			return newValidationError(op, "Networks", "invalid prefix: "+prefix)
+		}
+		if seen[network.String()] {
+			return newValidationError(op, "Networks", "duplicate prefix "+network.String())
+		}
+		seen[network.String()] = true
+		b.Networks[index] = network.String()
+	}
+	return nil
+}
+
+// routerMap returns the attributes of the router to send to the switch.
+func (b *BgpRouter) routerMap() map[string]interface{} {
+	routerMap := map[string]interface{}{
+		"always_compare_med":               b.Bestpath.AlwaysCompareMed,
+		"bestpath_as_path_multipath_relax": b.Bestpath.AsPathMultipathRelax,
+		"bestpath_compare_routerid":        b.Bestpath.CompareRouterId,
+		"bestpath_med_missing_as_worst":    b.Bestpath.MedMissingAsWorst,
+	}
+	if b.RouterId != "" {
+		routerMap["router_id"] = b.RouterId
+	} else {
+		routerMap["router_id"] = nil
+	}
+	return routerMap
+}
+
+// Create performs POST to create the BGP router and its network statements
+// on the given Client object. If a network statement fails the router is
+// deleted again.
+func (b *BgpRouter) Create(ctx context.Context, c *Client) error {
+	err := b.normalize("BgpRouter.Create")
+	if err != nil {
+		return err
+	}
+
+	base_uri := bgpRoutersURI(b.Vrf)
+	asn_str := strconv.FormatInt(b.Asn, 10)
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri
+	b.uri = "/rest/" + c.Version + "/" + base_uri + "/" + asn_str
+
+	postMap := b.routerMap()
+	postMap["asn"] = b.Asn
+
+	postBody, _ := json.Marshal(postMap)
+
+	json_body := bytes.NewBuffer(postBody)
+
+	res, err := post(ctx, c, url, json_body)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusCreated {
+		return newRequestError("BgpRouter.Create", res)
+	}
+
+	err = b.updateNetworks(ctx, c, "BgpRouter.Create", nil)
+	if err != nil {
+		// Do not leave the router behind without its network statements
+		return errors.Join(err, b.Delete(ctx, c))
+	}
+
+	b.materialized = true
+
+	return nil
+}
+
+// Update performs PATCH to update the BGP router on the given Client
+// object. Network statements are created or deleted to match Networks.
+func (b *BgpRouter) Update(ctx context.Context, c *Client) error {
+	err := b.normalize("BgpRouter.Update")
+	if err != nil {
+		return err
+	}
+
+	asn_str := strconv.FormatInt(b.Asn, 10)
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + bgpRoutersURI(b.Vrf) + "/" + asn_str
+
+	patchBody, _ := json.Marshal(b.routerMap())
+
+	json_body := bytes.NewBuffer(patchBody)
+
+	res, err := patch(ctx, c, url, json_body)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusNoContent {
+		return newRequestError("BgpRouter.Update", res)
+	}
+
+	current, err := getBgpNetworks(ctx, c, "BgpRouter.Update", url+"/bgp_networks")
+	if err != nil {
+		return err
+	}
+
+	return b.updateNetworks(ctx, c, "BgpRouter.Update", current)
+}
+
+// updateNetworks creates or deletes the network statements of the router
+// on the switch, holding the current ones, to match Networks.
+func (b *BgpRouter) updateNetworks(ctx context.Context, c *Client, op string, current []string) error {
+	asn_str := strconv.FormatInt(b.Asn, 10)
+	networks_url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + bgpRoutersURI(b.Vrf) + "/" + asn_str + "/bgp_networks"
+
+	existing := map[string]bool{}
+	for _, prefix := range current {
+		existing[prefix] = true
+	}
+	desired := map[string]bool{}
+
+	for _, prefix := range b.Networks {
+		desired[prefix] = true
+		if existing[prefix] {
+			continue
+		}
+
+		address_family := "ipv4-unicast"
+		if strings.Contains(prefix, ":") {
+			address_family = "ipv6-unicast"
+		}
+
+		postBody, _ := json.Marshal(map[string]interface{}{
+			"ip_prefix":      prefix,
+			"address_family": address_family,
+		})
+
+		res, err := post(ctx, c, networks_url, bytes.NewBuffer(postBody))
+		if err != nil {
+			return err
+		}
+
+		if res.StatusCode != http.StatusCreated {
+			return newRequestError(op, res)
+		}
+	}
+
+	for _, prefix := range current {
+		if desired[prefix] {
+			continue
+		}
+
+		res, err := delete(ctx, c, networks_url+"/"+url.PathEscape(prefix))
+		if err != nil {
+			return err
+		}
+
+		if res.StatusCode != http.StatusNoContent && res.StatusCode != http.StatusOK {
+			return newRequestError(op, res)
+		}
+	}
+
+	return nil
+}
+
+// Delete performs DELETE to remove the BGP router with its neighbors and
+// network statements from the given Client object.
+func (b *BgpRouter) Delete(ctx context.Context, c *Client) error {
+	err := normalizeBgp("BgpRouter.Delete", &b.Vrf, b.Asn)
+	if err != nil {
+		return err
+	}
+
+	asn_str := strconv.FormatInt(b.Asn, 10)
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + bgpRoutersURI(b.Vrf) + "/" + asn_str
+
+	res, err := delete(ctx, c, url)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusNoContent && res.StatusCode != http.StatusOK {
+		return newRequestError("BgpRouter.Delete", res)
+	}
+
+	b.materialized = false
+
+	return nil
+}
+
+// Get performs GET to retrieve the BGP router and its network statements
+// from the given Client object.
+func (b *BgpRouter) Get(ctx context.Context, c *Client) error {
+	err := normalizeBgp("BgpRouter.Get", &b.Vrf, b.Asn)
+	if err != nil {
+		return err
+	}
+
+	base_uri := bgpRoutersURI(b.Vrf)
+	asn_str := strconv.FormatInt(b.Asn, 10)
+	b.uri = "/rest/" + c.Version + "/" + base_uri + "/" + asn_str
+
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri + "/" + asn_str
+
+	res, body, err := get(ctx, c, url)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		b.materialized = false
+		return newRequestError("BgpRouter.Get", res)
+	}
+
+	payload := struct {
+		RouterId             *string `json:"router_id"`
+		AlwaysCompareMed     bool    `json:"always_compare_med"`
+		AsPathMultipathRelax bool    `json:"bestpath_as_path_multipath_relax"`
+		CompareRouterId      bool    `json:"bestpath_compare_routerid"`
+		MedMissingAsWorst    bool    `json:"bestpath_med_missing_as_worst"`
+	}{}
+	err = json.NewDecoder(res.Body).Decode(&payload)
+	if err != nil {
+		return fmt.Errorf("BgpRouter.Get: decoding router %d: %w", b.Asn, err)
+	}
+
+	b.RouterId = ""
+	if payload.RouterId != nil {
+		b.RouterId = *payload.RouterId
+	}
+	b.Bestpath = BgpBestpath{
+		AlwaysCompareMed:     payload.AlwaysCompareMed,
+		AsPathMultipathRelax: payload.AsPathMultipathRelax,
+		CompareRouterId:      payload.CompareRouterId,
+		MedMissingAsWorst:    payload.MedMissingAsWorst,
+	}
+
+	b.Networks, err = getBgpNetworks(ctx, c, "BgpRouter.Get", url+"/bgp_networks")
+	if err != nil {
+		return err
+	}
+
+	if b.BgpRouterDetails == nil {
+		b.BgpRouterDetails = map[string]interface{}{}
+	}
+	for key, value := range body {
+		b.BgpRouterDetails[key] = value
+	}
+
+	b.materialized = true
+
+	return nil
+}
+
+// GetStatus returns True if the BGP router exists on Client object or False
+// if not.
+func (b *BgpRouter) GetStatus() bool {
+	return b.materialized
+}
+
+// GetURI returns URI of the BGP router.
+func (b *BgpRouter) GetURI() string {
+	return b.uri
+}
+
+// getBgpNetworks performs GET to retrieve the prefixes of the network
+// statements of a router at the given URL, sorted.
+func getBgpNetworks(ctx context.Context, c *Client, op string, url_str string) ([]string, error) {
+	res, body, err := get(ctx, c, url_str)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, newRequestError(op, res)
+	}
+
+	networks := []string{}
+	for prefix := range body {
+		if unescaped, err := url.PathUnescape(prefix); err == nil {
+			prefix = unescaped
+		}
+		networks = append(networks, prefix)
+	}
+	sort.Strings(networks)
+
+	return networks, nil
+}
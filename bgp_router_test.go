@@ -0,0 +1,112 @@
+package aoscxgo_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/aruba/aoscxgo"
+)
+
+func TestBgpRouterCRUD(t *testing.T) {
+	_, c := connect(t)
+	ctx := context.Background()
+
+	router := aoscxgo.BgpRouter{
+		Asn:      65001,
+		RouterId: "10.0.0.1",
+		Bestpath: aoscxgo.BgpBestpath{AsPathMultipathRelax: true},
+		Networks: []string{"10.1.0.0/24", "2001:db8::/64"},
+	}
+	err := router.Create(ctx, c)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	got := aoscxgo.BgpRouter{Asn: 65001}
+	err = got.Get(ctx, c)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.Vrf != "default" || got.RouterId != "10.0.0.1" || !got.Bestpath.AsPathMultipathRelax ||
+		strings.Join(got.Networks, ",") != "10.1.0.0/24,2001:db8::/64" {
+		t.Errorf("Get = %+v", got)
+	}
+
+	var requests []string
+	c.RequestHook = func(ctx context.Context, info *aoscxgo.RequestInfo) {
+		if info.Method != "GET" && strings.Contains(info.URL, "/bgp_networks") {
+			requests = append(requests, info.Method+" "+info.URL[strings.Index(info.URL, "/bgp_networks"):])
+		}
+	}
+	router.Networks = []string{"10.1.0.0/24", "10.2.0.0/24"}
+	err = router.Update(ctx, c)
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	c.RequestHook = nil
+	if strings.Join(requests, "\n") != "POST /bgp_networks\nDELETE /bgp_networks/2001:db8::%2F64" {
+		t.Errorf("Update sent:\n%s", strings.Join(requests, "\n"))
+	}
+
+	err = got.Get(ctx, c)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if strings.Join(got.Networks, ",") != "10.1.0.0/24,10.2.0.0/24" {
+		t.Errorf("Networks after Update = %v", got.Networks)
+	}
+
+	err = router.Delete(ctx, c)
+	if err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	err = got.Get(ctx, c)
+	if !errors.Is(err, aoscxgo.ErrNotFound) {
+		t.Errorf("Get after Delete = %v, want ErrNotFound", err)
+	}
+}
+
+func TestBgpRouterValidation(t *testing.T) {
+	_, c := connect(t)
+	ctx := context.Background()
+
+	for _, router := range []aoscxgo.BgpRouter{
+		{Asn: 0},
+		{Asn: 4294967296},
+		{Asn: 65001, RouterId: "2001:db8::1"},
+		{Asn: 65001, Networks: []string{"10.1.0.1/24"}},
+		{Asn: 65001, Networks: []string{"10.1.0.0/24", "10.1.0.0/24"}},
+	} {
+		err := router.Create(ctx, c)
+		if !errors.Is(err, aoscxgo.ErrValidation) {
+			t.Errorf("Create(%+v) = %v, want ErrValidation", router, err)
+		}
+	}
+}
+
+func TestBgpRouterCreateRollback(t *testing.T) {
+	srv, c := connect(t)
+	ctx := context.Background()
+	c.HTTPClient = &http.Client{
+		Jar:       c.HTTPClient.Jar,
+		Transport: failingTransport{transport: c.HTTPClient.Transport, method: "POST", suffix: "/bgp_networks"},
+	}
+
+	router := aoscxgo.BgpRouter{Asn: 65001, Networks: []string{"10.1.0.0/24"}}
+	err := router.Create(ctx, c)
+	var request_error *aoscxgo.RequestError
+	if !errors.As(err, &request_error) || request_error.StatusCode != http.StatusBadRequest {
+		t.Fatalf("Create = %v, want the failed network statement", err)
+	}
+
+	// The router is not left behind without its network statements
+	if got := srv.Objects("system/vrfs/default/bgp_routers"); len(got) != 0 {
+		t.Errorf("BGP routers after failed Create = %v", got)
+	}
+	if router.GetStatus() {
+		t.Error("GetStatus after failed Create = true")
+	}
+}
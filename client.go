@@ -1,104 +1,229 @@
 package aoscxgo
 
 import (
+	"context"
 	"crypto/tls"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
 )
 
+// Client holds the connection to a switch. Set the connection properties and
+// options before calling Connect, after Connect returns a Client is safe for
+// concurrent use by multiple goroutines.
 type Client struct {
 	// Connection properties.
 	Hostname string `json:"hostname"`
 	Username string `json:"username"`
-	Password string `json:"password"`
-	Version  string `json:"version"`
+	Password string `json:"-"`
+	// Credentials, when set, supplies the username and password instead of
+	// the Username and Password fields.
+	Credentials CredentialProvider `json:"-"`
+	// Version is the REST API version to use, e.g. "v10.09". If empty the
+	// highest version supported by both the switch and this package is
+	// negotiated by Connect.
+	Version string `json:"version"`
 	// Generated after Connect
-	Cookie *http.Cookie `json:"cookie"`
-	Csrf   string       `json:"Csrf"`
+	Cookie          *http.Cookie `json:"cookie"`
+	Csrf            string       `json:"Csrf"`
+	FirmwareVersion string       `json:"firmware_version"`
+	Platform        string       `json:"platform"`
 	// HTTP transport options.  Note that the VerifyCertificate setting is
-	// only used if you do not specify a HTTP transport yourself.
-	VerifyCertificate bool            `json:"verify_certificate"`
-	Transport         *http.Transport `json:"-"`
+	// only used if you do not specify a HTTP transport yourself, and
+	// Transport and Timeout are only used if you do not specify a HTTPClient
+	// yourself. The HTTPClient built by Connect pools connections to the
+	// switch and stores the session cookie in a cookie jar.
+	VerifyCertificate bool              `json:"verify_certificate"`
+	Transport         http.RoundTripper `json:"-"`
+	Timeout           time.Duration     `json:"timeout"`
+	HTTPClient        *http.Client      `json:"-"`
+	// Session options. When KeepaliveInterval is set a background request
+	// is sent at that interval to keep the session from idling out until
+	// Logout is called. MaxSessions caps the number of concurrent sessions
+	// held by Clients of this process to the same switch and user, Connect
+	// waits for a free slot when the cap is reached.
+	KeepaliveInterval time.Duration `json:"keepalive_interval"`
+	MaxSessions       int           `json:"max_sessions"`
+	// Request limits, read by Connect. RateLimit caps the requests sent to
+	// the switch per second with bursts of up to RateBurst requests, and
+	// MaxInFlight caps the number of requests waiting for an answer. Zero
+	// values disable the limits.
+	RateLimit   float64 `json:"rate_limit"`
+	RateBurst   int     `json:"rate_burst"`
+	MaxInFlight int     `json:"max_in_flight"`
+	// RetryPolicy retries requests that fail transiently, nothing is
+	// retried when it is nil. See DefaultRetryPolicy.
+	RetryPolicy *RetryPolicy `json:"-"`
+	// Logging and tracing options. Logger receives the log output of the
+	// package, nothing is logged when it is nil. Every request is logged at
+	// debug level. RequestHook and ResponseHook are called before and after
+	// each request sent to the switch with passwords and secrets redacted
+	// from the bodies.
+	Logger       *slog.Logger                                  `json:"-"`
+	RequestHook  func(ctx context.Context, info *RequestInfo)  `json:"-"`
+	ResponseHook func(ctx context.Context, info *ResponseInfo) `json:"-"`
+
+	// session guards Cookie and Csrf while they are refreshed.
+	session sync.RWMutex
+	// state guards the keepalive, session slot and request limits.
+	state          sync.Mutex
+	stop_keepalive chan struct{}
+	slot_held      bool
+	rate_limiter   *tokenBucket
+	in_flight      chan struct{}
 }
 
-// Connect creates connection to given Client object.
-func Connect(c *Client) (*Client, error) {
+// Connect creates connection to given Client object. The context bounds the
+// login request.
+func Connect(ctx context.Context, c *Client) (*Client, error) {
 	var err error
 
-	tr := &http.Transport{
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: !c.VerifyCertificate},
+	c.initLimits()
+
+	if c.HTTPClient == nil {
+		if c.Transport == nil {
+			c.Transport = &http.Transport{
+				Proxy:               http.ProxyFromEnvironment,
+				TLSClientConfig:     &tls.Config{InsecureSkipVerify: !c.VerifyCertificate},
+				MaxIdleConnsPerHost: 10,
+				IdleConnTimeout:     90 * time.Second,
+			}
+		}
+
+		jar, err := cookiejar.New(nil)
+		if err != nil {
+			return nil, err
+		}
+
+		c.HTTPClient = &http.Client{
+			Transport: c.Transport,
+			Jar:       jar,
+			Timeout:   c.Timeout,
+		}
 	}
 
-	if c.Version == "" || (c.Version != "v10.09" && c.Version == "v10.10") {
-		c.Version = "v10.09"
+	c.Version, err = negotiateVersion(ctx, c, c.Version)
+	if err != nil {
+		return nil, err
 	}
 
-	if c.Transport == nil {
-		c.Transport = tr
+	err = acquireSession(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	username, password, err := c.credentials(ctx)
+	if err != nil {
+		releaseSession(c)
+		return nil, err
 	}
 
-	cookie, csrf, err := login(c.Transport, c.Hostname, c.Version, c.Username, c.Password)
+	cookie, csrf, err := login(ctx, c, username, password)
 
 	if err != nil {
+		releaseSession(c)
 		return nil, err
 	}
-	c.Cookie = cookie
-	c.Csrf = csrf
+	c.setSession(cookie, csrf)
+
+	err = c.getFirmware(ctx)
+	if err != nil {
+		c.Logout(ctx)
+		return nil, err
+	}
+
+	if c.KeepaliveInterval > 0 {
+		c.startKeepalive()
+	}
 	return c, err
 }
 
 // Logout calls the logout endpoint to clear the session.
-func (c *Client) Logout() error {
+func (c *Client) Logout(ctx context.Context) error {
 	if c == nil {
 		return errors.New("nil value to Logout")
 	}
+	c.stopKeepalive()
+	defer releaseSession(c)
+
+	cookie, csrf := c.getSession()
 	url := fmt.Sprintf("https://%s/rest/%s/logout", c.Hostname, c.Version)
-	resp := logout(c.Transport, c.Cookie, c.Csrf, url)
+	resp, err := logout(ctx, c, cookie, csrf, url)
+	if err != nil {
+		return err
+	}
 	if resp.StatusCode != http.StatusOK {
-		return errors.New(resp.Status)
+		return newRequestError("Client.Logout", resp)
 	}
 	return nil
 }
 
-// login performs POST to create a cookie for authentication to the given IP with the provided credentials.
-func login(http_transport *http.Transport, ip string, rest_version string, username string, password string) (*http.Cookie, string, error) {
-	url := fmt.Sprintf("https://%s/rest/%s/login?username=%s&password=%s", ip, rest_version, username, password)
-	req, _ := http.NewRequest("POST", url, nil)
+// login performs POST to create a cookie for authentication to the switch of the Client with the provided credentials.
+func login(ctx context.Context, c *Client, username string, password string) (*http.Cookie, string, error) {
+	ip := c.Hostname
+	login_url := fmt.Sprintf("https://%s/rest/%s/login", ip, c.Version)
+	// Credentials are sent form encoded in the body so they are escaped and
+	// kept out of URLs that end up in proxy and access logs
+	form := url.Values{}
+	form.Set("username", username)
+	form.Set("password", password)
+
+	req, err := http.NewRequestWithContext(ctx, "POST", login_url, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, "", fmt.Errorf("login to %s: %w", ip, err)
+	}
 	req.Header.Set("accept", "*/*")
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 	req.Header.Set("x-use-csrf-token", "true")
 	req.Close = false
 
-	res, err := http_transport.RoundTrip(req)
-	if res.Status != "200 OK" {
-		log.Fatalf("Got error while connecting to switch %s Error %s", res.Status, err)
-		return nil, "", err
+	res, err := roundTrip(c, req)
+	if err != nil {
+		return nil, "", fmt.Errorf("login to %s: %w", ip, err)
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, "", newRequestError("Client.login", res)
 	}
+	defer res.Body.Close()
 
-	fmt.Println("Login Successful")
+	csrf := res.Header.Get("X-Csrf-Token")
+	cookies := res.Cookies()
+	if len(cookies) == 0 {
+		return nil, "", fmt.Errorf("login to %s: missing session cookie in response", ip)
+	}
 
-	csrf := res.Header["X-Csrf-Token"][0]
-	cookie := res.Cookies()[0]
+	c.logger().InfoContext(ctx, "aoscxgo login successful", "hostname", ip, "version", c.Version)
 
-	return cookie, csrf, err
+	return cookies[0], csrf, nil
 }
 
 // logout performs POST to logout using a cookie from the given URL.
-func logout(http_transport *http.Transport, cookie *http.Cookie, csrf string, url string) *http.Response {
-	req, _ := http.NewRequest("POST", url, nil)
+func logout(ctx context.Context, c *Client, cookie *http.Cookie, csrf string, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("logout: %w", err)
+	}
 	req.Header.Set("accept", "*/*")
 	req.Header.Set("x-csrf-token", csrf)
 	req.Close = false
 
-	req.AddCookie(cookie)
-	res, err := http_transport.RoundTrip(req)
-	//Handle Error
-	if res.Status != "200 OK" {
-		log.Fatalf("Got error while logging out of switch %s Error %s", res.Status, err)
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+	res, err := roundTrip(c, req)
+	if err != nil {
+		return nil, fmt.Errorf("logout: %w", err)
+	}
+	if res.StatusCode == http.StatusOK {
+		res.Body.Close()
+		c.logger().InfoContext(ctx, "aoscxgo logout successful", "hostname", c.Hostname)
 	}
 
-	fmt.Println("Logout Successful")
-
-	return res
+	return res, nil
 }
@@ -0,0 +1,281 @@
+package aoscxgo_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptrace"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aruba/aoscxgo"
+	"github.com/aruba/aoscxgo/aoscxtest"
+)
+
+// connect starts a fake switch and connects a Client to it, both are closed
+// when the test ends.
+func connect(t *testing.T) (*aoscxtest.Server, *aoscxgo.Client) {
+	t.Helper()
+
+	srv := aoscxtest.NewServer()
+	t.Cleanup(srv.Close)
+
+	c, err := aoscxgo.Connect(context.Background(), srv.Client())
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	t.Cleanup(func() { c.Logout(context.Background()) })
+
+	return srv, c
+}
+
+func TestConnect(t *testing.T) {
+	_, c := connect(t)
+
+	if c.Version != aoscxtest.DefaultVersion {
+		t.Errorf("Version = %q, want %q", c.Version, aoscxtest.DefaultVersion)
+	}
+	if c.FirmwareVersion != aoscxtest.DefaultFirmwareVersion {
+		t.Errorf("FirmwareVersion = %q, want %q", c.FirmwareVersion, aoscxtest.DefaultFirmwareVersion)
+	}
+	if c.Platform != aoscxtest.DefaultPlatform {
+		t.Errorf("Platform = %q, want %q", c.Platform, aoscxtest.DefaultPlatform)
+	}
+	if c.Cookie == nil || c.Csrf == "" {
+		t.Errorf("Cookie = %v, Csrf = %q, want a session", c.Cookie, c.Csrf)
+	}
+}
+
+func TestConnectVersion(t *testing.T) {
+	srv := aoscxtest.NewServer()
+	defer srv.Close()
+
+	client := srv.Client()
+	client.Version = "v10.08"
+	c, err := aoscxgo.Connect(context.Background(), client)
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer c.Logout(context.Background())
+
+	if c.Version != "v10.08" {
+		t.Errorf("Version = %q, want v10.08", c.Version)
+	}
+}
+
+func TestConnectBadPassword(t *testing.T) {
+	srv := aoscxtest.NewServer()
+	defer srv.Close()
+
+	client := srv.Client()
+	client.Password = "wrong"
+	_, err := aoscxgo.Connect(context.Background(), client)
+	if !errors.Is(err, aoscxgo.ErrUnauthorized) {
+		t.Fatalf("Connect = %v, want ErrUnauthorized", err)
+	}
+}
+
+func TestSessionExpiry(t *testing.T) {
+	srv, c := connect(t)
+	ctx := context.Background()
+
+	cookie := c.Cookie
+	srv.ExpireSessions()
+
+	vlan := aoscxgo.Vlan{VlanId: 10, Name: "users"}
+	err := vlan.Create(ctx, c)
+	if err != nil {
+		t.Fatalf("Create after session expiry: %v", err)
+	}
+	if c.Cookie == cookie {
+		t.Error("session cookie was not renewed")
+	}
+	if _, ok := srv.Object("system/vlans", "10"); !ok {
+		t.Error("VLAN 10 was not created")
+	}
+}
+
+func TestInvalidCsrf(t *testing.T) {
+	srv, c := connect(t)
+	ctx := context.Background()
+
+	// A request with a stale token is rejected and replayed after login
+	c.Csrf = "stale"
+
+	vlan := aoscxgo.Vlan{VlanId: 10, Name: "users"}
+	err := vlan.Create(ctx, c)
+	if err != nil {
+		t.Fatalf("Create with stale CSRF token: %v", err)
+	}
+	if c.Csrf == "stale" {
+		t.Error("CSRF token was not renewed")
+	}
+	if _, ok := srv.Object("system/vlans", "10"); !ok {
+		t.Error("VLAN 10 was not created")
+	}
+}
+
+func TestLogout(t *testing.T) {
+	srv := aoscxtest.NewServer()
+	defer srv.Close()
+	ctx := context.Background()
+
+	c, err := aoscxgo.Connect(ctx, srv.Client())
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+
+	err = c.Logout(ctx)
+	if err != nil {
+		t.Fatalf("Logout: %v", err)
+	}
+}
+
+func TestConnectCanceled(t *testing.T) {
+	srv := aoscxtest.NewServer()
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := aoscxgo.Connect(ctx, srv.Client())
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Connect = %v, want context.Canceled", err)
+	}
+}
+
+func TestRequestCanceled(t *testing.T) {
+	srv, c := connect(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	vlan := aoscxgo.Vlan{VlanId: 10, Name: "users"}
+	err := vlan.Create(ctx, c)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Create = %v, want context.Canceled", err)
+	}
+	if _, ok := srv.Object("system/vlans", "10"); ok {
+		t.Error("VLAN 10 was created with a canceled context")
+	}
+}
+
+func TestUnreachable(t *testing.T) {
+	srv := aoscxtest.NewServer()
+	client := srv.Client()
+	c, err := aoscxgo.Connect(context.Background(), client)
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+
+	// The switch going away fails requests instead of the process
+	srv.Close()
+
+	vlan := aoscxgo.Vlan{VlanId: 10}
+	err = vlan.Get(context.Background(), c)
+	if err == nil {
+		t.Fatal("Get from an unreachable switch succeeded")
+	}
+	if c.Logout(context.Background()) == nil {
+		t.Error("Logout from an unreachable switch succeeded")
+	}
+
+	_, err = aoscxgo.Connect(context.Background(), &aoscxgo.Client{
+		Hostname: srv.Listener.Addr().String(),
+		Username: aoscxtest.DefaultUsername,
+		Password: aoscxtest.DefaultPassword,
+	})
+	if err == nil {
+		t.Fatal("Connect to an unreachable switch succeeded")
+	}
+}
+
+func TestConnectHTTPClient(t *testing.T) {
+	srv := aoscxtest.NewServer()
+	defer srv.Close()
+	ctx := context.Background()
+
+	// Without HTTPClient, Connect builds one on the Transport with a cookie
+	// jar and the Timeout
+	client := srv.Client()
+	transport := client.HTTPClient.Transport
+	client.HTTPClient = nil
+	client.Transport = transport
+	client.Timeout = 5 * time.Second
+	c, err := aoscxgo.Connect(ctx, client)
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer c.Logout(ctx)
+
+	if c.HTTPClient == nil || c.HTTPClient.Transport != transport || c.HTTPClient.Jar == nil || c.HTTPClient.Timeout != 5*time.Second {
+		t.Errorf("HTTPClient = %+v, want the Transport with a cookie jar and timeout", c.HTTPClient)
+	}
+}
+
+func TestInjectedHTTPClient(t *testing.T) {
+	srv := aoscxtest.NewServer()
+	defer srv.Close()
+	ctx := context.Background()
+
+	// An injected HTTPClient without cookie jar is used for every request
+	// and the session cookie is attached by the package
+	requests := atomic.Int32{}
+	client := srv.Client()
+	transport := client.HTTPClient.Transport
+	client.HTTPClient = &http.Client{
+		Transport: transportFunc(func(req *http.Request) (*http.Response, error) {
+			requests.Add(1)
+			return transport.RoundTrip(req)
+		}),
+	}
+	c, err := aoscxgo.Connect(ctx, client)
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer c.Logout(ctx)
+
+	sent := requests.Load()
+	vlan := aoscxgo.Vlan{VlanId: 1}
+	err = vlan.Get(ctx, c)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if requests.Load() != sent+1 {
+		t.Errorf("sent %d requests through the HTTPClient, want 1", requests.Load()-sent)
+	}
+}
+
+func TestConnectionReuse(t *testing.T) {
+	_, c := connect(t)
+
+	// Bodies are drained and closed so every request reuses the
+	// connection, including those answered with an error
+	new_connections := 0
+	ctx := httptrace.WithClientTrace(context.Background(), &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			if !info.Reused {
+				new_connections++
+			}
+		},
+	})
+
+	for i := 0; i < 20; i++ {
+		vlan := aoscxgo.Vlan{VlanId: 1}
+		err := vlan.Get(ctx, c)
+		if err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+
+		missing := aoscxgo.Vlan{VlanId: 99}
+		err = missing.Get(ctx, c)
+		if !errors.Is(err, aoscxgo.ErrNotFound) {
+			t.Fatalf("Get missing VLAN = %v, want ErrNotFound", err)
+		}
+	}
+
+	if new_connections != 0 {
+		t.Errorf("opened %d connections, want the connection of Connect reused", new_connections)
+	}
+}
@@ -0,0 +1,95 @@
+package aoscxgo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// CredentialProvider supplies the username and password used to login to
+// the switch. It is called on Connect and whenever the session has to be
+// re-established, so credentials may be rotated between calls.
+type CredentialProvider interface {
+	Credentials(ctx context.Context) (username string, password string, err error)
+}
+
+// CredentialsFunc adapts a function to a CredentialProvider.
+type CredentialsFunc func(ctx context.Context) (string, string, error)
+
+// Credentials calls f.
+func (f CredentialsFunc) Credentials(ctx context.Context) (string, string, error) {
+	return f(ctx)
+}
+
+// EnvCredentials reads the credentials from environment variables.
+// UsernameVar and PasswordVar default to AOSCX_USERNAME and AOSCX_PASSWORD.
+type EnvCredentials struct {
+	UsernameVar string
+	PasswordVar string
+}
+
+// Credentials returns the values of the environment variables.
+func (e EnvCredentials) Credentials(ctx context.Context) (string, string, error) {
+	username_var := e.UsernameVar
+	if username_var == "" {
+		username_var = "AOSCX_USERNAME"
+	}
+	password_var := e.PasswordVar
+	if password_var == "" {
+		password_var = "AOSCX_PASSWORD"
+	}
+
+	username, ok := os.LookupEnv(username_var)
+	if !ok {
+		return "", "", fmt.Errorf("environment variable %s is not set", username_var)
+	}
+	password, ok := os.LookupEnv(password_var)
+	if !ok {
+		return "", "", fmt.Errorf("environment variable %s is not set", password_var)
+	}
+
+	return username, password, nil
+}
+
+// FileCredentials reads the credentials from a JSON file of the form
+//
+//	{"username": "admin", "password": "secret"}
+//
+// The file is read on every login.
+type FileCredentials struct {
+	Path string
+}
+
+// Credentials returns the username and password stored in the file.
+func (f FileCredentials) Credentials(ctx context.Context) (string, string, error) {
+	contents, err := os.ReadFile(f.Path)
+	if err != nil {
+		return "", "", fmt.Errorf("reading credentials file: %w", err)
+	}
+
+	file_credentials := struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	}{}
+	err = json.Unmarshal(contents, &file_credentials)
+	if err != nil {
+		return "", "", fmt.Errorf("parsing credentials file %s: %w", f.Path, err)
+	}
+
+	return file_credentials.Username, file_credentials.Password, nil
+}
+
+// credentials returns the username and password to login with, from the
+// CredentialProvider if set or the Username and Password fields otherwise.
+func (c *Client) credentials(ctx context.Context) (string, string, error) {
+	if c.Credentials == nil {
+		return c.Username, c.Password, nil
+	}
+
+	username, password, err := c.Credentials.Credentials(ctx)
+	if err != nil {
+		return "", "", fmt.Errorf("credentials for %s: %w", c.Hostname, err)
+	}
+	return username, password, nil
+}
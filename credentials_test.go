@@ -0,0 +1,129 @@
+package aoscxgo_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aruba/aoscxgo"
+	"github.com/aruba/aoscxgo/aoscxtest"
+)
+
+func TestLoginEscaping(t *testing.T) {
+	srv := aoscxtest.NewServer()
+	defer srv.Close()
+	srv.Password = "p&ss#w%rd+=?"
+	ctx := context.Background()
+
+	var login *aoscxgo.RequestInfo
+	client := srv.Client()
+	client.Password = srv.Password
+	client.RequestHook = func(ctx context.Context, info *aoscxgo.RequestInfo) {
+		if strings.HasSuffix(info.URL, "/login") {
+			login = info
+		}
+	}
+
+	c, err := aoscxgo.Connect(ctx, client)
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer c.Logout(ctx)
+
+	// Credentials travel in the body, never in the URL
+	if login == nil || login.Method != "POST" || strings.Contains(login.URL, "?") {
+		t.Fatalf("login request = %+v, want a POST without query", login)
+	}
+	if strings.Contains(login.Body, "p&ss") || !strings.Contains(login.Body, "password="+aoscxgo.Redacted) {
+		t.Errorf("login body = %q, want the password redacted", login.Body)
+	}
+}
+
+func TestPasswordNotSerialized(t *testing.T) {
+	client := aoscxgo.Client{Hostname: "switch", Username: "admin", Password: "secret"}
+
+	serialized, err := json.Marshal(&client)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if strings.Contains(string(serialized), "secret") {
+		t.Errorf("Marshal = %s, want no password", serialized)
+	}
+}
+
+func TestEnvCredentials(t *testing.T) {
+	srv := aoscxtest.NewServer()
+	defer srv.Close()
+	ctx := context.Background()
+
+	t.Setenv("SWITCH_USER", aoscxtest.DefaultUsername)
+	t.Setenv("SWITCH_PASSWORD", aoscxtest.DefaultPassword)
+
+	client := srv.Client()
+	client.Username = ""
+	client.Password = ""
+	client.Credentials = aoscxgo.EnvCredentials{UsernameVar: "SWITCH_USER", PasswordVar: "SWITCH_PASSWORD"}
+	c, err := aoscxgo.Connect(ctx, client)
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	c.Logout(ctx)
+
+	_, _, err = aoscxgo.EnvCredentials{UsernameVar: "SWITCH_UNSET_USER"}.Credentials(ctx)
+	if err == nil || !strings.Contains(err.Error(), "SWITCH_UNSET_USER") {
+		t.Errorf("Credentials from an unset variable = %v, want an error naming it", err)
+	}
+}
+
+func TestFileCredentials(t *testing.T) {
+	srv := aoscxtest.NewServer()
+	defer srv.Close()
+	ctx := context.Background()
+
+	path := filepath.Join(t.TempDir(), "credentials.json")
+	err := os.WriteFile(path, []byte(`{"username": "admin", "password": "admin"}`), 0600)
+	if err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	client := srv.Client()
+	client.Password = ""
+	client.Credentials = aoscxgo.FileCredentials{Path: path}
+	c, err := aoscxgo.Connect(ctx, client)
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	c.Logout(ctx)
+
+	_, _, err = aoscxgo.FileCredentials{Path: filepath.Join(t.TempDir(), "missing.json")}.Credentials(ctx)
+	if err == nil {
+		t.Error("Credentials from a missing file succeeded")
+	}
+}
+
+func TestCredentialsFunc(t *testing.T) {
+	srv, c := connect(t)
+	ctx := context.Background()
+
+	// The provider is asked again when the session is re-established, so
+	// a rotated password is picked up
+	srv.Password = "rotated"
+	calls := 0
+	c.Credentials = aoscxgo.CredentialsFunc(func(ctx context.Context) (string, string, error) {
+		calls++
+		return aoscxtest.DefaultUsername, "rotated", nil
+	})
+	srv.ExpireSessions()
+
+	vlan := aoscxgo.Vlan{VlanId: 1}
+	err := vlan.Get(ctx, c)
+	if err != nil {
+		t.Fatalf("Get after rotation: %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("provider called %d times, want 1", calls)
+	}
+}
@@ -1,18 +1,21 @@
 /*
-aoscxgo is a golang package that allows users to connect to and configure AOS-CX switches using REST API. The minimum supported firmware version is 10.09.
+aoscxgo is a golang package that allows users to connect to and configure AOS-CX switches using REST API. The minimum supported firmware version is 10.08.
 
 To login to the switch and create a client connection:
 
 package main
 
 import (
+	"context"
 	"log"
 
 	"github.com/aruba/aoscxgo"
 )
 
 func main() {
+	ctx := context.Background()
 	sw, err := aoscxgo.Connect(
+		ctx,
 		&aoscxgo.Client{
 			Hostname:          "10.0.0.1",
 			Username:          "admin",
@@ -20,7 +23,7 @@ func main() {
 		},
 	)
 
-	if (sw.Cookie == nil) || (err != nil) {
+	if err != nil {
 		log.Printf("Failed to login to switch: %s", err)
 		return
 	}
@@ -28,6 +31,18 @@ func main() {
 
 }
 
+Connect queries the REST API versions offered by the switch and uses the highest one also supported by this package (see SupportedVersions), unless Version is set on the Client. The negotiated version, firmware version and platform are available on the Client after Connect. Client.VersionAtLeast compares the negotiated version for code sending attributes that only exist in newer versions.
+
+Credentials are sent form encoded in the body of the login request. Instead of setting Password on the Client, a CredentialProvider can supply them, for example from the environment (EnvCredentials), a JSON file (FileCredentials) or a callback (CredentialsFunc):
+
+	sw, err := aoscxgo.Connect(
+		ctx,
+		&aoscxgo.Client{
+			Hostname:    "10.0.0.1",
+			Credentials: aoscxgo.EnvCredentials{},
+		},
+	)
+
 This will login to the switch and create a cookie to use for authentication in further calls. This cookie is stored within the aoscxgo.Client object that will be passed into configuration modules like so:
 
 	vlan100 := aoscxgo.Vlan{
@@ -38,8 +53,8 @@ This will login to the switch and create a cookie to use for authentication in f
 	}
 
 	// if the vlan exists use
-	// err = vlan100.Update(sw)
-	err = vlan100.Create(sw)
+	// err = vlan100.Update(ctx, sw)
+	err = vlan100.Create(ctx, sw)
 
 	if err != nil {
 		log.Printf("Error in creating VLAN 100: %s", err)
@@ -48,6 +63,116 @@ This will login to the switch and create a cookie to use for authentication in f
 
 	log.Printf("VLAN Create Success")
 
+All calls take a context.Context which bounds the underlying HTTP requests, so deadlines and cancellation are honored and failures are returned as errors rather than terminating the program.
+
+If the switch expires the session (for example after the idle timeout) the Client logs in again and replays the request once. Set KeepaliveInterval on the Client to keep the session alive in the background and MaxSessions to limit how many sessions are opened to the same switch and user.
+
+Errors returned by the switch are of type *RequestError holding the HTTP status, method, URL and the message reported by the switch. Values rejected before a request is sent return a *ValidationError. Both can be matched with errors.Is against ErrNotFound, ErrConflict, ErrValidation and ErrUnauthorized:
+
+	err = vlan100.Get(ctx, sw)
+	if errors.Is(err, aoscxgo.ErrNotFound) {
+		err = vlan100.Create(ctx, sw)
+	}
+
+Transient failures such as 503 responses while the configuration daemon is busy or connection resets can be retried with exponential backoff by setting RetryPolicy on the Client, e.g. to DefaultRetryPolicy(). POST requests are not retried unless RetryNonIdempotent is set.
+
+Once Connect returns a Client is safe for concurrent use by multiple goroutines, the session is shared and refreshed once for all of them. To avoid overloading the switch set RateLimit and RateBurst to cap the requests sent per second and MaxInFlight to cap the number of requests waiting for an answer.
+
+The package does not print anything. Set Logger on the Client to a *slog.Logger to receive its log output, with every request logged at debug level, and RequestHook and ResponseHook to trace each request with its method, URL, status, latency and body, with passwords and secrets redacted:
+
+	sw.ResponseHook = func(ctx context.Context, info *aoscxgo.ResponseInfo) {
+		log.Printf("%s %s %d %s", info.Method, info.URL, info.StatusCode, info.Latency)
+	}
+
+To discover what is configured, ListVlans returns all VLANs and ListInterfaces all interfaces of a type, PhysicalInterfaces, LagInterfaces, VlanInterfaces or AllInterfaces, each in a single request.
+
+Vlan.Delete refuses to delete a VLAN that still has a VLAN interface or is used by interfaces as access, native or trunk VLAN, returning a *VlanInUseError listing them. Set DeleteMode to VlanDeleteCascade to detach the interfaces first. A trunk whose only allowed VLAN would be removed is never emptied, as an empty list allows all VLANs; it is listed in Trunks of the error instead.
+
+The VLANs allowed on a trunk L2Interface are a VlanList, which ParseVlanList builds from ranges such as "10-20,30". AddTrunkVlans and RemoveTrunkVlans change only the given VLANs on the switch, keeping the others, and fail with ErrNotFound if a VLAN does not exist.
+
+To provision many VLANs at once use VlanRange with ranges such as "10-20,30,100-199". Its Create, Update and Delete configure the VLANs in parallel, at most Concurrency at a time, and report the outcome per VLAN in Results.
+
+A Lag configures a link aggregation interface such as lag1 with its member ports, LACP mode (active, passive or static), LACP rate, fallback, hash algorithm and minimum links. Like Vlan.Update, Lag.Update sends only the fields that are set, unless the Lag was retrieved by Get. Its Interface method returns the LAG as Interface for an L2Interface or L3Interface, with the AdminState of the Lag:
+
+	lag := aoscxgo.Lag{Name: "lag1", AdminState: "up", Members: []string{"1/1/1", "1/1/2"}, LacpRate: "fast"}
+	err = lag.Create(ctx, sw)
+	...
+	l2 := aoscxgo.L2Interface{Interface: lag.Interface(), VlanMode: "trunk", VlanIds: aoscxgo.VlanList{10, 20}}
+	err = l2.Create(ctx, sw)
+
+VsxSystem configures VSX on a switch: its role, ISL LAG, keepalive peer, source and VRF, system MAC, config-sync options and linkup delay. Set MultiChassis on a Lag to span it across the pair. ValidateVsxPair takes a Client for each peer and returns a *VsxMismatchError listing every inconsistency in the VSX settings, VLANs and multi-chassis LAGs of the pair.
+
+A Vrf configures a VRF with its description, route distinguisher and import and export route targets, and ListVrfs returns all VRFs. L3Interface and VlanInterface check that their Vrf exists before they are created or updated, failing with an error matching ErrNotFound otherwise.
+
+A StaticRoute configures an IPv4 or IPv6 route of a VRF with next hops by IP address or interface, each with distance and tag, or drops traffic with type blackhole or reject. ListStaticRoutes returns the routes of a VRF, and ReconcileStaticRoutes creates, updates and deletes routes so a VRF holds exactly the given set, changing nothing when it already does:
+
+	changes, err := aoscxgo.ReconcileStaticRoutes(ctx, sw, "default", []aoscxgo.StaticRoute{
+		{Prefix: "0.0.0.0/0", Nexthops: []aoscxgo.StaticNexthop{{IpAddress: "10.0.0.1"}}},
+		{Prefix: "10.99.0.0/16", Type: "blackhole"},
+	})
+
+An OspfRouter configures an OSPFv2 or OSPFv3 router of a VRF with its router ID, areas and their types, redistribution and passive default, keeping the areas on the switch in line with Areas on update. An OspfInterface enables OSPF on a routed interface such as an L3Interface or VlanInterface in an area, with cost, network type, authentication and hello and dead intervals. ListOspfRouters and ListOspfInterfaces return what is configured:
+
+	router := aoscxgo.OspfRouter{InstanceTag: 1, RouterId: "10.0.0.1", Areas: []aoscxgo.OspfArea{{AreaId: "0.0.0.0"}}}
+	err = router.Create(ctx, sw)
+	...
+	uplink := aoscxgo.OspfInterface{InstanceTag: 1, Area: "0.0.0.0", Interface: "1/1/49", NetworkType: "point-to-point"}
+	err = uplink.Create(ctx, sw)
+
+A BgpRouter configures the BGP router of a VRF with its ASN, router ID, best path options and network statements. A BgpNeighbor configures a neighbor or peer group with remote AS, peer group, update source, BFD, password, timers and the address families it is activated in with their route-maps. ListBgpNeighbors returns the neighbors of a router and ReconcileBgpNeighbors makes them match a desired set by name, creating peer groups before their members:
+
+	changes, err := aoscxgo.ReconcileBgpNeighbors(ctx, sw, "default", 65001, []aoscxgo.BgpNeighbor{
+		{Name: "SPINES", IsPeerGroup: true, RemoteAs: 65000, Bfd: true,
+			AddressFamilies: []aoscxgo.BgpAddressFamily{{Name: "l2vpn-evpn"}}},
+		{Name: "10.0.0.1", PeerGroup: "SPINES"},
+		{Name: "10.0.0.3", PeerGroup: "SPINES"},
+	})
+
+Acl configures an IPv4, IPv6 or MAC access control list with its entries ordered by sequence, matching protocol, source and destination prefixes, ports, DSCP and, for MAC ACLs, addresses and Ethertype, with log and count options. Update replaces the whole list of entries in one request. ApplyAcl and UnapplyAcl of Interface, Vlan and VlanInterface bind an ACL to the traffic received ("in") or sent ("out"), after checking the ACL exists, and ListAcls returns all ACLs:
+
+	web := aoscxgo.Acl{Name: "WEB", Type: "ipv4", Entries: []aoscxgo.AclEntry{
+		{Sequence: 10, Action: "permit", Protocol: "tcp", Destination: "10.0.10.0/24", DestinationPort: "443", Count: true},
+		{Sequence: 20, Action: "deny", Log: true},
+	}}
+	err = web.Create(ctx, sw)
+	uplink := aoscxgo.Interface{Name: "1/1/49"}
+	err = uplink.ApplyAcl(ctx, sw, &web, "in")
+
+AddressGroup and PortGroup configure address and port object groups, listed by ListAddressGroups and ListPortGroups. Entries of an Acl match them by name with SourceGroup, DestinationGroup, SourcePortGroup and DestinationPortGroup, and Acl.Create and Acl.Update check the referenced groups exist before pushing the ACL:
+
+	clients := aoscxgo.AddressGroup{Name: "CLIENTS", Type: "ipv4", Addresses: []string{"10.0.0.0/16", "192.168.1.10"}}
+	err = clients.Create(ctx, sw)
+	web_ports := aoscxgo.PortGroup{Name: "WEB", Ports: []string{"80", "443", "8000-8080"}}
+	err = web_ports.Create(ctx, sw)
+	acl := aoscxgo.Acl{Name: "CLIENTS-WEB", Type: "ipv4", Entries: []aoscxgo.AclEntry{
+		{Sequence: 10, Action: "permit", Protocol: "tcp", SourceGroup: "CLIENTS", DestinationPortGroup: "WEB"},
+	}}
+	err = acl.Create(ctx, sw)
+
+QoS is configured with Class maps matching traffic like ACL entries and Policy entries remarking DSCP or 802.1p priority, policing or queueing the traffic of a class, applied by ApplyPolicy of Interface and Vlan. QueueProfile maps local priorities to queues and ScheduleProfile sets how queues are serviced, applied to all ports by ApplyQosProfiles or to a port by Interface.ApplyScheduleProfile. SetQosTrust and Interface.SetQosTrust set the trusted priority, mapped to local priorities by QosDscpMapEntry and QosCosMapEntry:
+
+	voice := aoscxgo.Class{Name: "VOICE", Type: "ipv4", Entries: []aoscxgo.AclEntry{
+		{Sequence: 10, Action: "match", Protocol: "udp", DestinationPort: "16384-32767"},
+	}}
+	err = voice.Create(ctx, sw)
+	policy := aoscxgo.Policy{Name: "ACCESS", Entries: []aoscxgo.PolicyEntry{
+		{Sequence: 10, Class: "VOICE", RemarkDscp: "EF", LocalPriority: "6", PoliceRate: 2000},
+	}}
+	err = policy.Create(ctx, sw)
+	access := aoscxgo.Interface{Name: "1/1/1"}
+	err = access.ApplyPolicy(ctx, sw, &policy, "in")
+	err = access.SetQosTrust(ctx, sw, "dscp")
+
+The aoscxtest package provides a fake switch answering the REST API from in-memory state, so code using this package can be unit tested without hardware:
+
+	srv := aoscxtest.NewServer()
+	defer srv.Close()
+	srv.AddInterface("1/1/1")
+
+	sw, err := aoscxgo.Connect(ctx, srv.Client())
+
+Recorder, also in aoscxtest, is an http.RoundTripper to set as the Transport of a Client. In Record mode it captures the traffic with a real switch to a golden file, with credentials, cookies, CSRF tokens and secrets scrubbed, and in Replay mode it answers the same flow from that file so it can run in CI.
+
 Each API resource will have the following functions (exceptions may vary):
 
   * Create
@@ -0,0 +1,215 @@
+package aoscxgo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Sentinel errors for use with errors.Is. Errors returned by this package
+// match one of these where the cause is known.
+var (
+	// ErrNotFound is matched when the switch answers 404 Not Found.
+	ErrNotFound = errors.New("not found")
+	// ErrConflict is matched when the switch answers 409 Conflict, e.g. when
+	// creating a resource that already exists.
+	ErrConflict = errors.New("conflict")
+	// ErrValidation is matched when values are rejected before any request
+	// is sent to the switch, or by a configuration dryrun.
+	ErrValidation = errors.New("validation failed")
+	// ErrUnauthorized is matched when the switch answers 401 Unauthorized or
+	// 403 Forbidden.
+	ErrUnauthorized = errors.New("unauthorized")
+)
+
+// RequestError is returned when the switch answers a request with an
+// unexpected status.
+type RequestError struct {
+	// Op is the operation that made the request, e.g. "Vlan.Create".
+	Op string
+	// Method and URL of the request.
+	Method string
+	URL    string
+	// StatusCode and Status of the response.
+	StatusCode int
+	Status     string
+	// Message is the error reported by the switch, taken from the JSON
+	// error body when present or the plain text body otherwise.
+	Message string
+	// Body is the raw response body.
+	Body string
+
+	Err error
+}
+
+// Error returns the operation, request and the reason given by the switch.
+func (r *RequestError) Error() string {
+	msg := fmt.Sprintf("%s: %s %s: %s", r.Op, r.Method, r.URL, r.Status)
+	if r.Message != "" {
+		msg += ": " + r.Message
+	}
+	if r.Err != nil {
+		msg += ": " + r.Err.Error()
+	}
+	return msg
+}
+
+// Unwrap returns the underlying error so callers can use errors.Is and errors.As.
+func (r *RequestError) Unwrap() error {
+	return r.Err
+}
+
+// Is matches the sentinel errors corresponding to the response status.
+func (r *RequestError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return r.StatusCode == http.StatusNotFound
+	case ErrConflict:
+		return r.StatusCode == http.StatusConflict
+	case ErrUnauthorized:
+		return r.StatusCode == http.StatusUnauthorized || r.StatusCode == http.StatusForbidden
+	}
+	return false
+}
+
+// newRequestError builds a RequestError from an unexpected response,
+// consuming and closing its body.
+func newRequestError(op string, res *http.Response) *RequestError {
+	r := &RequestError{
+		Op:         op,
+		StatusCode: res.StatusCode,
+		Status:     res.Status,
+	}
+	if res.Request != nil {
+		r.Method = res.Request.Method
+		r.URL = res.Request.URL.String()
+	}
+
+	if res.Body != nil {
+		body, _ := io.ReadAll(res.Body)
+		res.Body.Close()
+		r.Body = string(body)
+		r.Message = parseErrorMessage(body)
+	}
+
+	return r
+}
+
+// parseErrorMessage extracts the error message from an AOS-CX error body.
+// The REST API answers with either plain text or a JSON object holding the
+// message.
+func parseErrorMessage(body []byte) string {
+	error_body := map[string]interface{}{}
+	if json.Unmarshal(body, &error_body) == nil {
+		for _, key := range []string{"message", "error", "errors"} {
+			if value, ok := error_body[key]; ok && value != nil {
+				if str, ok := value.(string); ok {
+					return str
+				}
+				return fmt.Sprintf("%v", value)
+			}
+		}
+	}
+	return strings.TrimSpace(string(body))
+}
+
+// ValidationError is returned when a value is rejected before any request is
+// sent to the switch. It matches ErrValidation.
+type ValidationError struct {
+	// Op is the operation that validated the value, e.g. "Vlan.Create".
+	Op string
+	// Field is the name of the invalid field.
+	Field string
+	// Message describes why the value is invalid.
+	Message string
+}
+
+// Error returns the operation, field and reason.
+func (v *ValidationError) Error() string {
+	return fmt.Sprintf("%s: invalid %s: %s", v.Op, v.Field, v.Message)
+}
+
+// Unwrap returns ErrValidation.
+func (v *ValidationError) Unwrap() error {
+	return ErrValidation
+}
+
+// newValidationError builds a ValidationError for the given field.
+func newValidationError(op string, field string, message string) *ValidationError {
+	return &ValidationError{
+		Op:      op,
+		Field:   field,
+		Message: message,
+	}
+}
+
+// ConfigError is returned when a configuration dryrun reports errors. It
+// matches ErrValidation.
+type ConfigError struct {
+	// Op is the operation that ran the dryrun, e.g. "FullConfig.Create".
+	Op string
+	// Mode is the dryrun mode, "validate" or "apply".
+	Mode string
+	// Errors are the per line errors reported by the switch.
+	Errors []ConfigLineError
+}
+
+// ConfigLineError is an error reported by a dryrun for a line of configuration.
+type ConfigLineError struct {
+	Line    int
+	Message string
+}
+
+// Error returns the operation and every line error.
+func (e *ConfigError) Error() string {
+	errors_str := fmt.Sprintf("%s: %s failed", e.Op, e.Mode)
+	for _, line_error := range e.Errors {
+		errors_str += fmt.Sprintf("\nline %d | %s", line_error.Line, line_error.Message)
+	}
+	return errors_str
+}
+
+// Unwrap returns ErrValidation.
+func (e *ConfigError) Unwrap() error {
+	return ErrValidation
+}
+
+// DependencyError is returned when a resource refers to another resource
+// that does not exist on the switch, e.g. an interface placed in a missing
+// VRF. It matches ErrValidation, and ErrNotFound through Err.
+type DependencyError struct {
+	// Op is the operation that checked the reference, e.g.
+	// "L3Interface.Create".
+	Op string
+	// Message names the missing resource and how to resolve it.
+	Message string
+	// Err is the error retrieving the missing resource.
+	Err error
+}
+
+// Error returns the operation, the missing resource and the cause.
+func (e *DependencyError) Error() string {
+	return fmt.Sprintf("%s: %s: %v", e.Op, e.Message, e.Err)
+}
+
+// Unwrap returns the error retrieving the missing resource.
+func (e *DependencyError) Unwrap() error {
+	return e.Err
+}
+
+// Is matches ErrValidation.
+func (e *DependencyError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+// newDependencyError builds a DependencyError for a missing resource.
+func newDependencyError(op string, message string, err error) *DependencyError {
+	return &DependencyError{
+		Op:      op,
+		Message: message,
+		Err:     err,
+	}
+}
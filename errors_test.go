@@ -0,0 +1,142 @@
+package aoscxgo_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/aruba/aoscxgo"
+)
+
+// transportFunc adapts a function to an http.RoundTripper.
+type transportFunc func(req *http.Request) (*http.Response, error)
+
+func (f transportFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestRequestErrorNotFound(t *testing.T) {
+	_, c := connect(t)
+
+	vlan := aoscxgo.Vlan{VlanId: 99}
+	err := vlan.Get(context.Background(), c)
+
+	var request_error *aoscxgo.RequestError
+	if !errors.As(err, &request_error) {
+		t.Fatalf("Get = %v, want a RequestError", err)
+	}
+	if request_error.Op != "Vlan.Get" || request_error.Method != "GET" || request_error.StatusCode != http.StatusNotFound {
+		t.Errorf("RequestError = %+v, want Vlan.Get GET 404", request_error)
+	}
+	if !strings.HasSuffix(request_error.URL, "/system/vlans/99") {
+		t.Errorf("URL = %q, want the VLAN", request_error.URL)
+	}
+	if request_error.Message != "Object not found" {
+		t.Errorf("Message = %q, want the reason given by the switch", request_error.Message)
+	}
+	if !errors.Is(err, aoscxgo.ErrNotFound) {
+		t.Error("404 does not match ErrNotFound")
+	}
+	if errors.Is(err, aoscxgo.ErrConflict) || errors.Is(err, aoscxgo.ErrValidation) || errors.Is(err, aoscxgo.ErrUnauthorized) {
+		t.Error("404 matches another sentinel error")
+	}
+}
+
+func TestRequestErrorConflict(t *testing.T) {
+	_, c := connect(t)
+
+	vlan := aoscxgo.Vlan{VlanId: 1, Name: "DEFAULT_VLAN_1"}
+	err := vlan.Create(context.Background(), c)
+	if !errors.Is(err, aoscxgo.ErrConflict) {
+		t.Fatalf("Create existing VLAN = %v, want ErrConflict", err)
+	}
+	if errors.Is(err, aoscxgo.ErrNotFound) {
+		t.Error("409 matches ErrNotFound")
+	}
+}
+
+func TestRequestErrorMessage(t *testing.T) {
+	_, c := connect(t)
+	ctx := context.Background()
+
+	// AOS-CX reports the reason a PATCH is rejected in a JSON body
+	transport := c.HTTPClient.Transport
+	c.HTTPClient = &http.Client{
+		Jar: c.HTTPClient.Jar,
+		Transport: transportFunc(func(req *http.Request) (*http.Response, error) {
+			if req.Method != "PATCH" {
+				return transport.RoundTrip(req)
+			}
+			return &http.Response{
+				StatusCode: http.StatusBadRequest,
+				Status:     "400 Bad Request",
+				Header:     http.Header{"Content-Type": {"application/json"}},
+				Body:       io.NopCloser(strings.NewReader(`{"message": "Value 5000 for 'id' is out of range"}`)),
+				Request:    req,
+			}, nil
+		}),
+	}
+
+	vlan := aoscxgo.Vlan{VlanId: 1, Name: "users"}
+	err := vlan.Update(ctx, c)
+
+	var request_error *aoscxgo.RequestError
+	if !errors.As(err, &request_error) {
+		t.Fatalf("Update = %v, want a RequestError", err)
+	}
+	if request_error.StatusCode != http.StatusBadRequest || request_error.Method != "PATCH" {
+		t.Errorf("RequestError = %+v, want PATCH 400", request_error)
+	}
+	if request_error.Message != "Value 5000 for 'id' is out of range" {
+		t.Errorf("Message = %q, want the message of the JSON body", request_error.Message)
+	}
+	if !strings.Contains(request_error.Body, `"message"`) {
+		t.Errorf("Body = %q, want the raw body", request_error.Body)
+	}
+	if !strings.Contains(err.Error(), "Vlan.Update") || !strings.Contains(err.Error(), "out of range") {
+		t.Errorf("Error() = %q, want the operation and message", err.Error())
+	}
+}
+
+func TestValidationError(t *testing.T) {
+	_, c := connect(t)
+	requests := 0
+	c.RequestHook = func(ctx context.Context, info *aoscxgo.RequestInfo) {
+		requests++
+	}
+
+	vlan := aoscxgo.Vlan{Name: "users"}
+	err := vlan.Create(context.Background(), c)
+
+	var validation_error *aoscxgo.ValidationError
+	if !errors.As(err, &validation_error) {
+		t.Fatalf("Create = %v, want a ValidationError", err)
+	}
+	if validation_error.Op != "Vlan.Create" || validation_error.Field != "VlanId" {
+		t.Errorf("ValidationError = %+v, want Vlan.Create VlanId", validation_error)
+	}
+	if !errors.Is(err, aoscxgo.ErrValidation) {
+		t.Error("ValidationError does not match ErrValidation")
+	}
+	if requests != 0 {
+		t.Errorf("sent %d requests for an invalid VLAN", requests)
+	}
+}
+
+func TestDependencyError(t *testing.T) {
+	err := error(&aoscxgo.DependencyError{
+		Op:      "L3Interface.Create",
+		Message: "missing VRF blue - Create VRF first",
+		Err:     &aoscxgo.RequestError{StatusCode: http.StatusNotFound},
+	})
+
+	if !errors.Is(err, aoscxgo.ErrValidation) || !errors.Is(err, aoscxgo.ErrNotFound) {
+		t.Errorf("DependencyError does not match ErrValidation and ErrNotFound")
+	}
+	if !strings.Contains(err.Error(), "missing VRF blue") {
+		t.Errorf("Error() = %q, want the missing resource", err.Error())
+	}
+}
@@ -2,10 +2,9 @@ package aoscxgo
 
 import (
 	"bytes"
-	"errors"
+	"context"
 	"fmt"
 	"io/ioutil"
-	"log"
 	"net/http"
 	"time"
 
@@ -18,79 +17,47 @@ type FullConfig struct {
 	FileName string `json:"filename"`
 	//Hash     hash.Hash `json:"hash"`
 	Config string `json:"config"`
-	uri    string `json:"uri"`
+	uri    string
 }
 
 // Create performs POST to create VLAN configuration on the given Client object.
-func (fc *FullConfig) Create(c *Client) (*http.Response, error) {
+func (fc *FullConfig) Create(ctx context.Context, c *Client) (*http.Response, error) {
 	if fc.FileName == "" {
-		return nil, &RequestError{
-			StatusCode: "Missing FileName",
-			Err:        errors.New("Create Error"),
-		}
+		return nil, newValidationError("FullConfig.Create", "FileName", "missing FileName")
 	}
 
 	config_str, err := fc.ReadConfigFile(fc.FileName)
 
 	if err != nil {
-		return nil, &RequestError{
-			StatusCode: "Error in reading config file",
-			Err:        err,
-		}
+		return nil, err
 	}
 
-	res, body := fc.ValidateConfig(c, config_str)
-
-	if body == nil {
-		return res, &RequestError{
-			StatusCode: res.Status,
-			Err:        errors.New("Validation Error"),
-		}
-
-	} else if body["state"] == "success" {
-		res2, body2 := fc.ApplyConfig(c, config_str)
-		if body2["state"] != "success" {
-			errors_dict := body2["errors"].([]interface{})
-			error_str := convert_errors(errors_dict)
-
-			return res2, &RequestError{
-				StatusCode: "Error in applying config error : \n" + error_str,
-				Err:        errors.New("Apply Error"),
-			}
-		} else {
-			log.Println("New Config Applied Successfully")
-			fc.Get(c)
-			return res2, nil
-		}
-	} else if res != nil && body != nil {
-		errors_dict := body["errors"].([]interface{})
-		errors_dict = errors_dict
-		error_str := convert_errors(errors_dict)
-
-		return res, &RequestError{
-			StatusCode: "Error in validating config error : \n" + error_str,
-			Err:        errors.New("Apply Error"),
-		}
-
+	_, _, err = fc.dryrun(ctx, c, "FullConfig.Create", config_str, "validate")
+	if err != nil {
+		return nil, err
 	}
 
-	return res, &RequestError{
-		StatusCode: res.Status,
-		Err:        errors.New("Validation Error"),
+	res, _, err := fc.dryrun(ctx, c, "FullConfig.Create", config_str, "apply")
+	if err != nil {
+		return res, err
 	}
+
+	c.logger().InfoContext(ctx, "aoscxgo new config applied successfully", "hostname", c.Hostname)
+	return res, fc.Get(ctx, c)
 }
 
 // Get performs GET to retrieve Running configuration for the given Client object.
-func (fc *FullConfig) Get(c *Client) error {
+func (fc *FullConfig) Get(ctx context.Context, c *Client) error {
 	base_uri := "configs/running-config"
 	url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri
-	res, _ := get_accept_text(c, url)
+	res, err := get_accept_text(ctx, c, url)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
 
 	if res.Status != "200 OK" {
-		return &RequestError{
-			StatusCode: res.Status + url,
-			Err:        errors.New("Retrieval Error"),
-		}
+		return newRequestError("FullConfig.Get", res)
 	}
 
 	// Read the content
@@ -114,11 +81,7 @@ func (fc *FullConfig) ReadConfigFile(filename string) (string, error) {
 	config_contents, err := ioutil.ReadFile(filename)
 
 	if err != nil {
-		err_str := "Unable to read file " + filename
-		return "", &RequestError{
-			StatusCode: err_str,
-			Err:        err,
-		}
+		return "", fmt.Errorf("FullConfig.ReadConfigFile: unable to read file %s: %w", filename, err)
 	}
 	config_str := string(config_contents)
 	return config_str, nil
@@ -126,21 +89,35 @@ func (fc *FullConfig) ReadConfigFile(filename string) (string, error) {
 }
 
 // Formats the errors provided by dryrun for user
-func convert_errors(errors_list []interface{}) string {
-	errors_str := ""
+func convert_errors(op string, mode string, body map[string]interface{}) *ConfigError {
+	config_error := &ConfigError{
+		Op:   op,
+		Mode: mode,
+	}
+
+	errors_list, _ := body["errors"].([]interface{})
 	for _, error_dict := range errors_list {
-		tmp_dict := error_dict.(map[string]interface{})
-		line_num := tmp_dict["line"]
-		line_float := line_num.(float64)
-		line_int := int(line_float)
-		errors_str += "line "
-		line_num_str := fmt.Sprintf("%d", line_int)
-		errors_str += line_num_str
-		errors_str += " | "
-		errors_str += tmp_dict["message"].(string)
-		errors_str += "\n"
-	}
-	return errors_str
+		tmp_dict, ok := error_dict.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		line_error := ConfigLineError{}
+		if line_float, ok := tmp_dict["line"].(float64); ok {
+			line_error.Line = int(line_float)
+		}
+		line_error.Message, _ = tmp_dict["message"].(string)
+		config_error.Errors = append(config_error.Errors, line_error)
+	}
+
+	if len(config_error.Errors) == 0 {
+		// dryrun did not finish or gave no reason
+		state, _ := body["state"].(string)
+		config_error.Errors = append(config_error.Errors, ConfigLineError{
+			Message: "dryrun state " + state,
+		})
+	}
+
+	return config_error
 }
 
 // Sets Config Attribute
@@ -157,16 +134,17 @@ func (fc *FullConfig) CompareConfig(new_config string) string {
 }
 
 // Compares supplied string Config to stored Object
-func (fc *FullConfig) DownloadConfig(c *Client, filename string) error {
+func (fc *FullConfig) DownloadConfig(ctx context.Context, c *Client, filename string) error {
 	base_uri := "configs/running-config"
 	url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri
-	res, _ := get_accept_text(c, url)
+	res, err := get_accept_text(ctx, c, url)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
 
 	if res.Status != "200 OK" {
-		return &RequestError{
-			StatusCode: res.Status + url,
-			Err:        errors.New("Retrieval Error"),
-		}
+		return newRequestError("FullConfig.DownloadConfig", res)
 	}
 
 	// Read the content
@@ -179,73 +157,71 @@ func (fc *FullConfig) DownloadConfig(c *Client, filename string) error {
 	// Use the content
 	bodyString := string(bodyBytes)
 
-	err := ioutil.WriteFile(filename, []byte(bodyString), 0644)
-	if err != nil {
-		panic(err)
-	}
+	return ioutil.WriteFile(filename, []byte(bodyString), 0644)
+}
+
+// Validates supplied CLI configuration as string using dryrun. A ConfigError
+// is returned if the switch reports errors or the dryrun does not complete.
+func (fc *FullConfig) ValidateConfig(ctx context.Context, c *Client, config string) (*http.Response, map[string]interface{}, error) {
+	return fc.dryrun(ctx, c, "FullConfig.ValidateConfig", config, "validate")
+}
 
-	return err
+// Applies supplied CLI configuration as string using dryrun. A ConfigError is
+// returned if the switch reports errors or the dryrun does not complete.
+func (fc *FullConfig) ApplyConfig(ctx context.Context, c *Client, config string) (*http.Response, map[string]interface{}, error) {
+	return fc.dryrun(ctx, c, "FullConfig.ApplyConfig", config, "apply")
 }
 
-// Validates supplied CLI configuration as string using dryrun
-func (fc *FullConfig) ValidateConfig(c *Client, config string) (*http.Response, map[string]interface{}) {
+// dryrun POSTs the configuration with the given dryrun mode and polls the
+// dryrun status until it completes, the polling limit is reached or the
+// context is done. Unless the dryrun succeeds an error is returned, a
+// ConfigError if the switch reports errors or the polling limit is reached.
+func (fc *FullConfig) dryrun(ctx context.Context, c *Client, op string, config string, mode string) (*http.Response, map[string]interface{}, error) {
 	base_uri := "configs/running-config"
 	url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri
-	dryrun_url := url + "?dryrun=validate"
+	dryrun_url := url + "?dryrun=" + mode
 
 	json_body := bytes.NewBufferString(config)
 
-	res := post(c, dryrun_url, json_body)
+	res, err := post(ctx, c, dryrun_url, json_body)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	if res.Status != "200 OK" && res.Status != "202 Accepted" {
-		return res, nil
+	if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusAccepted {
+		return res, nil, newRequestError(op, res)
 	}
 
 	dryrun_url = url + "?dryrun"
 
-	res2, body := get(c, dryrun_url)
-
 	iterations := 10
 
-	for iterations > 0 {
-		iterations -= 1
-		if body["state"] == "success" || body["state"] == "error" {
-			break
+	for {
+		res, body, err := get(ctx, c, dryrun_url)
+		if err != nil {
+			return nil, nil, err
 		}
-		time.Sleep(2 * time.Second)
-		res2, body = get(c, dryrun_url)
-	}
-
-	return res2, body
-}
-
-func (fc *FullConfig) ApplyConfig(c *Client, config string) (*http.Response, map[string]interface{}) {
-	base_uri := "configs/running-config"
-	url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri
-	dryrun_url := url + "?dryrun=apply"
 
-	json_body := bytes.NewBufferString(config)
-
-	res := post(c, dryrun_url, json_body)
-
-	if res.Status != "200 OK" && res.Status != "202 Accepted" {
-		return res, nil
-	}
-
-	dryrun_url = url + "?dryrun"
-
-	res2, body := get(c, dryrun_url)
+		if res.StatusCode != http.StatusOK {
+			return res, nil, newRequestError(op, res)
+		}
 
-	iterations := 10
+		switch body["state"] {
+		case "success":
+			return res, body, nil
+		case "error":
+			return res, body, convert_errors(op, mode, body)
+		}
 
-	for iterations > 0 {
 		iterations -= 1
-		if body["state"] == "success" || body["state"] == "error" {
-			break
+		if iterations <= 0 {
+			return res, body, convert_errors(op, mode, body)
 		}
-		time.Sleep(2 * time.Second)
-		res2, body = get(c, dryrun_url)
-	}
 
-	return res2, body
+		select {
+		case <-ctx.Done():
+			return res, body, ctx.Err()
+		case <-time.After(2 * time.Second):
+		}
+	}
 }
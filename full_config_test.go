@@ -0,0 +1,114 @@
+package aoscxgo_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aruba/aoscxgo"
+)
+
+func TestFullConfigValidate(t *testing.T) {
+	srv, c := connect(t)
+	ctx := context.Background()
+	srv.SetRunningConfig("hostname old\n")
+
+	fc := aoscxgo.FullConfig{}
+	_, body, err := fc.ValidateConfig(ctx, c, "hostname new\n")
+	if err != nil {
+		t.Fatalf("ValidateConfig: %v", err)
+	}
+	if body["state"] != "success" {
+		t.Errorf("state = %v, want success", body["state"])
+	}
+	if srv.RunningConfig() != "hostname old\n" {
+		t.Error("ValidateConfig changed the running configuration")
+	}
+}
+
+func TestFullConfigApply(t *testing.T) {
+	srv, c := connect(t)
+	ctx := context.Background()
+
+	fc := aoscxgo.FullConfig{}
+	_, _, err := fc.ApplyConfig(ctx, c, "hostname new\n")
+	if err != nil {
+		t.Fatalf("ApplyConfig: %v", err)
+	}
+	if srv.RunningConfig() != "hostname new\n" {
+		t.Errorf("running configuration = %q", srv.RunningConfig())
+	}
+}
+
+func TestFullConfigDryrunErrors(t *testing.T) {
+	srv, c := connect(t)
+	ctx := context.Background()
+	srv.SetRunningConfig("hostname old\n")
+	srv.ValidateConfig = func(config string) []aoscxgo.ConfigLineError {
+		return []aoscxgo.ConfigLineError{{Line: 2, Message: "Invalid input: bogus"}}
+	}
+
+	fc := aoscxgo.FullConfig{}
+	for _, mode := range []string{"validate", "apply"} {
+		var err error
+		if mode == "validate" {
+			_, _, err = fc.ValidateConfig(ctx, c, "hostname new\nbogus\n")
+		} else {
+			_, _, err = fc.ApplyConfig(ctx, c, "hostname new\nbogus\n")
+		}
+
+		var config_err *aoscxgo.ConfigError
+		if !errors.As(err, &config_err) || !errors.Is(err, aoscxgo.ErrValidation) {
+			t.Fatalf("%s = %v, want ConfigError", mode, err)
+		}
+		if config_err.Mode != mode || len(config_err.Errors) != 1 || config_err.Errors[0].Line != 2 {
+			t.Errorf("%s ConfigError = %+v", mode, config_err)
+		}
+	}
+	if srv.RunningConfig() != "hostname old\n" {
+		t.Error("failed dryrun changed the running configuration")
+	}
+}
+
+func TestFullConfigDryrunPolls(t *testing.T) {
+	if testing.Short() {
+		t.Skip("dryrun polling waits between polls")
+	}
+	srv, c := connect(t)
+	ctx := context.Background()
+	srv.DryrunPolls = 1
+
+	fc := aoscxgo.FullConfig{}
+	_, _, err := fc.ApplyConfig(ctx, c, "hostname new\n")
+	if err != nil {
+		t.Fatalf("ApplyConfig: %v", err)
+	}
+	if srv.RunningConfig() != "hostname new\n" {
+		t.Errorf("running configuration = %q", srv.RunningConfig())
+	}
+}
+
+func TestFullConfigCreate(t *testing.T) {
+	srv, c := connect(t)
+	ctx := context.Background()
+
+	filename := filepath.Join(t.TempDir(), "config.txt")
+	err := os.WriteFile(filename, []byte("hostname new\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fc := aoscxgo.FullConfig{FileName: filename}
+	_, err = fc.Create(ctx, c)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if fc.Config != "hostname new\n" || srv.RunningConfig() != "hostname new\n" {
+		t.Errorf("Config = %q, running configuration = %q", fc.Config, srv.RunningConfig())
+	}
+	if diff := fc.CompareConfig("hostname new\n"); diff != "" {
+		t.Errorf("CompareConfig = %s", diff)
+	}
+}
@@ -1,5 +1,8 @@
 module github.com/aruba/aoscxgo
 
-go 1.18
+go 1.21
 
-require golang.org/x/exp v0.0.0-20240103183307-be819d1f06fc // indirect
+require (
+	github.com/google/go-cmp v0.5.8
+	golang.org/x/exp v0.0.0-20240103183307-be819d1f06fc
+)
@@ -0,0 +1,240 @@
+package aoscxgo_test
+
+import (
+	"context"
+	"flag"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aruba/aoscxgo"
+	"github.com/aruba/aoscxgo/aoscxtest"
+)
+
+var record = flag.Bool("record", false, "record the golden files in testdata against the fake switch")
+
+// replay connects a Client replaying the golden file testdata/<name>.json.
+// With -record the flow runs against a fake switch seeded by seed instead
+// and the golden file is rewritten when the test ends.
+func replay(t *testing.T, name string, seed func(srv *aoscxtest.Server)) *aoscxgo.Client {
+	t.Helper()
+	ctx := context.Background()
+	path := filepath.Join("testdata", name+".json")
+
+	client := &aoscxgo.Client{
+		Hostname: "switch.example",
+		Username: aoscxtest.DefaultUsername,
+		Password: aoscxtest.DefaultPassword,
+	}
+	mode := aoscxtest.Replay
+	var transport http.RoundTripper
+
+	if *record {
+		srv := aoscxtest.NewServer()
+		t.Cleanup(srv.Close)
+		if seed != nil {
+			seed(srv)
+		}
+		client.Hostname = srv.Listener.Addr().String()
+		mode = aoscxtest.Record
+		transport = srv.Client().HTTPClient.Transport
+	}
+
+	rec, err := aoscxtest.NewRecorder(path, mode, transport)
+	if err != nil {
+		t.Fatalf("NewRecorder: %v", err)
+	}
+	client.Transport = rec
+
+	c, err := aoscxgo.Connect(ctx, client)
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	t.Cleanup(func() {
+		err := c.Logout(ctx)
+		if err != nil {
+			t.Errorf("Logout: %v", err)
+		}
+		err = rec.Save()
+		if err != nil {
+			t.Errorf("Save: %v", err)
+		}
+	})
+
+	return c
+}
+
+func TestGoldenVlan(t *testing.T) {
+	c := replay(t, "vlan", nil)
+	ctx := context.Background()
+
+	vlan := aoscxgo.Vlan{VlanId: 10, Name: "users", Description: "user access"}
+	err := vlan.Create(ctx, c)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	vlan.AdminState = "up"
+	err = vlan.Update(ctx, c)
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	got := aoscxgo.Vlan{VlanId: 10}
+	err = got.Get(ctx, c)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.Name != "users" || got.Description != "user access" || got.AdminState != "up" {
+		t.Errorf("Get = %+v", got)
+	}
+
+	err = vlan.Delete(ctx, c)
+	if err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+}
+
+func TestGoldenL2Interface(t *testing.T) {
+	c := replay(t, "l2_interface", func(srv *aoscxtest.Server) {
+		srv.AddInterface("1/1/1")
+		srv.AddInterface("1/1/2")
+	})
+	ctx := context.Background()
+
+	for _, vlan_id := range []int{10, 20} {
+		vlan := aoscxgo.Vlan{VlanId: vlan_id, Name: "vlan"}
+		err := vlan.Create(ctx, c)
+		if err != nil {
+			t.Fatalf("Vlan.Create: %v", err)
+		}
+	}
+
+	access := aoscxgo.L2Interface{
+		Interface: aoscxgo.Interface{Name: "1/1/1", AdminState: "up"},
+		VlanMode:  "access",
+		VlanTag:   10,
+	}
+	err := access.Create(ctx, c)
+	if err != nil {
+		t.Fatalf("Create access: %v", err)
+	}
+
+	trunk := aoscxgo.L2Interface{
+		Interface:   aoscxgo.Interface{Name: "1/1/2", AdminState: "up"},
+		Description: "uplink",
+		VlanMode:    "trunk",
+		VlanTag:     1,
+		VlanIds:     aoscxgo.VlanList{10, 20},
+	}
+	err = trunk.Create(ctx, c)
+	if err != nil {
+		t.Fatalf("Create trunk: %v", err)
+	}
+
+	got := aoscxgo.L2Interface{Interface: aoscxgo.Interface{Name: "1/1/2"}}
+	err = got.Get(ctx, c)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.VlanMode != "native-untagged" || got.Description != "uplink" || len(got.VlanIds) != 2 {
+		t.Errorf("Get = %+v", got)
+	}
+
+	got = aoscxgo.L2Interface{Interface: aoscxgo.Interface{Name: "1/1/1"}}
+	err = got.Get(ctx, c)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.VlanMode != "access" || got.VlanTag != 10 {
+		t.Errorf("Get = %+v", got)
+	}
+
+	for _, i := range []aoscxgo.L2Interface{access, trunk} {
+		err = i.Delete(ctx, c)
+		if err != nil {
+			t.Fatalf("Delete %s: %v", i.Interface.Name, err)
+		}
+	}
+}
+
+func TestGoldenL3Interface(t *testing.T) {
+	c := replay(t, "l3_interface", func(srv *aoscxtest.Server) {
+		srv.AddInterface("1/1/3")
+	})
+	ctx := context.Background()
+
+	routed := aoscxgo.L3Interface{
+		Interface:   aoscxgo.Interface{Name: "1/1/3", AdminState: "up"},
+		Description: "core",
+		Ipv4:        []interface{}{"10.0.0.1/24", "10.0.1.1/24"},
+		Ipv6:        []interface{}{"2001:db8::1/64"},
+	}
+	err := routed.Create(ctx, c)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	got := aoscxgo.L3Interface{Interface: aoscxgo.Interface{Name: "1/1/3"}}
+	err = got.Get(ctx, c)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.Description != "core" || got.Vrf != "default" || len(got.Ipv4) != 2 || len(got.Ipv6) != 1 {
+		t.Errorf("Get = %+v", got)
+	}
+
+	err = routed.Delete(ctx, c)
+	if err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+}
+
+func TestGoldenFullConfig(t *testing.T) {
+	c := replay(t, "full_config", func(srv *aoscxtest.Server) {
+		srv.SetRunningConfig("hostname old\n")
+	})
+	ctx := context.Background()
+
+	config := "hostname new\nuser admin group administrators password plaintext secret123\n"
+
+	fc := aoscxgo.FullConfig{}
+	_, _, err := fc.ValidateConfig(ctx, c, config)
+	if err != nil {
+		t.Fatalf("ValidateConfig: %v", err)
+	}
+
+	_, _, err = fc.ApplyConfig(ctx, c, config)
+	if err != nil {
+		t.Fatalf("ApplyConfig: %v", err)
+	}
+
+	err = fc.Get(ctx, c)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if !strings.HasPrefix(fc.Config, "hostname new\n") {
+		t.Errorf("Config = %q", fc.Config)
+	}
+}
+
+func TestGoldenScrubbed(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join("testdata", "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range paths {
+		contents, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, secret := range []string{"secret123", "password=" + aoscxtest.DefaultPassword, "username=" + aoscxtest.DefaultUsername} {
+			if strings.Contains(string(contents), secret) {
+				t.Errorf("%s contains %q", path, secret)
+			}
+		}
+	}
+}
@@ -2,26 +2,35 @@ package aoscxgo
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
-	"errors"
+	"net/http"
 	"net/url"
 	"regexp"
+	"sort"
 )
 
 type Interface struct {
 
 	// Connection properties.
-	Name             string                 `json:"name"`
-	Description      string                 `json:"description"`
-	AdminState       string                 `json:"admin"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	AdminState  string `json:"admin"`
+
+	// Retrieved by Get and ListInterfaces. Type is "system" for ports,
+	// "lag", "vlan" or "loopback", LinkState is "up" or "down".
+	Type      string `json:"type"`
+	LinkState string `json:"link_state"`
+
 	InterfaceDetails map[string]interface{} `json:"details"`
-	materialized     bool                   `json:"materialized"`
-	uri              string                 `json:"uri"`
+	materialized     bool
+	uri              string
 }
 
-// checkName validates if interface Name is valid or not
+// checkName validates if interface Name is valid or not, physical ports and
+// LAGs such as "lag1" are accepted
 func checkName(name string) bool {
-	re := "\\d+/\\d+/\\d+"
+	re := "\\d+/\\d+/\\d+|^lag\\d+$"
 
 	found, err := regexp.MatchString(re, name)
 
@@ -31,28 +40,23 @@ func checkName(name string) bool {
 	return false
 }
 
-// checkValues validates if interface Name and AdminState are valid or not
-func (i *Interface) checkValues() error {
+// checkValues validates if interface Name and AdminState are valid or not,
+// op names the calling operation in the returned error.
+func (i *Interface) checkValues(op string) error {
 	if !checkName(i.Name) {
-		return &RequestError{
-			StatusCode: "Invalid Required Value: Name",
-			Err:        errors.New("Create Error"),
-		}
+		return newValidationError(op, "Name", "invalid interface name: "+i.Name)
 	}
 
-	status_str := "Invalid Required Value: AdminState - valid options are 'up' or 'down' received: " + i.AdminState
+	status_str := "valid options are 'up' or 'down' received: " + i.AdminState
 
 	if i.AdminState != "down" && i.AdminState != "up" {
-		return &RequestError{
-			StatusCode: status_str,
-			Err:        errors.New("Create Error"),
-		}
+		return newValidationError(op, "AdminState", status_str)
 	}
 	return nil
 }
 
 // Create performs POST to create Interface configuration on the given Client object.
-func (i *Interface) Create(c *Client) error {
+func (i *Interface) Create(ctx context.Context, c *Client) error {
 	base_uri := "system/interfaces"
 	url_str := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri
 
@@ -60,7 +64,7 @@ func (i *Interface) Create(c *Client) error {
 
 	i.uri = "/rest/" + c.Version + "/" + base_uri + "/" + int_str
 
-	err := i.checkValues()
+	err := i.checkValues("Interface.Create")
 	if err != nil {
 		return err
 
@@ -84,13 +88,13 @@ func (i *Interface) Create(c *Client) error {
 
 	json_body := bytes.NewBuffer(postBody)
 
-	res := post(c, url_str, json_body)
+	res, err := post(ctx, c, url_str, json_body)
+	if err != nil {
+		return err
+	}
 
 	if res.Status != "201 Created" {
-		return &RequestError{
-			StatusCode: res.Status,
-			Err:        errors.New("Create Error"),
-		}
+		return newRequestError("Interface.Create", res)
 	}
 
 	i.materialized = true
@@ -99,9 +103,9 @@ func (i *Interface) Create(c *Client) error {
 }
 
 // Update performs PATCH to update Interface configuration on the given Client object.
-func (i *Interface) Update(c *Client) error {
+func (i *Interface) Update(ctx context.Context, c *Client) error {
 	base_uri := "system/interfaces"
-	err := i.checkValues()
+	err := i.checkValues("Interface.Update")
 	if err != nil {
 		return err
 
@@ -129,20 +133,20 @@ func (i *Interface) Update(c *Client) error {
 
 	json_body := bytes.NewBuffer(patchBody)
 
-	res := patch(c, url, json_body)
+	res, err := patch(ctx, c, url, json_body)
+	if err != nil {
+		return err
+	}
 
 	if res.Status != "204 No Content" {
-		return &RequestError{
-			StatusCode: res.Status,
-			Err:        errors.New("Update Error"),
-		}
+		return newRequestError("Interface.Update", res)
 	}
 
 	return nil
 }
 
 // Delete performs PUT to remove/default Interface configuration from the given Client object.
-func (i *Interface) Delete(c *Client) error {
+func (i *Interface) Delete(ctx context.Context, c *Client) error {
 	base_uri := "system/interfaces"
 	int_str := url.PathEscape(i.Name)
 
@@ -157,33 +161,33 @@ func (i *Interface) Delete(c *Client) error {
 
 	//need logic for handling interfaces between platforms
 
-	res := put(c, url, json_body)
+	res, err := put(ctx, c, url, json_body)
+	if err != nil {
+		return err
+	}
 
 	if res.Status != "204 No Content" && res.Status != "200 OK" {
-		return &RequestError{
-			StatusCode: res.Status,
-			Err:        errors.New("Delete Error"),
-		}
+		return newRequestError("Interface.Delete", res)
 	}
 
 	return nil
 }
 
 // Get performs GET to retrieve Interface configuration from the given Client object.
-func (i *Interface) Get(c *Client) error {
+func (i *Interface) Get(ctx context.Context, c *Client) error {
 	base_uri := "system/interfaces"
 	int_str := url.PathEscape(i.Name)
 
 	url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri + "/" + int_str + ""
 
-	res, body := get(c, url)
+	res, body, err := get(ctx, c, url)
+	if err != nil {
+		return err
+	}
 
 	if res.Status != "200 OK" {
 		i.materialized = false
-		return &RequestError{
-			StatusCode: res.Status,
-			Err:        errors.New("Retrieval Error"),
-		}
+		return newRequestError("Interface.Get", res)
 	}
 
 	if i.InterfaceDetails == nil {
@@ -200,6 +204,14 @@ func (i *Interface) Get(c *Client) error {
 			i.AdminState = value.(string)
 		}
 
+		if key == "type" && value != nil {
+			i.Type = value.(string)
+		}
+
+		if key == "link_state" && value != nil {
+			i.LinkState = value.(string)
+		}
+
 	}
 
 	i.materialized = true
@@ -211,3 +223,61 @@ func (i *Interface) Get(c *Client) error {
 func (i *Interface) GetStatus() bool {
 	return i.materialized
 }
+
+// InterfaceFilter selects the interfaces returned by ListInterfaces by type.
+type InterfaceFilter string
+
+const (
+	// AllInterfaces returns interfaces of every type.
+	AllInterfaces InterfaceFilter = ""
+	// PhysicalInterfaces returns the ports of the switch.
+	PhysicalInterfaces InterfaceFilter = "system"
+	// LagInterfaces returns link aggregation interfaces.
+	LagInterfaces InterfaceFilter = "lag"
+	// VlanInterfaces returns VLAN interfaces (SVIs).
+	VlanInterfaces InterfaceFilter = "vlan"
+)
+
+// ListInterfaces performs GET to retrieve all interfaces of the given type
+// from the given Client object in a single request, ordered by Name, with
+// their Description, AdminState, Type and LinkState.
+func ListInterfaces(ctx context.Context, c *Client, filter InterfaceFilter) ([]Interface, error) {
+	base_uri := "system/interfaces"
+	url_str := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri + "?depth=2&attributes=name,description,admin,type,link_state"
+	if filter != AllInterfaces {
+		url_str += "&filter=type:" + url.QueryEscape(string(filter))
+	}
+
+	res, body, err := get(ctx, c, url_str)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, newRequestError("ListInterfaces", res)
+	}
+
+	interfaces := []Interface{}
+	for key, value := range body {
+		details, _ := value.(map[string]interface{})
+
+		i := Interface{
+			Name:             key,
+			InterfaceDetails: details,
+			materialized:     true,
+			uri:              "/rest/" + c.Version + "/" + base_uri + "/" + url.PathEscape(key),
+		}
+		i.Description, _ = details["description"].(string)
+		i.AdminState, _ = details["admin"].(string)
+		i.Type, _ = details["type"].(string)
+		i.LinkState, _ = details["link_state"].(string)
+
+		interfaces = append(interfaces, i)
+	}
+
+	sort.Slice(interfaces, func(a, b int) bool {
+		return interfaces[a].Name < interfaces[b].Name
+	})
+
+	return interfaces, nil
+}
@@ -0,0 +1,79 @@
+package aoscxgo_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aruba/aoscxgo"
+)
+
+func TestListInterfaces(t *testing.T) {
+	srv, c := connect(t)
+	ctx := context.Background()
+
+	srv.AddInterface("1/1/2")
+	srv.SetObject("system/interfaces", "1/1/1", map[string]interface{}{
+		"name":        "1/1/1",
+		"type":        "system",
+		"admin":       "up",
+		"link_state":  "up",
+		"description": "uplink",
+	})
+	srv.SetObject("system/interfaces", "lag1", map[string]interface{}{
+		"name":  "lag1",
+		"type":  "lag",
+		"admin": "up",
+	})
+
+	interfaces, err := aoscxgo.ListInterfaces(ctx, c, aoscxgo.AllInterfaces)
+	if err != nil {
+		t.Fatalf("ListInterfaces: %v", err)
+	}
+	var names []string
+	for _, i := range interfaces {
+		names = append(names, i.Name)
+	}
+	if len(names) != 3 || names[0] != "1/1/1" || names[1] != "1/1/2" || names[2] != "lag1" {
+		t.Fatalf("ListInterfaces = %v", names)
+	}
+
+	first := interfaces[0]
+	if first.Type != "system" || first.AdminState != "up" || first.LinkState != "up" || first.Description != "uplink" {
+		t.Errorf("1/1/1 = %+v", first)
+	}
+	if !first.GetStatus() {
+		t.Error("GetStatus = false for a listed interface")
+	}
+	if interfaces[1].AdminState != "down" || interfaces[2].Type != "lag" {
+		t.Errorf("1/1/2 = %+v, lag1 = %+v", interfaces[1], interfaces[2])
+	}
+
+	lags, err := aoscxgo.ListInterfaces(ctx, c, aoscxgo.LagInterfaces)
+	if err != nil {
+		t.Fatalf("ListInterfaces: %v", err)
+	}
+	if len(lags) != 1 || lags[0].Name != "lag1" {
+		t.Errorf("ListInterfaces(LagInterfaces) = %+v", lags)
+	}
+}
+
+func TestInterfaceGetUpdate(t *testing.T) {
+	srv, c := connect(t)
+	ctx := context.Background()
+	srv.AddInterface("1/1/1")
+
+	i := aoscxgo.Interface{Name: "1/1/1", Description: "server", AdminState: "up"}
+	err := i.Update(ctx, c)
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	got := aoscxgo.Interface{Name: "1/1/1"}
+	err = got.Get(ctx, c)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.Description != "server" || got.AdminState != "up" || got.Type != "system" {
+		t.Errorf("Get = %+v", got)
+	}
+}
@@ -2,9 +2,12 @@ package aoscxgo
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
-	"errors"
+	"fmt"
+	"net/http"
 	"net/url"
+	"sort"
 	"strconv"
 )
 
@@ -14,28 +17,25 @@ type L2Interface struct {
 	Interface        Interface              `json:"interface"`
 	Description      string                 `json:"description"`
 	VlanMode         string                 `json:"vlan_mode"`
-	VlanIds          []interface{}          `json:"vlan_ids"`
+	VlanIds          VlanList               `json:"vlan_ids"`
 	VlanTag          int                    `json:"vlan_tag"`
 	TrunkAllowedAll  bool                   `json:"trunk_allowed_all"`
 	NativeVlanTag    bool                   `json:"native_vlan_tag"`
 	InterfaceDetails map[string]interface{} `json:"details"`
-	materialized     bool                   `json:"materialized"`
+	materialized     bool
 }
 
 // Create performs PATCH to update L2Interface configuration on the given Client object.
-func (i *L2Interface) Create(c *Client) error {
+func (i *L2Interface) Create(ctx context.Context, c *Client) error {
 	base_uri := "system/interfaces"
 
 	patchMap := map[string]interface{}{}
 
 	if i.Interface.Name == "" {
-		return &RequestError{
-			StatusCode: "Missing Interface unable to configure L2Interface",
-			Err:        errors.New("Create Error"),
-		}
+		return newValidationError("L2Interface.Create", "Interface", "missing Interface unable to configure L2Interface")
 	}
 
-	err := i.Interface.checkValues()
+	err := i.Interface.checkValues("L2Interface.Create")
 	if err != nil {
 		return err
 
@@ -53,15 +53,12 @@ func (i *L2Interface) Create(c *Client) error {
 			VlanId: i.VlanTag,
 		}
 
-		err := tmp_vlan.Get(c)
+		err := tmp_vlan.Get(ctx, c)
 
 		if err != nil && !tmp_vlan.materialized {
-			err = tmp_vlan.Create(c)
+			err = tmp_vlan.Create(ctx, c)
 			if err != nil && !tmp_vlan.materialized {
-				return &RequestError{
-					StatusCode: "Vlan Not found unable to configure L2Interface",
-					Err:        errors.New("Create Error"),
-				}
+				return fmt.Errorf("L2Interface.Create: vlan %d not found unable to configure L2Interface: %w", i.VlanTag, err)
 			}
 		}
 		patchMap["vlan_tag"] = map[string]interface{}{strconv.Itoa(i.VlanTag): tmp_vlan.GetURI()}
@@ -82,43 +79,30 @@ func (i *L2Interface) Create(c *Client) error {
 				VlanId: i.VlanTag,
 			}
 
-			err := tmp_vlan.Get(c)
+			err := tmp_vlan.Get(ctx, c)
 
 			if err != nil && !tmp_vlan.materialized {
-				return &RequestError{
-					StatusCode: "Vlan Not found unable to configure L2Interface",
-					Err:        errors.New("Create Error"),
-				}
+				return fmt.Errorf("L2Interface.Create: vlan %d not found unable to configure L2Interface: %w", i.VlanTag, err)
 			}
 			patchMap["vlan_tag"] = map[string]interface{}{
 				strconv.Itoa(tmp_vlan.VlanId): tmp_vlan.GetURI(),
 			}
 		}
 
+		// An empty map allows all VLANs
 		vlan_trunks := map[string]interface{}{}
 
 		if !i.TrunkAllowedAll {
-			// Test what is behavior of List being empty or not
-			for _, item := range i.VlanIds {
-				tmp_vlan_obj := Vlan{VlanId: item.(int)}
-				err = tmp_vlan_obj.Get(c)
-				if err == nil {
-					vlan_trunks[strconv.Itoa(tmp_vlan_obj.VlanId)] = tmp_vlan_obj.GetURI()
-				}
-
+			vlan_trunks, err = trunkVlans(ctx, c, "L2Interface.Create", i.VlanIds)
+			if err != nil {
+				return err
 			}
-
-		} else {
-			vlan_trunks = map[string]interface{}{}
 		}
 		patchMap["vlan_trunks"] = vlan_trunks
 		patchMap["vlan_mode"] = i.VlanMode
 	} else {
-		status_str := "Invalid Required Value: VlanMode - valid options are 'access' or 'trunk' received: " + i.VlanMode
-		return &RequestError{
-			StatusCode: status_str,
-			Err:        errors.New("Create Error"),
-		}
+		status_str := "valid options are 'access' or 'trunk' received: " + i.VlanMode
+		return newValidationError("L2Interface.Create", "VlanMode", status_str)
 	}
 
 	// Make sure Interface exists in table before patching L2 attributes
@@ -126,13 +110,13 @@ func (i *L2Interface) Create(c *Client) error {
 		Name: i.Interface.Name,
 	}
 
-	err = tmp_int.Get(c)
+	err = tmp_int.Get(ctx, c)
 
 	if err != nil {
 		return err
 
 	} else if !tmp_int.materialized {
-		err = i.Interface.Create(c)
+		err = i.Interface.Create(ctx, c)
 
 		if err != nil {
 			return err
@@ -156,13 +140,13 @@ func (i *L2Interface) Create(c *Client) error {
 
 	json_body := bytes.NewBuffer(patchBody)
 
-	res := patch(c, url, json_body)
+	res, err := patch(ctx, c, url, json_body)
+	if err != nil {
+		return err
+	}
 
 	if res.Status != "204 No Content" {
-		return &RequestError{
-			StatusCode: res.Status,
-			Err:        errors.New("Create Error"),
-		}
+		return newRequestError("L2Interface.Create", res)
 	}
 
 	i.materialized = true
@@ -171,14 +155,14 @@ func (i *L2Interface) Create(c *Client) error {
 }
 
 // Update performs PATCH or PUT to update L2Interface configuration on the given Client object.
-func (i *L2Interface) Update(c *Client, use_put bool) error {
+func (i *L2Interface) Update(ctx context.Context, c *Client, use_put bool) error {
 	base_uri := "system/interfaces"
 
 	updateMap := map[string]interface{}{}
 
 	if use_put {
 		tmp_l2_int := L2Interface{Interface: i.Interface}
-		err := tmp_l2_int.Get(c)
+		err := tmp_l2_int.Get(ctx, c)
 		if err != nil {
 			return err
 		}
@@ -189,13 +173,10 @@ func (i *L2Interface) Update(c *Client, use_put bool) error {
 	}
 
 	if i.Interface.Name == "" {
-		return &RequestError{
-			StatusCode: "Missing Interface unable to configure L2Interface",
-			Err:        errors.New("Update Error"),
-		}
+		return newValidationError("L2Interface.Update", "Interface", "missing Interface unable to configure L2Interface")
 	}
 
-	err := i.Interface.checkValues()
+	err := i.Interface.checkValues("L2Interface.Update")
 	if err != nil {
 		return err
 
@@ -214,14 +195,10 @@ func (i *L2Interface) Update(c *Client, use_put bool) error {
 			VlanId: i.VlanTag,
 		}
 
-		err := tmp_vlan.Get(c)
+		err := tmp_vlan.Get(ctx, c)
 
 		if err != nil && !tmp_vlan.materialized {
-			err_str := "Vlan Not found unable to configure L2Interface Stats " + err.(*RequestError).StatusCode + " | " + strconv.FormatBool(tmp_vlan.materialized)
-			return &RequestError{
-				StatusCode: err_str,
-				Err:        errors.New("Update Error"),
-			}
+			return fmt.Errorf("L2Interface.Update: vlan %d not found unable to configure L2Interface: %w", i.VlanTag, err)
 		}
 		updateMap["vlan_tag"] = map[string]interface{}{strconv.Itoa(i.VlanTag): tmp_vlan.GetURI()}
 		updateMap["vlan_mode"] = "access"
@@ -241,44 +218,31 @@ func (i *L2Interface) Update(c *Client, use_put bool) error {
 				VlanId: i.VlanTag,
 			}
 
-			err := tmp_vlan.Get(c)
+			err := tmp_vlan.Get(ctx, c)
 
 			if err != nil && !tmp_vlan.materialized {
-				err_str := "Vlan Not found unable to configure L2Interface Stats " + err.(*RequestError).StatusCode + " | " + strconv.Itoa(i.VlanTag)
-				return &RequestError{
-					StatusCode: err_str,
-					Err:        errors.New("Update Error"),
-				}
+				return fmt.Errorf("L2Interface.Update: vlan %d not found unable to configure L2Interface: %w", i.VlanTag, err)
 			}
 			updateMap["vlan_tag"] = map[string]interface{}{
 				strconv.Itoa(tmp_vlan.VlanId): tmp_vlan.GetURI(),
 			}
 		}
 
+		// An empty map allows all VLANs
 		vlan_trunks := map[string]interface{}{}
 
 		if !i.TrunkAllowedAll {
-			// Test what is behavior of List being empty or not
-			for _, item := range i.VlanIds {
-				tmp_vlan_obj := Vlan{VlanId: item.(int)}
-				err = tmp_vlan_obj.Get(c)
-				if err == nil {
-					vlan_trunks[strconv.Itoa(tmp_vlan_obj.VlanId)] = tmp_vlan_obj.GetURI()
-				}
-
+			vlan_trunks, err = trunkVlans(ctx, c, "L2Interface.Update", i.VlanIds)
+			if err != nil {
+				return err
 			}
-		} else {
-			vlan_trunks = map[string]interface{}{}
 		}
 		updateMap["vlan_trunks"] = vlan_trunks
 		updateMap["vlan_mode"] = i.VlanMode
 
 	} else {
-		status_str := "Invalid Required Value: VlanMode - valid options are 'access' or 'trunk' received: " + i.VlanMode
-		return &RequestError{
-			StatusCode: status_str,
-			Err:        errors.New("Update Error"),
-		}
+		status_str := "valid options are 'access' or 'trunk' received: " + i.VlanMode
+		return newValidationError("L2Interface.Update", "VlanMode", status_str)
 	}
 
 	updateMap["description"] = i.Description
@@ -299,25 +263,21 @@ func (i *L2Interface) Update(c *Client, use_put bool) error {
 	json_body := bytes.NewBuffer(updateBody)
 
 	if use_put {
-		res := put(c, url, json_body)
+		res, err := put(ctx, c, url, json_body)
+		if err != nil {
+			return err
+		}
 		if res.Status != "200 OK" {
-			status_str := string(updateBody) + "PUT status code = " + res.Status
-			return &RequestError{
-				//StatusCode: res.Status,
-				StatusCode: status_str,
-				Err:        errors.New("Update Error"),
-			}
+			return newRequestError("L2Interface.Update", res)
 		}
 
 	} else {
-		res := patch(c, url, json_body)
+		res, err := patch(ctx, c, url, json_body)
+		if err != nil {
+			return err
+		}
 		if res.Status != "204 No Content" {
-			status_str := string(updateBody) + "PATCH status code = " + res.Status
-			return &RequestError{
-				//StatusCode: res.Status,
-				StatusCode: status_str,
-				Err:        errors.New("Update Error"),
-			}
+			return newRequestError("L2Interface.Update", res)
 		}
 	}
 
@@ -327,13 +287,10 @@ func (i *L2Interface) Update(c *Client, use_put bool) error {
 }
 
 // Delete performs PUT to remove/default L2Interface configuration from the given Client object.
-func (i *L2Interface) Delete(c *Client) error {
+func (i *L2Interface) Delete(ctx context.Context, c *Client) error {
 	base_uri := "system/interfaces"
 	if i.Interface.Name == "" {
-		return &RequestError{
-			StatusCode: "Missing Interface unable to delete L2Interface",
-			Err:        errors.New("Delete Error"),
-		}
+		return newValidationError("L2Interface.Delete", "Interface", "missing Interface unable to delete L2Interface")
 	}
 	int_str := url.PathEscape(i.Interface.Name)
 
@@ -347,39 +304,36 @@ func (i *L2Interface) Delete(c *Client) error {
 
 	//need logic for handling interfaces between platforms
 
-	res := put(c, url, json_body)
+	res, err := put(ctx, c, url, json_body)
+	if err != nil {
+		return err
+	}
 
 	if res.Status != "204 No Content" && res.Status != "200 OK" {
-		return &RequestError{
-			StatusCode: res.Status,
-			Err:        errors.New("Delete Error"),
-		}
+		return newRequestError("L2Interface.Delete", res)
 	}
 
 	return nil
 }
 
 // Get performs GET to retrieve L2Interface configuration from the given Client object.
-func (i *L2Interface) Get(c *Client) error {
+func (i *L2Interface) Get(ctx context.Context, c *Client) error {
 	base_uri := "system/interfaces"
 	if i.Interface.Name == "" {
-		return &RequestError{
-			StatusCode: "Missing Interface unable to retrieve L2Interface",
-			Err:        errors.New("Get Error"),
-		}
+		return newValidationError("L2Interface.Get", "Interface", "missing Interface unable to retrieve L2Interface")
 	}
 	int_str := url.PathEscape(i.Interface.Name)
 
 	url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri + "/" + int_str + "?selector=writable"
 
-	res, body := get(c, url)
+	res, body, err := get(ctx, c, url)
+	if err != nil {
+		return err
+	}
 
 	if res.Status != "200 OK" {
 		i.materialized = false
-		return &RequestError{
-			StatusCode: res.Status,
-			Err:        errors.New("Get Error"),
-		}
+		return newRequestError("L2Interface.Get", res)
 	}
 
 	if i.Interface.InterfaceDetails == nil {
@@ -418,24 +372,22 @@ func (i *L2Interface) Get(c *Client) error {
 
 		}
 
-		if key == "vlan_trunks" && len(value.(map[string]interface{})) != 0 {
-			// convert json to value singular
+		if key == "vlan_trunks" {
+			// convert json to list of VLAN IDs
 			// "vlan_trunks": {
 			// 	"42": "/rest/v10.09/system/vlans/42"
 			//   },
-			tmp_splice := []interface{}{}
-			for key, _ := range value.(map[string]interface{}) {
-				vlan_int, _ := strconv.Atoi(key)
-				tmp_splice = append(tmp_splice, vlan_int)
-
+			// an empty map allows all VLANs
+			vlan_trunks, _ := value.(map[string]interface{})
+			i.VlanIds = VlanList{}
+			for key := range vlan_trunks {
+				vlan_int, err := strconv.Atoi(key)
+				if err == nil {
+					i.VlanIds = append(i.VlanIds, vlan_int)
+				}
 			}
-
-			i.VlanIds = tmp_splice
-			i.TrunkAllowedAll = false
-
-		} else if key == "vlan_trunks" && len(value.(map[string]interface{})) == 0 {
-			i.TrunkAllowedAll = true
-			i.VlanIds = []interface{}{}
+			sort.Ints(i.VlanIds)
+			i.TrunkAllowedAll = len(i.VlanIds) == 0
 		}
 
 	}
@@ -449,3 +401,215 @@ func (i *L2Interface) Get(c *Client) error {
 func (i *L2Interface) GetStatus() bool {
 	return i.materialized
 }
+
+// AddTrunkVlans performs PATCH to allow the given VLANs on the trunk
+// L2Interface on the given Client object, keeping the VLANs already
+// allowed. Only vlan_trunks is changed and nothing is patched if the VLANs
+// are already allowed, including when the trunk allows all VLANs. The VLANs
+// must exist in either case, missing VLANs fail with ErrNotFound.
+func (i *L2Interface) AddTrunkVlans(ctx context.Context, c *Client, vlan_ids VlanList) error {
+	current, err := i.getTrunkVlans(ctx, c, "L2Interface.AddTrunkVlans")
+	if err != nil {
+		return err
+	}
+
+	// Allowing all VLANs includes the added ones, if they exist
+	if len(current) == 0 {
+		_, err = trunkVlans(ctx, c, "L2Interface.AddTrunkVlans", vlan_ids)
+		if err != nil {
+			return err
+		}
+		i.TrunkAllowedAll = true
+		i.VlanIds = VlanList{}
+		return nil
+	}
+
+	updated := append(VlanList{}, current...)
+	for _, vlan_id := range vlan_ids {
+		if !updated.Contains(vlan_id) {
+			updated = append(updated, vlan_id)
+		}
+	}
+
+	return i.patchTrunkVlans(ctx, c, "L2Interface.AddTrunkVlans", current, updated)
+}
+
+// RemoveTrunkVlans performs PATCH to stop allowing the given VLANs on the
+// trunk L2Interface on the given Client object, keeping the other VLANs
+// allowed. Only vlan_trunks is changed and nothing is sent if none of the
+// VLANs is allowed. If the trunk allows all VLANs, every other existing VLAN
+// stays allowed. Removing all VLANs is refused as an empty list allows all
+// VLANs.
+func (i *L2Interface) RemoveTrunkVlans(ctx context.Context, c *Client, vlan_ids VlanList) error {
+	current, err := i.getTrunkVlans(ctx, c, "L2Interface.RemoveTrunkVlans")
+	if err != nil {
+		return err
+	}
+
+	if len(current) == 0 {
+		existing, err := getVlanIds(ctx, c, "L2Interface.RemoveTrunkVlans")
+		if err != nil {
+			return err
+		}
+		current = existing
+	}
+
+	updated := VlanList{}
+	for _, vlan_id := range current {
+		if !vlan_ids.Contains(vlan_id) {
+			updated = append(updated, vlan_id)
+		}
+	}
+
+	if len(updated) == 0 {
+		return newValidationError("L2Interface.RemoveTrunkVlans", "VlanIds",
+			"removing all VLANs from trunk "+i.Interface.Name+" would allow all VLANs")
+	}
+
+	return i.patchTrunkVlans(ctx, c, "L2Interface.RemoveTrunkVlans", current, updated)
+}
+
+// getTrunkVlans performs GET to retrieve the VLANs allowed on the trunk,
+// an empty list allowing all VLANs.
+func (i *L2Interface) getTrunkVlans(ctx context.Context, c *Client, op string) (VlanList, error) {
+	base_uri := "system/interfaces"
+	if i.Interface.Name == "" {
+		return nil, newValidationError(op, "Interface", "missing Interface unable to configure L2Interface")
+	}
+	int_str := url.PathEscape(i.Interface.Name)
+
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri + "/" + int_str + "?attributes=vlan_mode,vlan_trunks"
+
+	res, _, err := get(ctx, c, url)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, newRequestError(op, res)
+	}
+
+	payload := struct {
+		VlanMode   *string         `json:"vlan_mode"`
+		VlanTrunks json.RawMessage `json:"vlan_trunks"`
+	}{}
+	err = json.NewDecoder(res.Body).Decode(&payload)
+	if err != nil {
+		return nil, fmt.Errorf("%s: decoding interface %s: %w", op, i.Interface.Name, err)
+	}
+
+	if payload.VlanMode == nil || *payload.VlanMode == "access" {
+		return nil, newValidationError(op, "VlanMode", "interface "+i.Interface.Name+" is not a trunk")
+	}
+
+	current := VlanList{}
+	for _, key := range referenceKeys(payload.VlanTrunks) {
+		vlan_id, err := strconv.Atoi(key)
+		if err == nil {
+			current = append(current, vlan_id)
+		}
+	}
+	sort.Ints(current)
+
+	return current, nil
+}
+
+// patchTrunkVlans performs PATCH to set the VLANs allowed on the trunk if
+// they differ from current. Added VLANs must exist.
+func (i *L2Interface) patchTrunkVlans(ctx context.Context, c *Client, op string, current VlanList, updated VlanList) error {
+	base_uri := "system/interfaces"
+	sort.Ints(updated)
+
+	added := VlanList{}
+	for _, vlan_id := range updated {
+		if !current.Contains(vlan_id) {
+			added = append(added, vlan_id)
+		}
+	}
+
+	if len(added) == 0 && len(updated) == len(current) {
+		i.VlanIds = updated
+		i.TrunkAllowedAll = false
+		return nil
+	}
+
+	vlan_trunks, err := trunkVlans(ctx, c, op, updated)
+	if err != nil {
+		return err
+	}
+
+	patchBody, _ := json.Marshal(map[string]interface{}{
+		"vlan_trunks": vlan_trunks,
+	})
+
+	json_body := bytes.NewBuffer(patchBody)
+
+	int_str := url.PathEscape(i.Interface.Name)
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri + "/" + int_str
+
+	res, err := patch(ctx, c, url, json_body)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusNoContent {
+		return newRequestError(op, res)
+	}
+
+	i.VlanIds = updated
+	i.TrunkAllowedAll = false
+
+	return nil
+}
+
+// trunkVlans returns the vlan_trunks reference map of the given VLANs,
+// failing with ErrNotFound if any of them does not exist.
+func trunkVlans(ctx context.Context, c *Client, op string, vlan_ids VlanList) (map[string]interface{}, error) {
+	existing, err := getVlanIds(ctx, c, op)
+	if err != nil {
+		return nil, err
+	}
+
+	vlan_trunks := map[string]interface{}{}
+	missing := []int{}
+	for _, vlan_id := range vlan_ids {
+		if !existing.Contains(vlan_id) {
+			missing = append(missing, vlan_id)
+			continue
+		}
+		vlan_str := strconv.Itoa(vlan_id)
+		vlan_trunks[vlan_str] = "/rest/" + c.Version + "/system/vlans/" + vlan_str
+	}
+
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%s: VLANs %s not found - create them before allowing them on a trunk: %w",
+			op, FormatVlanRange(missing), ErrNotFound)
+	}
+
+	return vlan_trunks, nil
+}
+
+// getVlanIds performs GET to retrieve the IDs of all VLANs.
+func getVlanIds(ctx context.Context, c *Client, op string) (VlanList, error) {
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/system/vlans"
+
+	res, body, err := get(ctx, c, url)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, newRequestError(op, res)
+	}
+
+	vlan_ids := VlanList{}
+	for key := range body {
+		vlan_id, err := strconv.Atoi(key)
+		if err == nil {
+			vlan_ids = append(vlan_ids, vlan_id)
+		}
+	}
+	sort.Ints(vlan_ids)
+
+	return vlan_ids, nil
+}
@@ -0,0 +1,151 @@
+package aoscxgo_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aruba/aoscxgo"
+	"github.com/aruba/aoscxgo/aoscxtest"
+)
+
+func TestVlanList(t *testing.T) {
+	list, err := aoscxgo.ParseVlanList("30, 10-12,11")
+	if err != nil {
+		t.Fatalf("ParseVlanList: %v", err)
+	}
+	if list.String() != "10-12,30" || !list.Contains(11) || list.Contains(13) {
+		t.Errorf("ParseVlanList = %v", list)
+	}
+
+	for _, invalid := range []string{"0", "4095", "20-10", "a"} {
+		_, err := aoscxgo.ParseVlanList(invalid)
+		if err == nil {
+			t.Errorf("ParseVlanList(%q) succeeded", invalid)
+		}
+	}
+
+	for _, data := range []string{`"10-12"`, `[10,11,12]`} {
+		var decoded aoscxgo.VlanList
+		err := json.Unmarshal([]byte(data), &decoded)
+		if err != nil || decoded.String() != "10-12" {
+			t.Errorf("Unmarshal(%s) = %v, %v", data, decoded, err)
+		}
+	}
+}
+
+// createTrunk creates VLANs 10, 20 and 30 and the trunk 1/1/1 allowing
+// vlan_ids.
+func createTrunk(t *testing.T, srv *aoscxtest.Server, c *aoscxgo.Client, vlan_ids aoscxgo.VlanList) aoscxgo.L2Interface {
+	t.Helper()
+	ctx := context.Background()
+
+	srv.AddInterface("1/1/1")
+	for _, vlan_id := range []int{10, 20, 30} {
+		vlan := aoscxgo.Vlan{VlanId: vlan_id, Name: "vlan"}
+		err := vlan.Create(ctx, c)
+		if err != nil {
+			t.Fatalf("Vlan.Create: %v", err)
+		}
+	}
+
+	trunk := aoscxgo.L2Interface{
+		Interface:       aoscxgo.Interface{Name: "1/1/1", AdminState: "up"},
+		VlanMode:        "trunk",
+		VlanTag:         1,
+		VlanIds:         vlan_ids,
+		TrunkAllowedAll: len(vlan_ids) == 0,
+	}
+	err := trunk.Create(ctx, c)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	return trunk
+}
+
+// trunkVlans returns the VLANs allowed on 1/1/1 as held by the switch.
+func trunkVlans(t *testing.T, srv *aoscxtest.Server) string {
+	t.Helper()
+
+	obj, _ := srv.Object("system/interfaces", "1/1/1")
+	trunks, _ := obj["vlan_trunks"].(map[string]interface{})
+	var vlan_ids aoscxgo.VlanList
+	for key := range trunks {
+		list, _ := aoscxgo.ParseVlanList(key)
+		vlan_ids = append(vlan_ids, list...)
+	}
+	return vlan_ids.String()
+}
+
+func TestL2InterfaceTrunkVlans(t *testing.T) {
+	srv, c := connect(t)
+	ctx := context.Background()
+	trunk := createTrunk(t, srv, c, aoscxgo.VlanList{10})
+
+	err := trunk.AddTrunkVlans(ctx, c, aoscxgo.VlanList{20, 30})
+	if err != nil {
+		t.Fatalf("AddTrunkVlans: %v", err)
+	}
+	if got := trunkVlans(t, srv); got != "10,20,30" {
+		t.Errorf("after AddTrunkVlans the trunk allows %s", got)
+	}
+
+	err = trunk.RemoveTrunkVlans(ctx, c, aoscxgo.VlanList{10, 20})
+	if err != nil {
+		t.Fatalf("RemoveTrunkVlans: %v", err)
+	}
+	if got := trunkVlans(t, srv); got != "30" {
+		t.Errorf("after RemoveTrunkVlans the trunk allows %s", got)
+	}
+
+	err = trunk.RemoveTrunkVlans(ctx, c, aoscxgo.VlanList{30})
+	if !errors.Is(err, aoscxgo.ErrValidation) {
+		t.Errorf("RemoveTrunkVlans of the last VLAN = %v, want ErrValidation", err)
+	}
+
+	err = trunk.AddTrunkVlans(ctx, c, aoscxgo.VlanList{40})
+	if err == nil {
+		t.Error("AddTrunkVlans of a missing VLAN succeeded")
+	}
+
+	got := aoscxgo.L2Interface{Interface: aoscxgo.Interface{Name: "1/1/1"}}
+	err = got.Get(ctx, c)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.VlanIds.String() != "30" || got.TrunkAllowedAll {
+		t.Errorf("Get = %+v", got)
+	}
+}
+
+func TestL2InterfaceTrunkAllowedAll(t *testing.T) {
+	srv, c := connect(t)
+	ctx := context.Background()
+	trunk := createTrunk(t, srv, c, nil)
+
+	// Adding to a trunk allowing all VLANs changes nothing
+	err := trunk.AddTrunkVlans(ctx, c, aoscxgo.VlanList{10})
+	if err != nil {
+		t.Fatalf("AddTrunkVlans: %v", err)
+	}
+	if got := trunkVlans(t, srv); got != "" {
+		t.Errorf("after AddTrunkVlans the trunk allows %s", got)
+	}
+
+	// Missing VLANs are reported even though nothing would change
+	err = trunk.AddTrunkVlans(ctx, c, aoscxgo.VlanList{10, 40})
+	if !errors.Is(err, aoscxgo.ErrNotFound) || !strings.Contains(err.Error(), "40") {
+		t.Errorf("AddTrunkVlans of a missing VLAN = %v, want ErrNotFound naming VLAN 40", err)
+	}
+
+	// Removing keeps every other existing VLAN allowed
+	err = trunk.RemoveTrunkVlans(ctx, c, aoscxgo.VlanList{20})
+	if err != nil {
+		t.Fatalf("RemoveTrunkVlans: %v", err)
+	}
+	if got := trunkVlans(t, srv); got != "1,10,30" {
+		t.Errorf("after RemoveTrunkVlans the trunk allows %s", got)
+	}
+}
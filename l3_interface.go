@@ -2,8 +2,8 @@ package aoscxgo
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"net"
 	"net/http"
@@ -22,28 +22,30 @@ type L3Interface struct {
 	Ipv6             []interface{}          `json:"ipv6"`
 	Vrf              string                 `json:"vrf"`
 	InterfaceDetails map[string]interface{} `json:"details"`
-	materialized     bool                   `json:"materialized"`
+	materialized     bool
 }
 
 // Create performs PATCH to update L3Interface configuration on the given Client object.
-func (i *L3Interface) Create(c *Client) error {
+func (i *L3Interface) Create(ctx context.Context, c *Client) error {
 	base_uri := "system/interfaces"
 
 	createMap := map[string]interface{}{}
 
 	if i.Interface.Name == "" {
-		return &RequestError{
-			StatusCode: "Missing Interface unable to configure L3Interface",
-			Err:        errors.New("Create Error"),
-		}
+		return newValidationError("L3Interface.Create", "Interface", "missing Interface unable to configure L3Interface")
 	}
 
-	err := i.Interface.checkValues()
+	err := i.Interface.checkValues("L3Interface.Create")
 	if err != nil {
 		return err
 
 	}
 
+	err = checkVrfExists(ctx, c, "L3Interface.Create", i.Vrf)
+	if err != nil {
+		return err
+	}
+
 	int_str := url.PathEscape(i.Interface.Name)
 	url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri + "/" + int_str
 
@@ -51,11 +53,7 @@ func (i *L3Interface) Create(c *Client) error {
 	createMap["admin"] = i.Interface.AdminState
 	createMap["routing"] = true
 
-	if i.Vrf == "" {
-		createMap["vrf"] = "/rest/" + c.Version + "/system/vrfs/" + "default"
-	} else {
-		createMap["vrf"] = "/rest/" + c.Version + "/system/vrfs/" + i.Vrf
-	}
+	createMap["vrf"] = vrfURI(c, i.Vrf)
 
 	//check if it's ipv6
 	// Validate ipv4 address
@@ -69,11 +67,8 @@ func (i *L3Interface) Create(c *Client) error {
 			createMap["ip4_address"] = str_ipv4_1
 			createMap["ip4_address_secondary"] = nil
 		} else {
-			status_str := "Invalid Required Value: Ipv4 - ensure addresses are in ipv4 format: " + str_ipv4_1
-			return &RequestError{
-				StatusCode: status_str,
-				Err:        errors.New("Create Error"),
-			}
+			status_str := "ensure addresses are in ipv4 format: " + str_ipv4_1
+			return newValidationError("L3Interface.Create", "Ipv4", status_str)
 		}
 
 	} else if len(i.Ipv4) > 1 {
@@ -81,11 +76,8 @@ func (i *L3Interface) Create(c *Client) error {
 		if checkIPAddress(str_ipv4_1) {
 			createMap["ip4_address"] = i.Ipv4[0]
 		} else {
-			status_str := "Invalid Required Value: Ipv4 - ensure addresses are in ipv4 format: " + str_ipv4_1
-			return &RequestError{
-				StatusCode: status_str,
-				Err:        errors.New("Create Error"),
-			}
+			status_str := "ensure addresses are in ipv4 format: " + str_ipv4_1
+			return newValidationError("L3Interface.Create", "Ipv4", status_str)
 		}
 
 		var tmp_splice []string
@@ -94,18 +86,15 @@ func (i *L3Interface) Create(c *Client) error {
 			if checkIPAddress(str_ipv4_tmp) {
 				tmp_splice = append(tmp_splice, str_ipv4_tmp)
 			} else {
-				status_str := "Invalid Required Value: Ipv4 - ensure addresses are in ipv4 format: " + str_ipv4_tmp
-				return &RequestError{
-					StatusCode: status_str,
-					Err:        errors.New("Create Error"),
-				}
+				status_str := "ensure addresses are in ipv4 format: " + str_ipv4_tmp
+				return newValidationError("L3Interface.Create", "Ipv4", status_str)
 			}
 		}
 		createMap["ip4_address_secondary"] = tmp_splice
 	}
 
-	// string to track ipv6 Create success
-	failed_ipv6 := ""
+	// error to track ipv6 Create success
+	var failed_ipv6 error
 
 	if len(i.Ipv6) == 0 {
 		// What are default values when no ipv6 but routing enabled
@@ -133,19 +122,19 @@ func (i *L3Interface) Create(c *Client) error {
 
 					ip6_url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri + "/" + int_str + "/" + "ip6_addresses"
 
-					res := post(c, ip6_url, json_body)
+					res, err := post(ctx, c, ip6_url, json_body)
+					if err != nil {
+						return err
+					}
 
 					if res.StatusCode != http.StatusCreated {
-						failed_ipv6 = fmt.Sprintf("\nip6_addresses failed to create %v\nstatus code %v", i.Ipv6, res.Status)
+						failed_ipv6 = newRequestError("L3Interface.Create", res)
 					}
 
 				} else {
-					status_str := "Invalid Required Value: Ipv6 - ensure addresses are in ipv6 address/mask format:" +
+					status_str := "ensure addresses are in ipv6 address/mask format: " +
 						ip_address.(string)
-					return &RequestError{
-						StatusCode: status_str,
-						Err:        errors.New("Create Error"),
-					}
+					return newValidationError("L3Interface.Create", "Ipv6", status_str)
 				}
 			}
 		}
@@ -165,13 +154,13 @@ func (i *L3Interface) Create(c *Client) error {
 		Name: i.Interface.Name,
 	}
 
-	err = tmp_int.Get(c)
+	err = tmp_int.Get(ctx, c)
 
 	if err != nil {
 		return err
 
 	} else if !tmp_int.materialized {
-		err = i.Interface.Create(c)
+		err = i.Interface.Create(ctx, c)
 
 		if err != nil {
 			return err
@@ -183,26 +172,15 @@ func (i *L3Interface) Create(c *Client) error {
 
 	json_body := bytes.NewBuffer(patchBody)
 
-	res := patch(c, url, json_body)
+	res, err := patch(ctx, c, url, json_body)
+	if err != nil {
+		return err
+	}
 
-	if res.StatusCode != http.StatusNoContent && failed_ipv6 != "" {
-		// Combine error messages
-		status_str := "L3Interface Create Failed\nstatus " + res.Status
-		status_str = status_str + failed_ipv6
-		return &RequestError{
-			StatusCode: status_str,
-			Err:        errors.New("Create Error"),
-		}
-	} else if res.StatusCode != http.StatusNoContent {
-		return &RequestError{
-			StatusCode: res.Status,
-			Err:        errors.New("Create Error"),
-		}
-	} else if failed_ipv6 != "" {
-		return &RequestError{
-			StatusCode: failed_ipv6,
-			Err:        errors.New("Create Error"),
-		}
+	if res.StatusCode != http.StatusNoContent {
+		return newRequestError("L3Interface.Create", res)
+	} else if failed_ipv6 != nil {
+		return failed_ipv6
 	}
 
 	i.materialized = true
@@ -211,7 +189,7 @@ func (i *L3Interface) Create(c *Client) error {
 }
 
 // Update performs PATCH or PUT to update L3Interface configuration on the given Client object.
-func (i *L3Interface) Update(c *Client, use_put bool) error {
+func (i *L3Interface) Update(ctx context.Context, c *Client, use_put bool) error {
 	base_uri := "system/interfaces"
 
 	updateMap := map[string]interface{}{}
@@ -219,7 +197,7 @@ func (i *L3Interface) Update(c *Client, use_put bool) error {
 
 	if use_put {
 		tmp_l3_int := L3Interface{Interface: i.Interface}
-		err := tmp_l3_int.Get(c)
+		err := tmp_l3_int.Get(ctx, c)
 		if err != nil {
 			return err
 		}
@@ -229,11 +207,7 @@ func (i *L3Interface) Update(c *Client, use_put bool) error {
 		}
 	}
 
-	if i.Vrf == "" {
-		updateMap["vrf"] = "/rest/" + c.Version + "/system/vrfs/" + "default"
-	} else {
-		updateMap["vrf"] = "/rest/" + c.Version + "/system/vrfs/" + i.Vrf
-	}
+	updateMap["vrf"] = vrfURI(c, i.Vrf)
 
 	//check if it's ipv6
 	// Validate ipv4 address
@@ -247,11 +221,8 @@ func (i *L3Interface) Update(c *Client, use_put bool) error {
 			updateMap["ip4_address"] = str_ipv4_1
 			updateMap["ip4_address_secondary"] = nil
 		} else {
-			status_str := "Invalid Required Value: Ipv4 - ensure addresses are in ipv4 format: " + str_ipv4_1
-			return &RequestError{
-				StatusCode: status_str,
-				Err:        errors.New("Update Error"),
-			}
+			status_str := "ensure addresses are in ipv4 format: " + str_ipv4_1
+			return newValidationError("L3Interface.Update", "Ipv4", status_str)
 		}
 
 	} else if len(i.Ipv4) > 1 {
@@ -259,11 +230,8 @@ func (i *L3Interface) Update(c *Client, use_put bool) error {
 		if checkIPAddress(str_ipv4_1) {
 			updateMap["ip4_address"] = i.Ipv4[0]
 		} else {
-			status_str := "Invalid Required Value: Ipv4 - ensure addresses are in ipv4 format: " + str_ipv4_1
-			return &RequestError{
-				StatusCode: status_str,
-				Err:        errors.New("Update Error"),
-			}
+			status_str := "ensure addresses are in ipv4 format: " + str_ipv4_1
+			return newValidationError("L3Interface.Update", "Ipv4", status_str)
 		}
 
 		var tmp_splice []string
@@ -272,11 +240,8 @@ func (i *L3Interface) Update(c *Client, use_put bool) error {
 			if checkIPAddress(str_ipv4_tmp) {
 				tmp_splice = append(tmp_splice, str_ipv4_tmp)
 			} else {
-				status_str := "Invalid Required Value: Ipv4 - ensure addresses are in ipv4 format: " + str_ipv4_tmp
-				return &RequestError{
-					StatusCode: status_str,
-					Err:        errors.New("Update Error"),
-				}
+				status_str := "ensure addresses are in ipv4 format: " + str_ipv4_tmp
+				return newValidationError("L3Interface.Update", "Ipv4", status_str)
 			}
 		}
 		updateMap["ip4_address_secondary"] = tmp_splice
@@ -287,24 +252,24 @@ func (i *L3Interface) Update(c *Client, use_put bool) error {
 		updateMap["ip6_addresses"] = nil
 		// retrieve what's existing on the switch and remove
 		ip6_url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri + "/" + int_str + "/" + "ip6_addresses"
-		res, body := get(c, ip6_url)
+		res, body, err := get(ctx, c, ip6_url)
+		if err != nil {
+			return err
+		}
 
 		if res.StatusCode != http.StatusOK {
-			return &RequestError{
-				StatusCode: res.Status,
-				Err:        errors.New("Retrieval Error"),
-			}
+			return newRequestError("L3Interface.Update", res)
 		}
 		for key, _ := range body {
 			tmp_ip6_str := url.QueryEscape(key)
 			del_ip6_url := ip6_url + "/" + tmp_ip6_str
-			res := delete(c, del_ip6_url)
+			res, err := delete(ctx, c, del_ip6_url)
+			if err != nil {
+				return err
+			}
 
 			if res.StatusCode != http.StatusNoContent {
-				return &RequestError{
-					StatusCode: res.Status,
-					Err:        errors.New("Delete Error"),
-				}
+				return newRequestError("L3Interface.Update", res)
 			}
 		}
 	} else if len(i.Ipv6) > 0 {
@@ -317,13 +282,13 @@ func (i *L3Interface) Update(c *Client, use_put bool) error {
 		// iterate over the list of IPs
 		// delete the ones that aren't provided
 
-		res, body := get(c, ip6_url)
+		res, body, err := get(ctx, c, ip6_url)
+		if err != nil {
+			return err
+		}
 
 		if res.StatusCode != http.StatusOK {
-			return &RequestError{
-				StatusCode: res.Status,
-				Err:        errors.New("Retrieval Error"),
-			}
+			return newRequestError("L3Interface.Update", res)
 		}
 
 		var ipv6_slice []string
@@ -339,13 +304,13 @@ func (i *L3Interface) Update(c *Client, use_put bool) error {
 			if !slices.Contains(ipv6_slice, key) {
 				tmp_ip6_str := url.QueryEscape(key)
 				del_ip6_url := ip6_url + "/" + tmp_ip6_str
-				res := delete(c, del_ip6_url)
+				res, err := delete(ctx, c, del_ip6_url)
+				if err != nil {
+					return err
+				}
 
 				if res.StatusCode != http.StatusNoContent {
-					return &RequestError{
-						StatusCode: res.Status,
-						Err:        errors.New("Delete Error"),
-					}
+					return newRequestError("L3Interface.Update", res)
 				}
 
 			} else {
@@ -368,25 +333,20 @@ func (i *L3Interface) Update(c *Client, use_put bool) error {
 
 				json_body := bytes.NewBuffer(ipv6body)
 
-				res := post(c, ip6_url, json_body)
+				res, err := post(ctx, c, ip6_url, json_body)
+				if err != nil {
+					return err
+				}
 
 				// include logic to check if address is existing?
 				if res.StatusCode != http.StatusCreated {
-					status_str := "ip6_addresses failed to update " +
-						str_ipv6 + " status " + res.Status
-					return &RequestError{
-						StatusCode: status_str,
-						Err:        errors.New("Update Error"),
-					}
+					return newRequestError("L3Interface.Update", res)
 				}
 
 			} else if !checkIPAddress(str_ipv6) {
-				status_str := "Invalid Required Value: Ipv6 - ensure addresses are in ipv6 address/mask format:" +
+				status_str := "ensure addresses are in ipv6 address/mask format: " +
 					str_ipv6
-				return &RequestError{
-					StatusCode: status_str,
-					Err:        errors.New("Update Error"),
-				}
+				return newValidationError("L3Interface.Update", "Ipv6", status_str)
 			}
 		}
 	}
@@ -400,18 +360,20 @@ func (i *L3Interface) Update(c *Client, use_put bool) error {
 	}
 
 	if i.Interface.Name == "" {
-		return &RequestError{
-			StatusCode: "Missing Interface.Name unable to configure L3Interface",
-			Err:        errors.New("Update Error"),
-		}
+		return newValidationError("L3Interface.Update", "Interface", "missing Interface.Name unable to configure L3Interface")
 	}
 
-	err := i.Interface.checkValues()
+	err := i.Interface.checkValues("L3Interface.Update")
 	if err != nil {
 		return err
 
 	}
 
+	err = checkVrfExists(ctx, c, "L3Interface.Update", i.Vrf)
+	if err != nil {
+		return err
+	}
+
 	url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri + "/" + int_str
 
 	updateMap["description"] = i.Description
@@ -434,25 +396,21 @@ func (i *L3Interface) Update(c *Client, use_put bool) error {
 	json_body := bytes.NewBuffer(updateBody)
 
 	if use_put {
-		res := put(c, url, json_body)
+		res, err := put(ctx, c, url, json_body)
+		if err != nil {
+			return err
+		}
 		if res.StatusCode != http.StatusOK {
-			status_str := string(updateBody) + "\nPUT status code = " + res.Status
-			return &RequestError{
-				//StatusCode: res.Status,
-				StatusCode: status_str,
-				Err:        errors.New("Update Error"),
-			}
+			return newRequestError("L3Interface.Update", res)
 		}
 
 	} else {
-		res := patch(c, url, json_body)
+		res, err := patch(ctx, c, url, json_body)
+		if err != nil {
+			return err
+		}
 		if res.StatusCode != http.StatusNoContent {
-			status_str := string(updateBody) + "\nPATCH status code = " + res.Status
-			return &RequestError{
-				//StatusCode: res.Status,
-				StatusCode: status_str,
-				Err:        errors.New("Update Error"),
-			}
+			return newRequestError("L3Interface.Update", res)
 		}
 	}
 
@@ -462,13 +420,10 @@ func (i *L3Interface) Update(c *Client, use_put bool) error {
 }
 
 // Delete performs PUT to remove/default L3Interface configuration from the given Client object.
-func (i *L3Interface) Delete(c *Client) error {
+func (i *L3Interface) Delete(ctx context.Context, c *Client) error {
 	base_uri := "system/interfaces"
 	if i.Interface.Name == "" {
-		return &RequestError{
-			StatusCode: "Missing Interface unable to delete L3Interface",
-			Err:        errors.New("Delete Error"),
-		}
+		return newValidationError("L3Interface.Delete", "Interface", "missing Interface unable to delete L3Interface")
 	}
 	int_str := url.PathEscape(i.Interface.Name)
 
@@ -480,39 +435,36 @@ func (i *L3Interface) Delete(c *Client) error {
 
 	url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri + "/" + int_str
 
-	res := put(c, url, json_body)
+	res, err := put(ctx, c, url, json_body)
+	if err != nil {
+		return err
+	}
 
 	if res.StatusCode != http.StatusNoContent && res.StatusCode != http.StatusOK {
-		return &RequestError{
-			StatusCode: res.Status,
-			Err:        errors.New("Delete Error"),
-		}
+		return newRequestError("L3Interface.Delete", res)
 	}
 
 	return nil
 }
 
 // Get performs GET to retrieve L3Interface configuration from the given Client object.
-func (i *L3Interface) Get(c *Client) error {
+func (i *L3Interface) Get(ctx context.Context, c *Client) error {
 	base_uri := "system/interfaces"
 	if i.Interface.Name == "" {
-		return &RequestError{
-			StatusCode: "Missing Interface unable to configure L3Interface",
-			Err:        errors.New("Create Error"),
-		}
+		return newValidationError("L3Interface.Get", "Interface", "missing Interface unable to configure L3Interface")
 	}
 	int_str := url.PathEscape(i.Interface.Name)
 
 	url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri + "/" + int_str + "?selector=writable"
 
-	res, body := get(c, url)
+	res, body, err := get(ctx, c, url)
+	if err != nil {
+		return err
+	}
 
 	if res.StatusCode != http.StatusOK {
 		i.materialized = false
-		return &RequestError{
-			StatusCode: res.Status,
-			Err:        errors.New("Retrieval Error"),
-		}
+		return newRequestError("L3Interface.Get", res)
 	}
 
 	if i.Interface.InterfaceDetails == nil {
@@ -533,8 +485,7 @@ func (i *L3Interface) Get(c *Client) error {
 		if key == "ip4_address" && value != nil {
 			var tmp_splice []interface{}
 			tmp_splice = append(tmp_splice, value.(string))
-			if val, ok := body["ip4_address_secondary"]; ok {
-				tmp_addr := val.([]interface{})
+			if tmp_addr, ok := body["ip4_address_secondary"].([]interface{}); ok {
 				if len(tmp_addr) > 0 {
 					for index := 0; index < len(tmp_addr); index++ {
 						tmp_splice = append(tmp_splice, tmp_addr[index])
@@ -556,13 +507,13 @@ func (i *L3Interface) Get(c *Client) error {
 
 	ip6_url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri + "/" + int_str + "/" + "ip6_addresses"
 
-	res, body = get(c, ip6_url)
+	res, body, err = get(ctx, c, ip6_url)
+	if err != nil {
+		return err
+	}
 
 	if res.StatusCode != http.StatusOK {
-		return &RequestError{
-			StatusCode: res.Status,
-			Err:        errors.New("Retrieval Error"),
-		}
+		return newRequestError("L3Interface.Get", res)
 	}
 
 	var ipv6_slice []string
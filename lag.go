@@ -0,0 +1,436 @@
+package aoscxgo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"golang.org/x/exp/slices"
+)
+
+// Lag is a link aggregation interface, e.g. "lag1". Its Interface can be
+// used as the Interface of L2Interface and L3Interface to configure it.
+type Lag struct {
+
+	// Connection properties.
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// AdminState is "up" or "down", Create defaults to "down".
+	AdminState string `json:"admin"`
+	// Members lists the member ports, e.g. "1/1/1".
+	Members []string `json:"members"`
+	// LacpMode is "active", "passive" or "static" for a LAG without LACP,
+	// defaults to "active".
+	LacpMode string `json:"lacp_mode"`
+	// LacpRate is "slow" or "fast", defaults to "slow".
+	LacpRate string `json:"lacp_rate"`
+	// Fallback lets a single member forward traffic while no LACP partner
+	// is detected.
+	Fallback bool `json:"fallback"`
+	// Hash is the load balancing algorithm, "l2-src-dst", "l3-src-dst" or
+	// "l4-src-dst", defaults to "l3-src-dst".
+	Hash string `json:"hash"`
+	// MinLinks is the number of members that must be up for the LAG to be
+	// up, zero for no minimum.
+	MinLinks int `json:"min_links"`
+	// MultiChassis makes the LAG span both switches of a VSX pair, it must
+	// be configured alike on both, see ValidateVsxPair.
+	MultiChassis bool `json:"multi_chassis"`
+
+	LagDetails   map[string]interface{} `json:"details"`
+	materialized bool
+	uri          string
+}
+
+// Keys of the LAG settings in other_config.
+const (
+	lag_lacp_rate = "lacp-time"
+	lag_fallback  = "lacp-fallback-ab"
+	lag_hash      = "bond_mode"
+	lag_min_links = "lacp-min-links"
+	lag_mclag     = "mclag_enabled"
+)
+
+// checkLagName validates if LAG Name is valid or not
+func checkLagName(name string) bool {
+	found, err := regexp.MatchString("^lag\\d+$", name)
+	return found && err == nil
+}
+
+// checkValues validates the LAG settings, op names the calling operation in
+// the returned error.
+func (l *Lag) checkValues(op string) error {
+	if !checkLagName(l.Name) {
+		return newValidationError(op, "Name", "invalid LAG name, expected lagN received: "+l.Name)
+	}
+	if l.AdminState != "" && l.AdminState != "up" && l.AdminState != "down" {
+		return newValidationError(op, "AdminState", "valid options are 'up' or 'down' received: "+l.AdminState)
+	}
+	if l.LacpMode != "" && !slices.Contains([]string{"active", "passive", "static"}, l.LacpMode) {
+		return newValidationError(op, "LacpMode", "valid options are 'active', 'passive' or 'static' received: "+l.LacpMode)
+	}
+	if l.LacpRate != "" && l.LacpRate != "slow" && l.LacpRate != "fast" {
+		return newValidationError(op, "LacpRate", "valid options are 'slow' or 'fast' received: "+l.LacpRate)
+	}
+	if l.Hash != "" && !slices.Contains([]string{"l2-src-dst", "l3-src-dst", "l4-src-dst"}, l.Hash) {
+		return newValidationError(op, "Hash", "valid options are 'l2-src-dst', 'l3-src-dst' or 'l4-src-dst' received: "+l.Hash)
+	}
+	if l.MinLinks < 0 {
+		return newValidationError(op, "MinLinks", "must not be negative")
+	}
+	for _, member := range l.Members {
+		if !checkName(member) || checkLagName(member) {
+			return newValidationError(op, "Members", "invalid member port: "+member)
+		}
+	}
+	return nil
+}
+
+// lagMap returns the attributes of the LAG to send on Create, unset fields
+// taking their defaults.
+func (l *Lag) lagMap(c *Client) map[string]interface{} {
+	admin := l.AdminState
+	if admin == "" {
+		admin = "down"
+	}
+
+	lacp := "active"
+	if l.LacpMode == "static" {
+		lacp = "off"
+	} else if l.LacpMode != "" {
+		lacp = l.LacpMode
+	}
+
+	lacp_rate := l.LacpRate
+	if lacp_rate == "" {
+		lacp_rate = "slow"
+	}
+	hash := l.Hash
+	if hash == "" {
+		hash = "l3-src-dst"
+	}
+
+	other_config := map[string]interface{}{
+		lag_lacp_rate: lacp_rate,
+		lag_fallback:  strconv.FormatBool(l.Fallback),
+		lag_hash:      hash,
+	}
+	if l.MinLinks > 0 {
+		other_config[lag_min_links] = strconv.Itoa(l.MinLinks)
+	}
+	if l.MultiChassis {
+		other_config[lag_mclag] = "true"
+	}
+
+	return map[string]interface{}{
+		"description":  l.Description,
+		"admin":        admin,
+		"user_config":  map[string]interface{}{"admin": admin},
+		"interfaces":   l.memberURIs(c),
+		"lacp":         lacp,
+		"other_config": other_config,
+	}
+}
+
+// memberURIs returns the references to the member ports.
+func (l *Lag) memberURIs(c *Client) []string {
+	members := []string{}
+	for _, member := range l.Members {
+		members = append(members, "/rest/"+c.Version+"/system/interfaces/"+url.PathEscape(member))
+	}
+	return members
+}
+
+// updateMap returns the attributes of the LAG to send on Update, all of them
+// if the Lag holds the state of the switch and only the set fields
+// otherwise. other_config is replaced as a whole by PATCH, so the settings
+// are merged into current, the other_config on the switch.
+func (l *Lag) updateMap(c *Client, current map[string]interface{}) map[string]interface{} {
+	all := l.materialized
+	patchMap := map[string]interface{}{}
+
+	if l.Description != "" || all {
+		patchMap["description"] = l.Description
+	}
+	if l.AdminState != "" {
+		patchMap["admin"] = l.AdminState
+		patchMap["user_config"] = map[string]interface{}{"admin": l.AdminState}
+	}
+	if l.Members != nil || all {
+		patchMap["interfaces"] = l.memberURIs(c)
+	}
+	if l.LacpMode == "static" {
+		patchMap["lacp"] = "off"
+	} else if l.LacpMode != "" {
+		patchMap["lacp"] = l.LacpMode
+	}
+
+	// Unset flags and minimum links are left alone unless the Lag holds the
+	// state of the switch
+	other_config := map[string]interface{}{}
+	for key, value := range current {
+		if all && ((key == lag_min_links && l.MinLinks == 0) || (key == lag_mclag && !l.MultiChassis)) {
+			continue
+		}
+		other_config[key] = value
+	}
+	if l.LacpRate != "" {
+		other_config[lag_lacp_rate] = l.LacpRate
+	}
+	if l.Hash != "" {
+		other_config[lag_hash] = l.Hash
+	}
+	if l.Fallback || all {
+		other_config[lag_fallback] = strconv.FormatBool(l.Fallback)
+	}
+	if l.MinLinks > 0 {
+		other_config[lag_min_links] = strconv.Itoa(l.MinLinks)
+	}
+	if l.MultiChassis {
+		other_config[lag_mclag] = "true"
+	}
+	patchMap["other_config"] = other_config
+
+	return patchMap
+}
+
+// Create performs POST to create LAG configuration on the given Client object.
+func (l *Lag) Create(ctx context.Context, c *Client) error {
+	base_uri := "system/interfaces"
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri
+	l.uri = "/rest/" + c.Version + "/" + base_uri + "/" + l.Name
+
+	err := l.checkValues("Lag.Create")
+	if err != nil {
+		return err
+	}
+
+	postMap := l.lagMap(c)
+	postMap["name"] = l.Name
+	postMap["type"] = "lag"
+
+	postBody, _ := json.Marshal(postMap)
+
+	json_body := bytes.NewBuffer(postBody)
+
+	res, err := post(ctx, c, url, json_body)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusCreated {
+		return newRequestError("Lag.Create", res)
+	}
+
+	l.materialized = true
+
+	return nil
+}
+
+// Update performs PATCH to update LAG configuration on the given Client object.
+// As with Vlan.Update only the set fields are sent, so a Lag built with some
+// fields leaves the others alone: Fallback, MultiChassis and MinLinks are
+// only sent when set and Members only when not nil, an empty list removing
+// all members. Members not listed are removed from the LAG. To clear the
+// flags call Get, change the fields and Update, a Lag retrieved by Get or
+// created by Create sends them as they are.
+func (l *Lag) Update(ctx context.Context, c *Client) error {
+	base_uri := "system/interfaces"
+
+	err := l.checkValues("Lag.Update")
+	if err != nil {
+		return err
+	}
+
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri + "/" + l.Name
+
+	res, body, err := get(ctx, c, url+"?attributes=other_config")
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return newRequestError("Lag.Update", res)
+	}
+	current, _ := body["other_config"].(map[string]interface{})
+
+	patchBody, _ := json.Marshal(l.updateMap(c, current))
+
+	json_body := bytes.NewBuffer(patchBody)
+
+	res, err = patch(ctx, c, url, json_body)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusNoContent {
+		return newRequestError("Lag.Update", res)
+	}
+
+	return nil
+}
+
+// Delete performs DELETE to remove LAG configuration from the given Client
+// object, releasing its members.
+func (l *Lag) Delete(ctx context.Context, c *Client) error {
+	base_uri := "system/interfaces"
+	if !checkLagName(l.Name) {
+		return newValidationError("Lag.Delete", "Name", "invalid LAG name, expected lagN received: "+l.Name)
+	}
+
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri + "/" + l.Name
+
+	res, err := delete(ctx, c, url)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusNoContent && res.StatusCode != http.StatusOK {
+		return newRequestError("Lag.Delete", res)
+	}
+
+	l.materialized = false
+
+	return nil
+}
+
+// Get performs GET to retrieve LAG configuration from the given Client object.
+func (l *Lag) Get(ctx context.Context, c *Client) error {
+	base_uri := "system/interfaces"
+	if !checkLagName(l.Name) {
+		return newValidationError("Lag.Get", "Name", "invalid LAG name, expected lagN received: "+l.Name)
+	}
+	l.uri = "/rest/" + c.Version + "/" + base_uri + "/" + l.Name
+
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri + "/" + l.Name + "?selector=writable"
+
+	res, body, err := get(ctx, c, url)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		l.materialized = false
+		return newRequestError("Lag.Get", res)
+	}
+
+	payload := lagPayload{}
+	err = json.NewDecoder(res.Body).Decode(&payload)
+	if err != nil {
+		return fmt.Errorf("Lag.Get: decoding %s: %w", l.Name, err)
+	}
+	payload.decode(l)
+
+	if l.LagDetails == nil {
+		l.LagDetails = map[string]interface{}{}
+	}
+	for key, value := range body {
+		l.LagDetails[key] = value
+	}
+
+	l.materialized = true
+
+	return nil
+}
+
+// lagPayload is the LAG as returned by the switch.
+type lagPayload struct {
+	Description *string           `json:"description"`
+	Admin       *string           `json:"admin"`
+	Interfaces  json.RawMessage   `json:"interfaces"`
+	Lacp        *string           `json:"lacp"`
+	OtherConfig map[string]string `json:"other_config"`
+}
+
+// decode sets the fields of l from the payload.
+func (p *lagPayload) decode(l *Lag) {
+	if p.Description != nil {
+		l.Description = *p.Description
+	}
+	if p.Admin != nil {
+		l.AdminState = *p.Admin
+	}
+	l.Members = referenceKeys(p.Interfaces)
+
+	l.LacpMode = "active"
+	if p.Lacp != nil {
+		l.LacpMode = *p.Lacp
+		if l.LacpMode == "off" {
+			l.LacpMode = "static"
+		}
+	}
+
+	l.LacpRate = p.OtherConfig[lag_lacp_rate]
+	l.Fallback = p.OtherConfig[lag_fallback] == "true"
+	l.Hash = p.OtherConfig[lag_hash]
+	l.MinLinks, _ = strconv.Atoi(p.OtherConfig[lag_min_links])
+	l.MultiChassis = p.OtherConfig[lag_mclag] == "true"
+}
+
+// getLags returns all LAGs of the given Client object in a single request,
+// sorted by name. op names the calling operation in returned errors.
+func getLags(ctx context.Context, c *Client, op string) ([]Lag, error) {
+	base_uri := "system/interfaces"
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri +
+		"?depth=2&filter=type:lag&attributes=name,description,admin,interfaces,lacp,other_config"
+
+	res, _, err := get(ctx, c, url)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, newRequestError(op, res)
+	}
+
+	payloads := map[string]lagPayload{}
+	err = json.NewDecoder(res.Body).Decode(&payloads)
+	if err != nil {
+		return nil, fmt.Errorf("%s: decoding LAGs: %w", op, err)
+	}
+
+	lags := []Lag{}
+	for name, payload := range payloads {
+		l := Lag{
+			Name:         name,
+			materialized: true,
+			uri:          "/rest/" + c.Version + "/" + base_uri + "/" + name,
+		}
+		payload.decode(&l)
+		lags = append(lags, l)
+	}
+
+	sort.Slice(lags, func(a, b int) bool {
+		return lags[a].Name < lags[b].Name
+	})
+
+	return lags, nil
+}
+
+// GetStatus returns True if LAG exists on Client object or False if not.
+func (l *Lag) GetStatus() bool {
+	return l.materialized
+}
+
+// GetURI returns URI of LAG.
+func (l *Lag) GetURI() string {
+	return l.uri
+}
+
+// Interface returns the LAG as Interface, to be used as the Interface of an
+// L2Interface or L3Interface. Its AdminState is that of the Lag, which
+// L2Interface and L3Interface require, so an unset one is reported by them
+// rather than shutting the LAG down.
+func (l *Lag) Interface() Interface {
+	return Interface{
+		Name:        l.Name,
+		Description: l.Description,
+		AdminState:  l.AdminState,
+	}
+}
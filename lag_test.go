@@ -0,0 +1,180 @@
+package aoscxgo_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aruba/aoscxgo"
+)
+
+func TestLagCRUD(t *testing.T) {
+	srv, c := connect(t)
+	ctx := context.Background()
+	srv.AddInterface("1/1/1")
+	srv.AddInterface("1/1/2")
+
+	lag := aoscxgo.Lag{
+		Name:       "lag1",
+		AdminState: "up",
+		Members:    []string{"1/1/1", "1/1/2"},
+		LacpRate:   "fast",
+		Fallback:   true,
+		MinLinks:   1,
+	}
+	err := lag.Create(ctx, c)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	obj, _ := srv.Object("system/interfaces", "lag1")
+	if obj["type"] != "lag" || obj["lacp"] != "active" {
+		t.Errorf("after Create the switch holds %v", obj)
+	}
+
+	got := aoscxgo.Lag{Name: "lag1"}
+	err = got.Get(ctx, c)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if len(got.Members) != 2 || got.LacpMode != "active" || got.LacpRate != "fast" ||
+		!got.Fallback || got.Hash != "l3-src-dst" || got.MinLinks != 1 || got.AdminState != "up" {
+		t.Errorf("Get = %+v", got)
+	}
+
+	lag.Members = []string{"1/1/1"}
+	lag.LacpMode = "static"
+	err = lag.Update(ctx, c)
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	got = aoscxgo.Lag{Name: "lag1"}
+	err = got.Get(ctx, c)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if len(got.Members) != 1 || got.Members[0] != "1/1/1" || got.LacpMode != "static" {
+		t.Errorf("Get after Update = %+v", got)
+	}
+
+	err = lag.Delete(ctx, c)
+	if err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, ok := srv.Object("system/interfaces", "lag1"); ok {
+		t.Error("lag1 exists after Delete")
+	}
+}
+
+func TestLagUpdatePartial(t *testing.T) {
+	srv, c := connect(t)
+	ctx := context.Background()
+	srv.AddInterface("1/1/1")
+	srv.AddInterface("1/1/2")
+
+	lag := aoscxgo.Lag{
+		Name:       "lag1",
+		AdminState: "up",
+		Members:    []string{"1/1/1", "1/1/2"},
+		LacpMode:   "passive",
+		LacpRate:   "fast",
+		Fallback:   true,
+		Hash:       "l4-src-dst",
+		MinLinks:   2,
+	}
+	err := lag.Create(ctx, c)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	obj, _ := srv.Object("system/interfaces", "lag1")
+	other_config, _ := obj["other_config"].(map[string]interface{})
+	other_config["lacp-fallback-timeout"] = "60"
+	srv.SetObject("system/interfaces", "lag1", obj)
+
+	// A Lag built with some fields leaves the others alone
+	partial := aoscxgo.Lag{Name: "lag1", Description: "uplink"}
+	err = partial.Update(ctx, c)
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	got := aoscxgo.Lag{Name: "lag1"}
+	err = got.Get(ctx, c)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.Description != "uplink" || got.AdminState != "up" || len(got.Members) != 2 || got.LacpMode != "passive" ||
+		got.LacpRate != "fast" || !got.Fallback || got.Hash != "l4-src-dst" || got.MinLinks != 2 {
+		t.Errorf("Get after partial Update = %+v", got)
+	}
+	obj, _ = srv.Object("system/interfaces", "lag1")
+	if other_config, _ := obj["other_config"].(map[string]interface{}); other_config["lacp-fallback-timeout"] != "60" {
+		t.Errorf("other_config = %v, want unknown settings kept", obj["other_config"])
+	}
+
+	// A Lag retrieved by Get clears what was unset on it
+	got.Fallback = false
+	got.MinLinks = 0
+	got.Members = []string{"1/1/1"}
+	err = got.Update(ctx, c)
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	cleared := aoscxgo.Lag{Name: "lag1"}
+	err = cleared.Get(ctx, c)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if cleared.Fallback || cleared.MinLinks != 0 || len(cleared.Members) != 1 || cleared.LacpRate != "fast" {
+		t.Errorf("Get after Update of retrieved Lag = %+v", cleared)
+	}
+}
+
+func TestLagValidation(t *testing.T) {
+	_, c := connect(t)
+	ctx := context.Background()
+
+	for _, lag := range []aoscxgo.Lag{
+		{Name: "port1"},
+		{Name: "lag1", LacpMode: "on"},
+		{Name: "lag1", Hash: "round-robin"},
+		{Name: "lag1", Members: []string{"lag2"}},
+		{Name: "lag1", MinLinks: -1},
+	} {
+		err := lag.Create(ctx, c)
+		if !errors.Is(err, aoscxgo.ErrValidation) {
+			t.Errorf("Create(%+v) = %v, want ErrValidation", lag, err)
+		}
+	}
+}
+
+func TestLagL2Interface(t *testing.T) {
+	srv, c := connect(t)
+	ctx := context.Background()
+	srv.AddInterface("1/1/1")
+
+	lag := aoscxgo.Lag{Name: "lag1", AdminState: "up", Members: []string{"1/1/1"}}
+	err := lag.Create(ctx, c)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	l2 := aoscxgo.L2Interface{Interface: lag.Interface(), VlanMode: "access", VlanTag: 1}
+	err = l2.Create(ctx, c)
+	if err != nil {
+		t.Fatalf("L2Interface.Create: %v", err)
+	}
+	obj, _ := srv.Object("system/interfaces", "lag1")
+	if obj["vlan_mode"] != "access" {
+		t.Errorf("lag1 = %v", obj)
+	}
+
+	// A Lag without AdminState does not shut the LAG down
+	l2 = aoscxgo.L2Interface{Interface: (&aoscxgo.Lag{Name: "lag1"}).Interface(), VlanMode: "access", VlanTag: 1}
+	err = l2.Create(ctx, c)
+	if !errors.Is(err, aoscxgo.ErrValidation) {
+		t.Errorf("L2Interface.Create without AdminState = %v, want ErrValidation", err)
+	}
+	obj, _ = srv.Object("system/interfaces", "lag1")
+	if obj["admin"] != "up" {
+		t.Errorf("lag1 admin = %v, want up", obj["admin"])
+	}
+}
@@ -0,0 +1,190 @@
+package aoscxgo
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// RequestInfo describes a request about to be sent to the switch. Passwords
+// and secrets in Body are redacted.
+type RequestInfo struct {
+	Method string
+	URL    string
+	Body   string
+}
+
+// ResponseInfo describes the outcome of a request sent to the switch.
+// StatusCode is zero and Err is set if no response was received. Passwords
+// and secrets in Body are redacted.
+type ResponseInfo struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Status     string
+	Latency    time.Duration
+	Body       string
+	Err        error
+}
+
+// discard_logger is used when the Client has no Logger.
+var discard_logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// logger returns the Logger of the Client or a logger discarding everything.
+func (c *Client) logger() *slog.Logger {
+	if c.Logger == nil {
+		return discard_logger
+	}
+	return c.Logger
+}
+
+// roundTrip sends req with the HTTPClient of the Client, logging it and
+// calling the request and response hooks. The response body is read in full
+// and closed so the connection can be reused, callers get a buffered copy
+// that does not need to be closed.
+func roundTrip(c *Client, req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	trace := c.RequestHook != nil || c.ResponseHook != nil || c.logger().Enabled(ctx, slog.LevelDebug)
+
+	req_body := ""
+	if trace && req.GetBody != nil {
+		body, err := req.GetBody()
+		if err == nil {
+			raw_body, _ := io.ReadAll(body)
+			req_body = RedactBody(raw_body, req.Header.Get("Content-Type"))
+		}
+	}
+
+	if c.RequestHook != nil {
+		c.RequestHook(ctx, &RequestInfo{
+			Method: req.Method,
+			URL:    req.URL.String(),
+			Body:   req_body,
+		})
+	}
+
+	release, err := c.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	start := time.Now()
+	res, err := c.HTTPClient.Do(req)
+
+	var raw_body []byte
+	if err == nil {
+		raw_body, err = io.ReadAll(res.Body)
+		res.Body.Close()
+		res.Body = io.NopCloser(bytes.NewReader(raw_body))
+		if err != nil {
+			res = nil
+		}
+	}
+	latency := time.Since(start)
+
+	if !trace {
+		return res, err
+	}
+
+	info := &ResponseInfo{
+		Method:  req.Method,
+		URL:     req.URL.String(),
+		Latency: latency,
+		Err:     err,
+	}
+	if res != nil {
+		info.StatusCode = res.StatusCode
+		info.Status = res.Status
+		if c.ResponseHook != nil {
+			info.Body = RedactBody(raw_body, res.Header.Get("Content-Type"))
+		}
+	}
+
+	if err != nil {
+		c.logger().DebugContext(ctx, "aoscxgo request failed",
+			"method", info.Method, "url", info.URL, "latency", latency, "body", req_body, "error", err)
+	} else {
+		c.logger().DebugContext(ctx, "aoscxgo request",
+			"method", info.Method, "url", info.URL, "status", info.StatusCode, "latency", latency, "body", req_body)
+	}
+
+	if c.ResponseHook != nil {
+		c.ResponseHook(ctx, info)
+	}
+
+	return res, err
+}
+
+// Redacted replaces passwords and other secrets in traced bodies.
+const Redacted = "REDACTED"
+
+// secret_cli matches secrets in CLI configuration such as
+// "password plaintext foo" or "key 1 md5 foo".
+var secret_cli = regexp.MustCompile(`(?i)\b(password|secret|key)((?:\s+(?:plaintext|ciphertext|md5|sha1|sha256|\d+))*)\s+\S+`)
+
+// RedactBody returns body as a string with passwords and other secrets
+// replaced by Redacted, based on its content type: the values of secret
+// form fields and JSON attributes as reported by IsSecretKey, and secrets in
+// CLI configuration otherwise. It is used for the bodies passed to the
+// request and response hooks and recorded by aoscxtest.
+func RedactBody(body []byte, content_type string) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	if strings.HasPrefix(content_type, "application/x-www-form-urlencoded") {
+		form, err := url.ParseQuery(string(body))
+		if err == nil {
+			for key := range form {
+				if IsSecretKey(key) {
+					form.Set(key, Redacted)
+				}
+			}
+			return form.Encode()
+		}
+	}
+
+	var value interface{}
+	if json.Unmarshal(body, &value) == nil {
+		redacted_body, err := json.Marshal(redactValue(value))
+		if err == nil {
+			return string(redacted_body)
+		}
+	}
+
+	return secret_cli.ReplaceAllString(string(body), "$1$2 "+Redacted)
+}
+
+// redactValue replaces the values of secret keys in decoded JSON.
+func redactValue(value interface{}) interface{} {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		for key, item := range typed {
+			if IsSecretKey(key) {
+				typed[key] = Redacted
+			} else {
+				typed[key] = redactValue(item)
+			}
+		}
+	case []interface{}:
+		for index, item := range typed {
+			typed[index] = redactValue(item)
+		}
+	}
+	return value
+}
+
+// IsSecretKey returns true if the form field or attribute named key holds a
+// password or other secret.
+func IsSecretKey(key string) bool {
+	key = strings.ToLower(key)
+	return strings.Contains(key, "password") || strings.Contains(key, "secret") ||
+		strings.HasSuffix(key, "_key") || strings.HasSuffix(key, "_keys")
+}
@@ -0,0 +1,119 @@
+package aoscxgo_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/aruba/aoscxgo"
+	"github.com/aruba/aoscxgo/aoscxtest"
+)
+
+func TestLogger(t *testing.T) {
+	srv := aoscxtest.NewServer()
+	defer srv.Close()
+	ctx := context.Background()
+
+	var output bytes.Buffer
+	client := srv.Client()
+	client.Logger = slog.New(slog.NewTextHandler(&output, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	c, err := aoscxgo.Connect(ctx, client)
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer c.Logout(ctx)
+
+	vlan := aoscxgo.Vlan{VlanId: 10, Name: "users"}
+	err = vlan.Create(ctx, c)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	logged := output.String()
+	for _, want := range []string{"aoscxgo login successful", "aoscxgo request", "method=POST", "status=201", "/system/vlans"} {
+		if !strings.Contains(logged, want) {
+			t.Errorf("log is missing %q:\n%s", want, logged)
+		}
+	}
+	if strings.Contains(logged, "password="+aoscxtest.DefaultPassword) {
+		t.Errorf("log holds the password:\n%s", logged)
+	}
+}
+
+func TestHooks(t *testing.T) {
+	_, c := connect(t)
+	ctx := context.Background()
+
+	var requests []aoscxgo.RequestInfo
+	var responses []aoscxgo.ResponseInfo
+	c.RequestHook = func(ctx context.Context, info *aoscxgo.RequestInfo) {
+		requests = append(requests, *info)
+	}
+	c.ResponseHook = func(ctx context.Context, info *aoscxgo.ResponseInfo) {
+		responses = append(responses, *info)
+	}
+
+	vlan := aoscxgo.Vlan{VlanId: 10, Name: "users"}
+	err := vlan.Create(ctx, c)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	err = vlan.Get(ctx, c)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+
+	if len(requests) != 2 || len(responses) != 2 {
+		t.Fatalf("hooks called for %d requests and %d responses, want 2", len(requests), len(responses))
+	}
+	if requests[0].Method != "POST" || !strings.HasSuffix(requests[0].URL, "/system/vlans") || !strings.Contains(requests[0].Body, `"name":"users"`) {
+		t.Errorf("request = %+v, want the POST of VLAN 10", requests[0])
+	}
+	if responses[0].StatusCode != http.StatusCreated || responses[0].Latency <= 0 || responses[0].Err != nil {
+		t.Errorf("response = %+v, want 201 with latency", responses[0])
+	}
+	if responses[1].Method != "GET" || responses[1].StatusCode != http.StatusOK || !strings.Contains(responses[1].Body, "users") {
+		t.Errorf("response = %+v, want the VLAN", responses[1])
+	}
+}
+
+func TestRedactBody(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         string
+		content_type string
+		want         string
+	}{
+		{"form", "username=admin&password=p%26ss", "application/x-www-form-urlencoded", "password=" + aoscxgo.Redacted + "&username=admin"},
+		{"json", `{"name":"spine","password":"secret","nested":[{"auth_key":"k"}]}`, "application/json",
+			`{"name":"spine","nested":[{"auth_key":"` + aoscxgo.Redacted + `"}],"password":"` + aoscxgo.Redacted + `"}`},
+		{"cli", "user admin group administrators password plaintext foo\nneighbor 10.0.0.1 password ciphertext AQBap\n", "text/plain",
+			"user admin group administrators password plaintext " + aoscxgo.Redacted + "\nneighbor 10.0.0.1 password ciphertext " + aoscxgo.Redacted + "\n"},
+		{"empty", "", "application/json", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := aoscxgo.RedactBody([]byte(test.body), test.content_type)
+			if got != test.want {
+				t.Errorf("RedactBody = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestIsSecretKey(t *testing.T) {
+	for _, key := range []string{"password", "Password", "radius_secret", "auth_key", "ssh_keys"} {
+		if !aoscxgo.IsSecretKey(key) {
+			t.Errorf("IsSecretKey(%q) = false", key)
+		}
+	}
+	for _, key := range []string{"name", "keepalive_interval", "key_chain_name"} {
+		if aoscxgo.IsSecretKey(key) {
+			t.Errorf("IsSecretKey(%q) = true", key)
+		}
+	}
+}
@@ -0,0 +1,518 @@
+package aoscxgo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// AddressGroup is an IPv4 or IPv6 address object group, matched by the
+// SourceGroup and DestinationGroup of AclEntry.
+type AddressGroup struct {
+
+	// Connection properties.
+	Name string `json:"name"`
+	// Type is "ipv4" or "ipv6".
+	Type string `json:"type"`
+	// Addresses are prefixes or host addresses of the type of the group,
+	// e.g. "10.0.0.0/24" or "10.0.1.1".
+	Addresses []string `json:"addresses"`
+
+	AddressGroupDetails map[string]interface{} `json:"details"`
+	materialized        bool
+	uri                 string
+}
+
+// PortGroup is a TCP, UDP or SCTP port object group, matched by the
+// SourcePortGroup and DestinationPortGroup of AclEntry.
+type PortGroup struct {
+
+	// Connection properties.
+	Name string `json:"name"`
+	// Ports are ports or port ranges, e.g. "443" or "1024-65535".
+	Ports []string `json:"ports"`
+
+	PortGroupDetails map[string]interface{} `json:"details"`
+	materialized     bool
+	uri              string
+}
+
+// objectGroupKey returns the escaped key of an object group, "name,type".
+func objectGroupKey(name string, object_type string) string {
+	return url.PathEscape(name) + "," + object_type
+}
+
+// objectGroupName returns the name of the object group at the given URI.
+func objectGroupName(uri string) string {
+	key := uri[strings.LastIndex(uri, "/")+1:]
+	if unescaped, err := url.PathUnescape(key); err == nil {
+		key = unescaped
+	}
+	name, _, _ := strings.Cut(key, ",")
+	return name
+}
+
+// checkObjectGroupName validates the name of an object group.
+func checkObjectGroupName(op string, name string) error {
+	if name == "" || strings.ContainsAny(name, ",/") {
+		return newValidationError(op, "Name", "invalid object group name: "+name)
+	}
+	return nil
+}
+
+// checkValues validates the group and normalizes its addresses as returned
+// by Get. op names the calling operation in the returned error.
+func (g *AddressGroup) checkValues(op string) error {
+	err := checkObjectGroupName(op, g.Name)
+	if err != nil {
+		return err
+	}
+	if g.Type != "ipv4" && g.Type != "ipv6" {
+		return newValidationError(op, "Type", "valid options are 'ipv4' or 'ipv6' received: "+g.Type)
+	}
+
+	for index, address := range g.Addresses {
+		prefix, ok := aclAddress(address, g.Type)
+		if !ok {
+			return newValidationError(op, "Addresses", "invalid "+g.Type+" address "+address)
+		}
+		g.Addresses[index] = prefix
+	}
+	return nil
+}
+
+// entriesMap returns the addresses as sent to the switch, keyed by sequence.
+func (g *AddressGroup) entriesMap() map[string]interface{} {
+	entries := map[string]interface{}{}
+	for index, address := range g.Addresses {
+		entries[strconv.Itoa((index+1)*10)] = map[string]interface{}{
+			"address": aclAddressMask(address),
+		}
+	}
+	return entries
+}
+
+// Create performs POST to create the address group on the given Client
+// object.
+func (g *AddressGroup) Create(ctx context.Context, c *Client) error {
+	err := g.checkValues("AddressGroup.Create")
+	if err != nil {
+		return err
+	}
+
+	g.uri, err = createObjectGroup(ctx, c, "AddressGroup.Create", g.Name, g.Type, g.entriesMap())
+	if err != nil {
+		return err
+	}
+
+	g.materialized = true
+
+	return nil
+}
+
+// Update performs PUT to replace the addresses of the group on the given
+// Client object.
+func (g *AddressGroup) Update(ctx context.Context, c *Client) error {
+	err := g.checkValues("AddressGroup.Update")
+	if err != nil {
+		return err
+	}
+
+	return updateObjectGroup(ctx, c, "AddressGroup.Update", objectGroupKey(g.Name, g.Type), g.entriesMap())
+}
+
+// Delete performs DELETE to remove the address group from the given Client
+// object. The switch refuses to delete a group used by an ACL.
+func (g *AddressGroup) Delete(ctx context.Context, c *Client) error {
+	err := deleteObjectGroup(ctx, c, "AddressGroup.Delete", objectGroupKey(g.Name, g.Type))
+	if err != nil {
+		return err
+	}
+
+	g.materialized = false
+
+	return nil
+}
+
+// Get performs GET to retrieve the address group from the given Client
+// object.
+func (g *AddressGroup) Get(ctx context.Context, c *Client) error {
+	key := objectGroupKey(g.Name, g.Type)
+	g.uri = "/rest/" + c.Version + "/system/acl_object_groups/" + key
+
+	payload, body, err := getObjectGroup(ctx, c, "AddressGroup.Get", key)
+	if err != nil {
+		g.materialized = false
+		return err
+	}
+	payload.decodeAddresses(g)
+
+	if g.AddressGroupDetails == nil {
+		g.AddressGroupDetails = map[string]interface{}{}
+	}
+	for key, value := range body {
+		g.AddressGroupDetails[key] = value
+	}
+
+	g.materialized = true
+
+	return nil
+}
+
+// GetStatus returns True if the address group exists on Client object or
+// False if not.
+func (g *AddressGroup) GetStatus() bool {
+	return g.materialized
+}
+
+// GetURI returns URI of the address group.
+func (g *AddressGroup) GetURI() string {
+	return g.uri
+}
+
+// checkValues validates the group and normalizes its ports as returned by
+// Get. op names the calling operation in the returned error.
+func (g *PortGroup) checkValues(op string) error {
+	err := checkObjectGroupName(op, g.Name)
+	if err != nil {
+		return err
+	}
+
+	for index, ports := range g.Ports {
+		min, max, ok := parsePortRange(ports)
+		if !ok {
+			return newValidationError(op, "Ports", "invalid port range "+ports)
+		}
+		g.Ports[index] = formatPortRange(min, max)
+	}
+	return nil
+}
+
+// entriesMap returns the ports as sent to the switch, keyed by sequence.
+func (g *PortGroup) entriesMap() map[string]interface{} {
+	entries := map[string]interface{}{}
+	for index, ports := range g.Ports {
+		min, max, _ := parsePortRange(ports)
+		entries[strconv.Itoa((index+1)*10)] = map[string]interface{}{
+			"l4_port_min": min,
+			"l4_port_max": max,
+		}
+	}
+	return entries
+}
+
+// Create performs POST to create the port group on the given Client object.
+func (g *PortGroup) Create(ctx context.Context, c *Client) error {
+	err := g.checkValues("PortGroup.Create")
+	if err != nil {
+		return err
+	}
+
+	g.uri, err = createObjectGroup(ctx, c, "PortGroup.Create", g.Name, "port", g.entriesMap())
+	if err != nil {
+		return err
+	}
+
+	g.materialized = true
+
+	return nil
+}
+
+// Update performs PUT to replace the ports of the group on the given Client
+// object.
+func (g *PortGroup) Update(ctx context.Context, c *Client) error {
+	err := g.checkValues("PortGroup.Update")
+	if err != nil {
+		return err
+	}
+
+	return updateObjectGroup(ctx, c, "PortGroup.Update", objectGroupKey(g.Name, "port"), g.entriesMap())
+}
+
+// Delete performs DELETE to remove the port group from the given Client
+// object. The switch refuses to delete a group used by an ACL.
+func (g *PortGroup) Delete(ctx context.Context, c *Client) error {
+	err := deleteObjectGroup(ctx, c, "PortGroup.Delete", objectGroupKey(g.Name, "port"))
+	if err != nil {
+		return err
+	}
+
+	g.materialized = false
+
+	return nil
+}
+
+// Get performs GET to retrieve the port group from the given Client object.
+func (g *PortGroup) Get(ctx context.Context, c *Client) error {
+	key := objectGroupKey(g.Name, "port")
+	g.uri = "/rest/" + c.Version + "/system/acl_object_groups/" + key
+
+	payload, body, err := getObjectGroup(ctx, c, "PortGroup.Get", key)
+	if err != nil {
+		g.materialized = false
+		return err
+	}
+	payload.decodePorts(g)
+
+	if g.PortGroupDetails == nil {
+		g.PortGroupDetails = map[string]interface{}{}
+	}
+	for key, value := range body {
+		g.PortGroupDetails[key] = value
+	}
+
+	g.materialized = true
+
+	return nil
+}
+
+// GetStatus returns True if the port group exists on Client object or False
+// if not.
+func (g *PortGroup) GetStatus() bool {
+	return g.materialized
+}
+
+// GetURI returns URI of the port group.
+func (g *PortGroup) GetURI() string {
+	return g.uri
+}
+
+// createObjectGroup performs POST to create an object group with the given
+// entries and returns its URI.
+func createObjectGroup(ctx context.Context, c *Client, op string, name string, object_type string, entries map[string]interface{}) (string, error) {
+	base_uri := "system/acl_object_groups"
+
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri
+
+	postMap := map[string]interface{}{
+		"name":        name,
+		"object_type": object_type,
+		"cfg_entries": entries,
+		"cfg_version": nextCfgVersion(),
+	}
+
+	postBody, _ := json.Marshal(postMap)
+
+	json_body := bytes.NewBuffer(postBody)
+
+	res, err := post(ctx, c, url, json_body)
+	if err != nil {
+		return "", err
+	}
+
+	if res.StatusCode != http.StatusCreated {
+		return "", newRequestError(op, res)
+	}
+
+	return "/rest/" + c.Version + "/" + base_uri + "/" + objectGroupKey(name, object_type), nil
+}
+
+// updateObjectGroup performs PUT to replace the entries of the object group
+// with the given key.
+func updateObjectGroup(ctx context.Context, c *Client, op string, key string, entries map[string]interface{}) error {
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/system/acl_object_groups/" + key
+
+	putMap := map[string]interface{}{
+		"cfg_entries": entries,
+		"cfg_version": nextCfgVersion(),
+	}
+
+	putBody, _ := json.Marshal(putMap)
+
+	json_body := bytes.NewBuffer(putBody)
+
+	res, err := put(ctx, c, url, json_body)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return newRequestError(op, res)
+	}
+
+	return nil
+}
+
+// deleteObjectGroup performs DELETE to remove the object group with the
+// given key.
+func deleteObjectGroup(ctx context.Context, c *Client, op string, key string) error {
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/system/acl_object_groups/" + key
+
+	res, err := delete(ctx, c, url)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusNoContent && res.StatusCode != http.StatusOK {
+		return newRequestError(op, res)
+	}
+
+	return nil
+}
+
+// getObjectGroup performs GET to retrieve the object group with the given
+// key.
+func getObjectGroup(ctx context.Context, c *Client, op string, key string) (objectGroupPayload, map[string]interface{}, error) {
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/system/acl_object_groups/" + key + "?depth=2"
+
+	payload := objectGroupPayload{}
+
+	res, body, err := get(ctx, c, url)
+	if err != nil {
+		return payload, nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return payload, nil, newRequestError(op, res)
+	}
+
+	err = json.NewDecoder(res.Body).Decode(&payload)
+	if err != nil {
+		return payload, nil, fmt.Errorf("%s: decoding %s: %w", op, key, err)
+	}
+
+	return payload, body, nil
+}
+
+// objectGroupEntryPayload is an entry of an object group as returned by the
+// switch.
+type objectGroupEntryPayload struct {
+	Address   string `json:"address"`
+	L4PortMin int    `json:"l4_port_min"`
+	L4PortMax int    `json:"l4_port_max"`
+}
+
+// objectGroupPayload is an object group as returned by the switch.
+type objectGroupPayload struct {
+	Name       string                             `json:"name"`
+	ObjectType string                             `json:"object_type"`
+	CfgEntries map[string]objectGroupEntryPayload `json:"cfg_entries"`
+}
+
+// sortedEntries returns the entries of the group in order of sequence.
+func (p *objectGroupPayload) sortedEntries() []objectGroupEntryPayload {
+	sequences := []int{}
+	for sequence_str := range p.CfgEntries {
+		sequence, _ := strconv.Atoi(sequence_str)
+		sequences = append(sequences, sequence)
+	}
+	sort.Ints(sequences)
+
+	entries := []objectGroupEntryPayload{}
+	for _, sequence := range sequences {
+		entries = append(entries, p.CfgEntries[strconv.Itoa(sequence)])
+	}
+	return entries
+}
+
+// decodeAddresses sets the addresses of g from the payload.
+func (p *objectGroupPayload) decodeAddresses(g *AddressGroup) {
+	g.Addresses = []string{}
+	for _, entry := range p.sortedEntries() {
+		g.Addresses = append(g.Addresses, aclPrefix(entry.Address))
+	}
+}
+
+// decodePorts sets the ports of g from the payload.
+func (p *objectGroupPayload) decodePorts(g *PortGroup) {
+	g.Ports = []string{}
+	for _, entry := range p.sortedEntries() {
+		g.Ports = append(g.Ports, formatPortRange(entry.L4PortMin, entry.L4PortMax))
+	}
+}
+
+// listObjectGroups performs GET to retrieve all object groups in a single
+// request.
+func listObjectGroups(ctx context.Context, c *Client, op string) ([]objectGroupPayload, error) {
+	url_str := "https://" + c.Hostname + "/rest/" + c.Version + "/system/acl_object_groups?depth=2&attributes=name,object_type,cfg_entries"
+
+	res, _, err := get(ctx, c, url_str)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, newRequestError(op, res)
+	}
+
+	payloads := map[string]objectGroupPayload{}
+	err = json.NewDecoder(res.Body).Decode(&payloads)
+	if err != nil {
+		return nil, fmt.Errorf("%s: decoding object groups: %w", op, err)
+	}
+
+	groups := []objectGroupPayload{}
+	for key, payload := range payloads {
+		if unescaped, err := url.PathUnescape(key); err == nil {
+			key = unescaped
+		}
+		payload.Name, payload.ObjectType, _ = strings.Cut(key, ",")
+		groups = append(groups, payload)
+	}
+
+	sort.Slice(groups, func(x, y int) bool {
+		if groups[x].Name != groups[y].Name {
+			return groups[x].Name < groups[y].Name
+		}
+		return groups[x].ObjectType < groups[y].ObjectType
+	})
+
+	return groups, nil
+}
+
+// ListAddressGroups performs GET to retrieve all IPv4 and IPv6 address
+// groups from the given Client object, sorted by name and type.
+func ListAddressGroups(ctx context.Context, c *Client) ([]AddressGroup, error) {
+	payloads, err := listObjectGroups(ctx, c, "ListAddressGroups")
+	if err != nil {
+		return nil, err
+	}
+
+	groups := []AddressGroup{}
+	for _, payload := range payloads {
+		if payload.ObjectType != "ipv4" && payload.ObjectType != "ipv6" {
+			continue
+		}
+		g := AddressGroup{
+			Name:         payload.Name,
+			Type:         payload.ObjectType,
+			materialized: true,
+		}
+		payload.decodeAddresses(&g)
+		g.uri = "/rest/" + c.Version + "/system/acl_object_groups/" + objectGroupKey(g.Name, g.Type)
+		groups = append(groups, g)
+	}
+
+	return groups, nil
+}
+
+// ListPortGroups performs GET to retrieve all port groups from the given
+// Client object, sorted by name.
+func ListPortGroups(ctx context.Context, c *Client) ([]PortGroup, error) {
+	payloads, err := listObjectGroups(ctx, c, "ListPortGroups")
+	if err != nil {
+		return nil, err
+	}
+
+	groups := []PortGroup{}
+	for _, payload := range payloads {
+		if payload.ObjectType != "port" {
+			continue
+		}
+		g := PortGroup{
+			Name:         payload.Name,
+			materialized: true,
+		}
+		payload.decodePorts(&g)
+		g.uri = "/rest/" + c.Version + "/system/acl_object_groups/" + objectGroupKey(g.Name, "port")
+		groups = append(groups, g)
+	}
+
+	return groups, nil
+}
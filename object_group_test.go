@@ -0,0 +1,190 @@
+package aoscxgo_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aruba/aoscxgo"
+)
+
+func TestAddressGroupCRUD(t *testing.T) {
+	_, c := connect(t)
+	ctx := context.Background()
+
+	group := aoscxgo.AddressGroup{Name: "servers", Type: "ipv4", Addresses: []string{"10.0.0.1", "10.1.0.0/16"}}
+	err := group.Create(ctx, c)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	got := aoscxgo.AddressGroup{Name: "servers", Type: "ipv4"}
+	err = got.Get(ctx, c)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if strings.Join(got.Addresses, ",") != "10.0.0.1/32,10.1.0.0/16" {
+		t.Errorf("Addresses = %v", got.Addresses)
+	}
+
+	group.Addresses = []string{"10.2.0.0/16"}
+	err = group.Update(ctx, c)
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	err = got.Get(ctx, c)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if strings.Join(got.Addresses, ",") != "10.2.0.0/16" {
+		t.Errorf("Addresses after Update = %v", got.Addresses)
+	}
+
+	err = group.Delete(ctx, c)
+	if err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	err = got.Get(ctx, c)
+	if !errors.Is(err, aoscxgo.ErrNotFound) {
+		t.Errorf("Get after Delete = %v, want ErrNotFound", err)
+	}
+
+	for _, invalid := range []aoscxgo.AddressGroup{
+		{Name: "a,b", Type: "ipv4"},
+		{Name: "servers", Type: "mac"},
+		{Name: "servers", Type: "ipv6", Addresses: []string{"10.0.0.1"}},
+	} {
+		err := invalid.Create(ctx, c)
+		if !errors.Is(err, aoscxgo.ErrValidation) {
+			t.Errorf("Create(%+v) = %v, want ErrValidation", invalid, err)
+		}
+	}
+}
+
+func TestPortGroupCRUD(t *testing.T) {
+	_, c := connect(t)
+	ctx := context.Background()
+
+	group := aoscxgo.PortGroup{Name: "web", Ports: []string{"80", "8000-8080"}}
+	err := group.Create(ctx, c)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	group.Ports = []string{"443"}
+	err = group.Update(ctx, c)
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	got := aoscxgo.PortGroup{Name: "web"}
+	err = got.Get(ctx, c)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if strings.Join(got.Ports, ",") != "443" {
+		t.Errorf("Ports = %v", got.Ports)
+	}
+
+	invalid := aoscxgo.PortGroup{Name: "web", Ports: []string{"80-20"}}
+	err = invalid.Update(ctx, c)
+	if !errors.Is(err, aoscxgo.ErrValidation) {
+		t.Errorf("Update with invalid ports = %v, want ErrValidation", err)
+	}
+
+	err = group.Delete(ctx, c)
+	if err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+}
+
+func TestListObjectGroups(t *testing.T) {
+	srv, c := connect(t)
+	ctx := context.Background()
+
+	group := aoscxgo.AddressGroup{Name: "servers", Type: "ipv6", Addresses: []string{"2001:db8::/64"}}
+	err := group.Create(ctx, c)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	ports := aoscxgo.PortGroup{Name: "web", Ports: []string{"443"}}
+	err = ports.Create(ctx, c)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	// The switch escapes the names in the keys of the groups
+	srv.SetObject("system/acl_object_groups", "branch%20offices,ipv4", map[string]interface{}{
+		"name":        "branch offices",
+		"object_type": "ipv4",
+		"cfg_entries": map[string]interface{}{"10": map[string]interface{}{"address": "10.9.0.0/255.255.0.0"}},
+	})
+
+	groups, err := aoscxgo.ListAddressGroups(ctx, c)
+	if err != nil {
+		t.Fatalf("ListAddressGroups: %v", err)
+	}
+	if len(groups) != 2 || groups[0].Name != "branch offices" || groups[0].Type != "ipv4" ||
+		strings.Join(groups[0].Addresses, ",") != "10.9.0.0/16" || groups[1].Name != "servers" ||
+		groups[0].GetURI() != "/rest/"+c.Version+"/system/acl_object_groups/branch%20offices,ipv4" {
+		t.Errorf("ListAddressGroups = %+v", groups)
+	}
+
+	port_groups, err := aoscxgo.ListPortGroups(ctx, c)
+	if err != nil {
+		t.Fatalf("ListPortGroups: %v", err)
+	}
+	if len(port_groups) != 1 || port_groups[0].Name != "web" || strings.Join(port_groups[0].Ports, ",") != "443" {
+		t.Errorf("ListPortGroups = %+v", port_groups)
+	}
+}
+
+func TestAclGroups(t *testing.T) {
+	_, c := connect(t)
+	ctx := context.Background()
+
+	acl := aoscxgo.Acl{
+		Name: "web",
+		Type: "ipv4",
+		Entries: []aoscxgo.AclEntry{
+			{Sequence: 10, Action: "permit", Protocol: "tcp", DestinationGroup: "servers", DestinationPortGroup: "web"},
+		},
+	}
+	err := acl.Create(ctx, c)
+	var dependency *aoscxgo.DependencyError
+	if !errors.As(err, &dependency) || !errors.Is(err, aoscxgo.ErrValidation) || !errors.Is(err, aoscxgo.ErrNotFound) {
+		t.Fatalf("Create with a missing group = %v, want *DependencyError", err)
+	}
+
+	// Failures other than a missing group are returned unchanged
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	err = acl.Create(canceled, c)
+	if !errors.Is(err, context.Canceled) || errors.Is(err, aoscxgo.ErrValidation) {
+		t.Errorf("Create with a canceled context = %v, want context.Canceled", err)
+	}
+
+	group := aoscxgo.AddressGroup{Name: "servers", Type: "ipv4", Addresses: []string{"10.0.0.0/24"}}
+	err = group.Create(ctx, c)
+	if err != nil {
+		t.Fatalf("AddressGroup.Create: %v", err)
+	}
+	ports := aoscxgo.PortGroup{Name: "web", Ports: []string{"443"}}
+	err = ports.Create(ctx, c)
+	if err != nil {
+		t.Fatalf("PortGroup.Create: %v", err)
+	}
+
+	err = acl.Create(ctx, c)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	got := aoscxgo.Acl{Name: "web", Type: "ipv4"}
+	err = got.Get(ctx, c)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if len(got.Entries) != 1 || got.Entries[0] != acl.Entries[0] {
+		t.Errorf("Get = %+v, want %+v", got.Entries, acl.Entries)
+	}
+}
@@ -0,0 +1,412 @@
+package aoscxgo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// OspfInterface enables OSPF on a routed interface, such as an L3Interface
+// or VlanInterface, in an area of an OspfRouter.
+type OspfInterface struct {
+
+	// Connection properties.
+	// Version is 2 for OSPFv2 or 3 for OSPFv3, defaults to 2.
+	Version int `json:"version"`
+	// Vrf and InstanceTag identify the OspfRouter, Vrf defaults to
+	// "default". The VRF must be the one of the interface.
+	Vrf         string `json:"vrf"`
+	InstanceTag int    `json:"instance_tag"`
+	// Area is the ID of an area of the router, e.g. "0.0.0.0". To move the
+	// interface to another area delete and create it.
+	Area string `json:"area"`
+	// Interface is the name of the interface, e.g. "1/1/1", "lag1" or
+	// "vlan10" for a VlanInterface.
+	Interface string `json:"interface"`
+	// Cost is the OSPF cost 1-65535, derived from the speed if zero.
+	Cost int `json:"cost"`
+	// NetworkType is "broadcast" or "point-to-point", defaults to
+	// "broadcast".
+	NetworkType string `json:"network_type"`
+	// AuthType is "none", "text" or "md5" and only supported by OSPFv2,
+	// defaults to "none". AuthKey is the text key or the MD5 key with ID
+	// AuthKeyId. AuthKey is not returned by Get.
+	AuthType  string `json:"auth_type"`
+	AuthKey   string `json:"auth_key"`
+	AuthKeyId int    `json:"auth_key_id"`
+	// HelloInterval and DeadInterval in seconds, default to 10 and 40.
+	HelloInterval int `json:"hello_interval"`
+	DeadInterval  int `json:"dead_interval"`
+
+	OspfInterfaceDetails map[string]interface{} `json:"details"`
+	materialized         bool
+	uri                  string
+}
+
+// Values of ospf_if_type and ospf_auth_type for the NetworkType and AuthType
+// of an OspfInterface.
+var (
+	ospf_network_types = map[string]string{
+		"broadcast":      "ospf_iftype_broadcast",
+		"point-to-point": "ospf_iftype_pointopoint",
+	}
+	ospf_auth_types = map[string]string{
+		"none": "null",
+		"text": "text",
+		"md5":  "md5",
+	}
+)
+
+// checkRoutedName validates if the name of a routed interface is valid or
+// not, accepting ports, LAGs, VLAN interfaces and loopbacks.
+func checkRoutedName(name string) bool {
+	if checkName(name) {
+		return true
+	}
+	found, err := regexp.MatchString("^(vlan|loopback)\\d+$", name)
+	return found && err == nil
+}
+
+// normalizeKey validates the keys identifying the OSPF interface and sets
+// their defaults.
+func (o *OspfInterface) normalizeKey(op string) error {
+	err := normalizeOspf(op, &o.Version, &o.Vrf, o.InstanceTag)
+	if err != nil {
+		return err
+	}
+	area_id, ok := normalizeOspfArea(o.Area)
+	if !ok {
+		return newValidationError(op, "Area", "invalid area ID: "+o.Area)
+	}
+	o.Area = area_id
+	if !checkRoutedName(o.Interface) {
+		return newValidationError(op, "Interface", "invalid interface name: "+o.Interface)
+	}
+	return nil
+}
+
+// normalize validates the OSPF interface settings and sets their defaults.
+// op names the calling operation in the returned error.
+func (o *OspfInterface) normalize(op string) error {
+	err := o.normalizeKey(op)
+	if err != nil {
+		return err
+	}
+
+	if o.Cost < 0 || o.Cost > 65535 {
+		return newValidationError(op, "Cost", "valid range is 1-65535 received: "+strconv.Itoa(o.Cost))
+	}
+	if o.NetworkType == "" {
+		o.NetworkType = "broadcast"
+	}
+	if _, ok := ospf_network_types[o.NetworkType]; !ok {
+		return newValidationError(op, "NetworkType", "valid options are 'broadcast' or 'point-to-point' received: "+o.NetworkType)
+	}
+
+	if o.AuthType == "" {
+		o.AuthType = "none"
+	}
+	if _, ok := ospf_auth_types[o.AuthType]; !ok {
+		return newValidationError(op, "AuthType", "valid options are 'none', 'text' or 'md5' received: "+o.AuthType)
+	}
+	if o.AuthType != "none" && o.Version == 3 {
+		return newValidationError(op, "AuthType", "authentication is only supported by OSPFv2")
+	}
+	if o.AuthType != "none" && o.AuthKey == "" {
+		return newValidationError(op, "AuthKey", "missing key for "+o.AuthType+" authentication")
+	}
+	if o.AuthType == "md5" && (o.AuthKeyId < 1 || o.AuthKeyId > 255) {
+		return newValidationError(op, "AuthKeyId", "valid range is 1-255 received: "+strconv.Itoa(o.AuthKeyId))
+	}
+
+	if o.HelloInterval == 0 {
+		o.HelloInterval = 10
+	}
+	if o.DeadInterval == 0 {
+		o.DeadInterval = 40
+	}
+	if o.HelloInterval < 1 || o.HelloInterval > 65535 {
+		return newValidationError(op, "HelloInterval", "valid range is 1-65535 received: "+strconv.Itoa(o.HelloInterval))
+	}
+	if o.DeadInterval <= o.HelloInterval || o.DeadInterval > 65535 {
+		return newValidationError(op, "DeadInterval", "must be greater than HelloInterval and at most 65535 received: "+strconv.Itoa(o.DeadInterval))
+	}
+	return nil
+}
+
+// ospfInterfacesURI returns the URI of the OSPF interfaces of the area below
+// the REST version.
+func (o *OspfInterface) ospfInterfacesURI() string {
+	return ospfRoutersURI(o.Vrf, o.Version) + "/" + strconv.Itoa(o.InstanceTag) + "/areas/" + o.Area + "/ospf_interfaces"
+}
+
+// interfaceMap returns the attributes of the OSPF interface to send to the
+// switch.
+func (o *OspfInterface) interfaceMap() map[string]interface{} {
+	interfaceMap := map[string]interface{}{
+		"ospf_if_type":   ospf_network_types[o.NetworkType],
+		"ospf_auth_type": ospf_auth_types[o.AuthType],
+		"ospf_intervals": map[string]interface{}{
+			"hello_interval": o.HelloInterval,
+			"dead_interval":  o.DeadInterval,
+		},
+		"ospf_auth_text_key": nil,
+		"ospf_auth_md5_keys": map[string]interface{}{},
+	}
+	if o.Cost > 0 {
+		interfaceMap["ospf_if_cost"] = o.Cost
+	} else {
+		interfaceMap["ospf_if_cost"] = nil
+	}
+
+	switch o.AuthType {
+	case "text":
+		interfaceMap["ospf_auth_text_key"] = o.AuthKey
+	case "md5":
+		interfaceMap["ospf_auth_md5_keys"] = map[string]interface{}{strconv.Itoa(o.AuthKeyId): o.AuthKey}
+	}
+	return interfaceMap
+}
+
+// Create performs POST to enable OSPF on the interface on the given Client
+// object. The interface and the area of the router must exist.
+func (o *OspfInterface) Create(ctx context.Context, c *Client) error {
+	err := o.normalize("OspfInterface.Create")
+	if err != nil {
+		return err
+	}
+
+	// Make sure Interface exists
+	int_str := url.PathEscape(o.Interface)
+	tmp_int := Interface{Name: o.Interface}
+	err = tmp_int.Get(ctx, c)
+	if err != nil {
+		return fmt.Errorf("OspfInterface.Create: missing Interface %s: %w", o.Interface, err)
+	}
+
+	base_uri := o.ospfInterfacesURI()
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri
+	o.uri = "/rest/" + c.Version + "/" + base_uri + "/" + int_str
+
+	postMap := o.interfaceMap()
+	postMap["name"] = o.Interface
+	postMap["port"] = "/rest/" + c.Version + "/system/interfaces/" + int_str
+
+	postBody, _ := json.Marshal(postMap)
+
+	json_body := bytes.NewBuffer(postBody)
+
+	res, err := post(ctx, c, url, json_body)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusCreated {
+		return newRequestError("OspfInterface.Create", res)
+	}
+
+	o.materialized = true
+
+	return nil
+}
+
+// Update performs PATCH to update the OSPF settings of the interface on the
+// given Client object.
+func (o *OspfInterface) Update(ctx context.Context, c *Client) error {
+	err := o.normalize("OspfInterface.Update")
+	if err != nil {
+		return err
+	}
+
+	int_str := url.PathEscape(o.Interface)
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + o.ospfInterfacesURI() + "/" + int_str
+
+	patchBody, _ := json.Marshal(o.interfaceMap())
+
+	json_body := bytes.NewBuffer(patchBody)
+
+	res, err := patch(ctx, c, url, json_body)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusNoContent {
+		return newRequestError("OspfInterface.Update", res)
+	}
+
+	return nil
+}
+
+// Delete performs DELETE to disable OSPF on the interface on the given
+// Client object.
+func (o *OspfInterface) Delete(ctx context.Context, c *Client) error {
+	err := o.normalizeKey("OspfInterface.Delete")
+	if err != nil {
+		return err
+	}
+
+	int_str := url.PathEscape(o.Interface)
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + o.ospfInterfacesURI() + "/" + int_str
+
+	res, err := delete(ctx, c, url)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusNoContent && res.StatusCode != http.StatusOK {
+		return newRequestError("OspfInterface.Delete", res)
+	}
+
+	o.materialized = false
+
+	return nil
+}
+
+// Get performs GET to retrieve the OSPF settings of the interface from the
+// given Client object.
+func (o *OspfInterface) Get(ctx context.Context, c *Client) error {
+	err := o.normalizeKey("OspfInterface.Get")
+	if err != nil {
+		return err
+	}
+
+	base_uri := o.ospfInterfacesURI()
+	int_str := url.PathEscape(o.Interface)
+	o.uri = "/rest/" + c.Version + "/" + base_uri + "/" + int_str
+
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri + "/" + int_str
+
+	res, body, err := get(ctx, c, url)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		o.materialized = false
+		return newRequestError("OspfInterface.Get", res)
+	}
+
+	payload := ospfInterfacePayload{}
+	err = json.NewDecoder(res.Body).Decode(&payload)
+	if err != nil {
+		return fmt.Errorf("OspfInterface.Get: decoding %s: %w", o.Interface, err)
+	}
+	payload.decode(o)
+
+	if o.OspfInterfaceDetails == nil {
+		o.OspfInterfaceDetails = map[string]interface{}{}
+	}
+	for key, value := range body {
+		o.OspfInterfaceDetails[key] = value
+	}
+
+	o.materialized = true
+
+	return nil
+}
+
+// GetStatus returns True if OSPF is enabled on the interface on Client
+// object or False if not.
+func (o *OspfInterface) GetStatus() bool {
+	return o.materialized
+}
+
+// GetURI returns URI of the OSPF interface.
+func (o *OspfInterface) GetURI() string {
+	return o.uri
+}
+
+// ospfInterfacePayload is the OSPF interface as returned by the switch.
+type ospfInterfacePayload struct {
+	OspfIfType      string                 `json:"ospf_if_type"`
+	OspfIfCost      *int                   `json:"ospf_if_cost"`
+	OspfAuthType    string                 `json:"ospf_auth_type"`
+	OspfAuthMd5Keys map[string]interface{} `json:"ospf_auth_md5_keys"`
+	OspfIntervals   map[string]int         `json:"ospf_intervals"`
+}
+
+// decode sets the fields of o from the payload.
+func (p *ospfInterfacePayload) decode(o *OspfInterface) {
+	o.Cost = 0
+	if p.OspfIfCost != nil {
+		o.Cost = *p.OspfIfCost
+	}
+
+	o.NetworkType = "broadcast"
+	for network_type, if_type := range ospf_network_types {
+		if p.OspfIfType == if_type {
+			o.NetworkType = network_type
+		}
+	}
+
+	o.AuthType = "none"
+	for auth_type, value := range ospf_auth_types {
+		if p.OspfAuthType == value {
+			o.AuthType = auth_type
+		}
+	}
+	o.AuthKeyId = 0
+	for key_id := range p.OspfAuthMd5Keys {
+		o.AuthKeyId, _ = strconv.Atoi(key_id)
+	}
+
+	o.HelloInterval = p.OspfIntervals["hello_interval"]
+	o.DeadInterval = p.OspfIntervals["dead_interval"]
+}
+
+// ListOspfInterfaces performs GET to retrieve the interfaces of all areas of
+// an OspfRouter from the given Client object, sorted by area and name. The
+// version defaults to 2 and the VRF to "default".
+func ListOspfInterfaces(ctx context.Context, c *Client, vrf string, version int, instance_tag int) ([]OspfInterface, error) {
+	router := OspfRouter{Version: version, Vrf: vrf, InstanceTag: instance_tag}
+	err := router.Get(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	interfaces := []OspfInterface{}
+	for _, area := range router.Areas {
+		o := OspfInterface{Version: router.Version, Vrf: router.Vrf, InstanceTag: instance_tag, Area: area.AreaId}
+		base_uri := o.ospfInterfacesURI()
+		url_str := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri
+
+		res, _, err := get(ctx, c, url_str+"?depth=2&attributes=name,ospf_if_type,ospf_if_cost,ospf_auth_type,ospf_auth_md5_keys,ospf_intervals")
+		if err != nil {
+			return nil, err
+		}
+
+		if res.StatusCode != http.StatusOK {
+			return nil, newRequestError("ListOspfInterfaces", res)
+		}
+
+		payloads := map[string]ospfInterfacePayload{}
+		err = json.NewDecoder(res.Body).Decode(&payloads)
+		if err != nil {
+			return nil, fmt.Errorf("ListOspfInterfaces: decoding interfaces of area %s: %w", area.AreaId, err)
+		}
+
+		names := []string{}
+		for name := range payloads {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			payload := payloads[name]
+			i := o
+			i.Interface = name
+			i.materialized = true
+			i.uri = "/rest/" + c.Version + "/" + base_uri + "/" + url.PathEscape(name)
+			payload.decode(&i)
+			interfaces = append(interfaces, i)
+		}
+	}
+
+	return interfaces, nil
+}
@@ -0,0 +1,72 @@
+package aoscxgo_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aruba/aoscxgo"
+)
+
+func TestOspfInterface(t *testing.T) {
+	srv, c := connect(t)
+	ctx := context.Background()
+	srv.AddInterface("1/1/1")
+
+	router := aoscxgo.OspfRouter{InstanceTag: 1, Areas: []aoscxgo.OspfArea{{AreaId: "0"}}}
+	err := router.Create(ctx, c)
+	if err != nil {
+		t.Fatalf("OspfRouter.Create: %v", err)
+	}
+
+	ospf := aoscxgo.OspfInterface{
+		InstanceTag: 1,
+		Area:        "0",
+		Interface:   "1/1/1",
+		Cost:        100,
+		NetworkType: "point-to-point",
+		AuthType:    "md5",
+		AuthKey:     "secret",
+		AuthKeyId:   1,
+	}
+	err = ospf.Create(ctx, c)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	got := aoscxgo.OspfInterface{InstanceTag: 1, Area: "0.0.0.0", Interface: "1/1/1"}
+	err = got.Get(ctx, c)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.Cost != 100 || got.NetworkType != "point-to-point" || got.AuthType != "md5" || got.AuthKeyId != 1 ||
+		got.AuthKey != "" || got.HelloInterval != 10 || got.DeadInterval != 40 {
+		t.Errorf("Get = %+v", got)
+	}
+
+	ospf.Cost = 200
+	ospf.AuthKey = ""
+	ospf.AuthType = "none"
+	err = ospf.Update(ctx, c)
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	interfaces, err := aoscxgo.ListOspfInterfaces(ctx, c, "", 2, 1)
+	if err != nil {
+		t.Fatalf("ListOspfInterfaces: %v", err)
+	}
+	if len(interfaces) != 1 || interfaces[0].Cost != 200 || interfaces[0].AuthType != "none" {
+		t.Errorf("ListOspfInterfaces = %+v", interfaces)
+	}
+
+	err = ospf.Delete(ctx, c)
+	if err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	v3 := aoscxgo.OspfInterface{Version: 3, InstanceTag: 1, Area: "0", Interface: "1/1/1", AuthType: "text", AuthKey: "key"}
+	err = v3.Create(ctx, c)
+	if !errors.Is(err, aoscxgo.ErrValidation) {
+		t.Errorf("Create of OSPFv3 with authentication = %v, want ErrValidation", err)
+	}
+}
@@ -0,0 +1,468 @@
+package aoscxgo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+
+	"golang.org/x/exp/slices"
+)
+
+// OspfRouter is an OSPFv2 or OSPFv3 router instance of a VRF with its areas.
+type OspfRouter struct {
+
+	// Connection properties.
+	// Version is 2 for OSPFv2 or 3 for OSPFv3, defaults to 2.
+	Version int `json:"version"`
+	// Vrf is the VRF of the router, defaults to "default".
+	Vrf string `json:"vrf"`
+	// InstanceTag identifies the router within the VRF, 1-63.
+	InstanceTag int    `json:"instance_tag"`
+	RouterId    string `json:"router_id"`
+	// PassiveDefault makes interfaces passive unless configured otherwise.
+	PassiveDefault bool `json:"passive_default"`
+	// Redistribute lists the route sources redistributed into OSPF,
+	// "connected", "static", "bgp" or "local-loopback".
+	Redistribute []string   `json:"redistribute"`
+	Areas        []OspfArea `json:"areas"`
+
+	OspfRouterDetails map[string]interface{} `json:"details"`
+	materialized      bool
+	uri               string
+}
+
+// OspfArea is an area of an OspfRouter.
+type OspfArea struct {
+	// AreaId in dotted decimal or decimal form, e.g. "0.0.0.0" or "0".
+	AreaId string `json:"area_id"`
+	// Type is "default", "stub", "stub_no_summary", "nssa" or
+	// "nssa_no_summary", defaults to "default".
+	Type string `json:"type"`
+}
+
+// ospfRoutersURI returns the URI of the OSPF routers of the VRF below the
+// REST version.
+func ospfRoutersURI(vrf string, version int) string {
+	table := "ospf_routers"
+	if version == 3 {
+		table = "ospfv3_routers"
+	}
+	return "system/vrfs/" + url.PathEscape(vrf) + "/" + table
+}
+
+// normalizeOspfArea returns an area ID in dotted decimal form.
+func normalizeOspfArea(area_id string) (string, bool) {
+	if number, err := strconv.ParseUint(area_id, 10, 32); err == nil {
+		return net.IPv4(byte(number>>24), byte(number>>16), byte(number>>8), byte(number)).String(), true
+	}
+	ip := net.ParseIP(area_id).To4()
+	if ip == nil {
+		return "", false
+	}
+	return ip.String(), true
+}
+
+// normalizeOspf validates the version, VRF and instance tag shared by OSPF
+// resources and sets their defaults.
+func normalizeOspf(op string, version *int, vrf *string, instance_tag int) error {
+	if *version == 0 {
+		*version = 2
+	}
+	if *version != 2 && *version != 3 {
+		return newValidationError(op, "Version", "valid options are 2 or 3 received: "+strconv.Itoa(*version))
+	}
+	if *vrf == "" {
+		*vrf = "default"
+	}
+	if instance_tag < 1 || instance_tag > 63 {
+		return newValidationError(op, "InstanceTag", "valid range is 1-63 received: "+strconv.Itoa(instance_tag))
+	}
+	return nil
+}
+
+// normalize validates the router settings and sets its defaults. op names
+// the calling operation in the returned error.
+func (o *OspfRouter) normalize(op string) error {
+	err := normalizeOspf(op, &o.Version, &o.Vrf, o.InstanceTag)
+	if err != nil {
+		return err
+	}
+	if o.RouterId != "" && net.ParseIP(o.RouterId).To4() == nil {
+		return newValidationError(op, "RouterId", "expected IPv4 address received: "+o.RouterId)
+	}
+	for _, source := range o.Redistribute {
+		if !slices.Contains([]string{"connected", "static", "bgp", "local-loopback"}, source) {
+			return newValidationError(op, "Redistribute", "valid options are 'connected', 'static', 'bgp' or 'local-loopback' received: "+source)
+		}
+	}
+
+	seen := map[string]bool{}
+	for index := range o.Areas {
+		area := &o.Areas[index]
+		area_id, ok := normalizeOspfArea(area.AreaId)
+		if !ok {
+			return newValidationError(op, "AreaId", "invalid area ID: "+area.AreaId)
+		}
+		if seen[area_id] {
+			return newValidationError(op, "AreaId", "duplicate area "+area_id)
+		}
+		seen[area_id] = true
+		area.AreaId = area_id
+
+		if area.Type == "" {
+			area.Type = "default"
+		}
+		if !slices.Contains([]string{"default", "stub", "stub_no_summary", "nssa", "nssa_no_summary"}, area.Type) {
+			return newValidationError(op, "Type", "valid options are 'default', 'stub', 'stub_no_summary', 'nssa' or 'nssa_no_summary' received: "+area.Type)
+		}
+		if area_id == "0.0.0.0" && area.Type != "default" {
+			return newValidationError(op, "Type", "backbone area must be of type 'default'")
+		}
+	}
+	return nil
+}
+
+// routerMap returns the attributes of the router to send to the switch.
+func (o *OspfRouter) routerMap() map[string]interface{} {
+	redistribute := []string{}
+	if o.Redistribute != nil {
+		redistribute = o.Redistribute
+	}
+
+	routerMap := map[string]interface{}{
+		"passive_interface_default": o.PassiveDefault,
+		"redistribute":              redistribute,
+	}
+	if o.RouterId != "" {
+		routerMap["router_id"] = o.RouterId
+	} else {
+		routerMap["router_id"] = nil
+	}
+	return routerMap
+}
+
+// Create performs POST to create the OSPF router and its areas on the given
+// Client object. If an area fails the router is deleted again.
+func (o *OspfRouter) Create(ctx context.Context, c *Client) error {
+	err := o.normalize("OspfRouter.Create")
+	if err != nil {
+		return err
+	}
+
+	base_uri := ospfRoutersURI(o.Vrf, o.Version)
+	tag_str := strconv.Itoa(o.InstanceTag)
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri
+	o.uri = "/rest/" + c.Version + "/" + base_uri + "/" + tag_str
+
+	postMap := o.routerMap()
+	postMap["instance_tag"] = o.InstanceTag
+
+	postBody, _ := json.Marshal(postMap)
+
+	json_body := bytes.NewBuffer(postBody)
+
+	res, err := post(ctx, c, url, json_body)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusCreated {
+		return newRequestError("OspfRouter.Create", res)
+	}
+
+	err = o.updateAreas(ctx, c, "OspfRouter.Create", nil)
+	if err != nil {
+		// Do not leave the router behind without its areas
+		return errors.Join(err, o.Delete(ctx, c))
+	}
+
+	o.materialized = true
+
+	return nil
+}
+
+// Update performs PATCH to update the OSPF router on the given Client
+// object. Areas are created, changed or deleted to match Areas.
+func (o *OspfRouter) Update(ctx context.Context, c *Client) error {
+	err := o.normalize("OspfRouter.Update")
+	if err != nil {
+		return err
+	}
+
+	tag_str := strconv.Itoa(o.InstanceTag)
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + ospfRoutersURI(o.Vrf, o.Version) + "/" + tag_str
+
+	patchBody, _ := json.Marshal(o.routerMap())
+
+	json_body := bytes.NewBuffer(patchBody)
+
+	res, err := patch(ctx, c, url, json_body)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusNoContent {
+		return newRequestError("OspfRouter.Update", res)
+	}
+
+	current, err := getOspfAreas(ctx, c, "OspfRouter.Update", url+"/areas")
+	if err != nil {
+		return err
+	}
+
+	return o.updateAreas(ctx, c, "OspfRouter.Update", current)
+}
+
+// updateAreas creates, changes or deletes the areas of the router on the
+// switch, holding the current areas, to match Areas.
+func (o *OspfRouter) updateAreas(ctx context.Context, c *Client, op string, current []OspfArea) error {
+	tag_str := strconv.Itoa(o.InstanceTag)
+	areas_url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + ospfRoutersURI(o.Vrf, o.Version) + "/" + tag_str + "/areas"
+
+	current_types := map[string]string{}
+	for _, area := range current {
+		current_types[area.AreaId] = area.Type
+	}
+	desired := map[string]bool{}
+
+	for _, area := range o.Areas {
+		desired[area.AreaId] = true
+		current_type, exists := current_types[area.AreaId]
+		if exists && current_type == area.Type {
+			continue
+		}
+
+		var res *http.Response
+		var err error
+		if exists {
+			patchBody, _ := json.Marshal(map[string]interface{}{"area_type": area.Type})
+			res, err = patch(ctx, c, areas_url+"/"+area.AreaId, bytes.NewBuffer(patchBody))
+		} else {
+			postBody, _ := json.Marshal(map[string]interface{}{"area_id": area.AreaId, "area_type": area.Type})
+			res, err = post(ctx, c, areas_url, bytes.NewBuffer(postBody))
+		}
+		if err != nil {
+			return err
+		}
+
+		if res.StatusCode != http.StatusCreated && res.StatusCode != http.StatusNoContent {
+			return newRequestError(op, res)
+		}
+	}
+
+	for _, area := range current {
+		if desired[area.AreaId] {
+			continue
+		}
+
+		res, err := delete(ctx, c, areas_url+"/"+area.AreaId)
+		if err != nil {
+			return err
+		}
+
+		if res.StatusCode != http.StatusNoContent && res.StatusCode != http.StatusOK {
+			return newRequestError(op, res)
+		}
+	}
+
+	return nil
+}
+
+// Delete performs DELETE to remove the OSPF router, its areas and their
+// interfaces from the given Client object.
+func (o *OspfRouter) Delete(ctx context.Context, c *Client) error {
+	err := normalizeOspf("OspfRouter.Delete", &o.Version, &o.Vrf, o.InstanceTag)
+	if err != nil {
+		return err
+	}
+
+	tag_str := strconv.Itoa(o.InstanceTag)
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + ospfRoutersURI(o.Vrf, o.Version) + "/" + tag_str
+
+	res, err := delete(ctx, c, url)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusNoContent && res.StatusCode != http.StatusOK {
+		return newRequestError("OspfRouter.Delete", res)
+	}
+
+	o.materialized = false
+
+	return nil
+}
+
+// Get performs GET to retrieve the OSPF router and its areas from the given
+// Client object.
+func (o *OspfRouter) Get(ctx context.Context, c *Client) error {
+	err := normalizeOspf("OspfRouter.Get", &o.Version, &o.Vrf, o.InstanceTag)
+	if err != nil {
+		return err
+	}
+
+	base_uri := ospfRoutersURI(o.Vrf, o.Version)
+	tag_str := strconv.Itoa(o.InstanceTag)
+	o.uri = "/rest/" + c.Version + "/" + base_uri + "/" + tag_str
+
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri + "/" + tag_str
+
+	res, body, err := get(ctx, c, url)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		o.materialized = false
+		return newRequestError("OspfRouter.Get", res)
+	}
+
+	payload := ospfRouterPayload{}
+	err = json.NewDecoder(res.Body).Decode(&payload)
+	if err != nil {
+		return fmt.Errorf("OspfRouter.Get: decoding router %d: %w", o.InstanceTag, err)
+	}
+	payload.decode(o)
+
+	o.Areas, err = getOspfAreas(ctx, c, "OspfRouter.Get", url+"/areas")
+	if err != nil {
+		return err
+	}
+
+	if o.OspfRouterDetails == nil {
+		o.OspfRouterDetails = map[string]interface{}{}
+	}
+	for key, value := range body {
+		o.OspfRouterDetails[key] = value
+	}
+
+	o.materialized = true
+
+	return nil
+}
+
+// GetStatus returns True if the OSPF router exists on Client object or False
+// if not.
+func (o *OspfRouter) GetStatus() bool {
+	return o.materialized
+}
+
+// GetURI returns URI of the OSPF router.
+func (o *OspfRouter) GetURI() string {
+	return o.uri
+}
+
+// ospfRouterPayload is the OSPF router as returned by the switch.
+type ospfRouterPayload struct {
+	RouterId                *string  `json:"router_id"`
+	PassiveInterfaceDefault bool     `json:"passive_interface_default"`
+	Redistribute            []string `json:"redistribute"`
+}
+
+// decode sets the fields of o from the payload.
+func (p *ospfRouterPayload) decode(o *OspfRouter) {
+	o.RouterId = ""
+	if p.RouterId != nil {
+		o.RouterId = *p.RouterId
+	}
+	o.PassiveDefault = p.PassiveInterfaceDefault
+	o.Redistribute = p.Redistribute
+}
+
+// getOspfAreas performs GET to retrieve the areas of a router at the given
+// URL, sorted by area ID.
+func getOspfAreas(ctx context.Context, c *Client, op string, url string) ([]OspfArea, error) {
+	res, _, err := get(ctx, c, url+"?depth=2&attributes=area_id,area_type")
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, newRequestError(op, res)
+	}
+
+	payloads := map[string]struct {
+		AreaType string `json:"area_type"`
+	}{}
+	err = json.NewDecoder(res.Body).Decode(&payloads)
+	if err != nil {
+		return nil, fmt.Errorf("%s: decoding areas: %w", op, err)
+	}
+
+	areas := []OspfArea{}
+	for area_id, payload := range payloads {
+		area_type := payload.AreaType
+		if area_type == "" {
+			area_type = "default"
+		}
+		areas = append(areas, OspfArea{AreaId: area_id, Type: area_type})
+	}
+
+	sort.Slice(areas, func(a, b int) bool {
+		return bytes.Compare(net.ParseIP(areas[a].AreaId).To4(), net.ParseIP(areas[b].AreaId).To4()) < 0
+	})
+
+	return areas, nil
+}
+
+// ListOspfRouters performs GET to retrieve the OSPF routers of the given
+// version, 2 if zero, of a VRF, "default" if empty, with their areas from
+// the given Client object, sorted by instance tag.
+func ListOspfRouters(ctx context.Context, c *Client, vrf string, version int) ([]OspfRouter, error) {
+	if vrf == "" {
+		vrf = "default"
+	}
+	if version == 0 {
+		version = 2
+	}
+
+	base_uri := ospfRoutersURI(vrf, version)
+	url_str := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri
+
+	res, _, err := get(ctx, c, url_str+"?depth=2&attributes=instance_tag,router_id,passive_interface_default,redistribute")
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, newRequestError("ListOspfRouters", res)
+	}
+
+	payloads := map[string]ospfRouterPayload{}
+	err = json.NewDecoder(res.Body).Decode(&payloads)
+	if err != nil {
+		return nil, fmt.Errorf("ListOspfRouters: decoding routers: %w", err)
+	}
+
+	routers := []OspfRouter{}
+	for tag_str, payload := range payloads {
+		o := OspfRouter{
+			Version:      version,
+			Vrf:          vrf,
+			materialized: true,
+			uri:          "/rest/" + c.Version + "/" + base_uri + "/" + tag_str,
+		}
+		o.InstanceTag, _ = strconv.Atoi(tag_str)
+		payload.decode(&o)
+
+		o.Areas, err = getOspfAreas(ctx, c, "ListOspfRouters", url_str+"/"+tag_str+"/areas")
+		if err != nil {
+			return nil, err
+		}
+
+		routers = append(routers, o)
+	}
+
+	sort.Slice(routers, func(a, b int) bool {
+		return routers[a].InstanceTag < routers[b].InstanceTag
+	})
+
+	return routers, nil
+}
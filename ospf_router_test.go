@@ -0,0 +1,105 @@
+package aoscxgo_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/aruba/aoscxgo"
+)
+
+func TestOspfRouterCRUD(t *testing.T) {
+	_, c := connect(t)
+	ctx := context.Background()
+
+	router := aoscxgo.OspfRouter{
+		InstanceTag:  1,
+		RouterId:     "10.0.0.1",
+		Redistribute: []string{"connected"},
+		Areas: []aoscxgo.OspfArea{
+			{AreaId: "0"},
+			{AreaId: "1", Type: "stub"},
+		},
+	}
+	err := router.Create(ctx, c)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	got := aoscxgo.OspfRouter{InstanceTag: 1}
+	err = got.Get(ctx, c)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.Version != 2 || got.Vrf != "default" || got.RouterId != "10.0.0.1" || len(got.Redistribute) != 1 ||
+		len(got.Areas) != 2 || got.Areas[0] != (aoscxgo.OspfArea{AreaId: "0.0.0.0", Type: "default"}) ||
+		got.Areas[1] != (aoscxgo.OspfArea{AreaId: "0.0.0.1", Type: "stub"}) {
+		t.Errorf("Get = %+v", got)
+	}
+
+	router.Areas = []aoscxgo.OspfArea{{AreaId: "0.0.0.0"}, {AreaId: "0.0.0.2", Type: "nssa"}}
+	err = router.Update(ctx, c)
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	routers, err := aoscxgo.ListOspfRouters(ctx, c, "", 2)
+	if err != nil {
+		t.Fatalf("ListOspfRouters: %v", err)
+	}
+	if len(routers) != 1 || len(routers[0].Areas) != 2 || routers[0].Areas[1].AreaId != "0.0.0.2" {
+		t.Errorf("ListOspfRouters = %+v", routers)
+	}
+
+	err = router.Delete(ctx, c)
+	if err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	err = got.Get(ctx, c)
+	if !errors.Is(err, aoscxgo.ErrNotFound) {
+		t.Errorf("Get after Delete = %v, want ErrNotFound", err)
+	}
+}
+
+func TestOspfRouterValidation(t *testing.T) {
+	_, c := connect(t)
+	ctx := context.Background()
+
+	for _, router := range []aoscxgo.OspfRouter{
+		{InstanceTag: 0},
+		{InstanceTag: 1, Version: 4},
+		{InstanceTag: 1, RouterId: "2001:db8::1"},
+		{InstanceTag: 1, Redistribute: []string{"rip"}},
+		{InstanceTag: 1, Areas: []aoscxgo.OspfArea{{AreaId: "0", Type: "stub"}}},
+		{InstanceTag: 1, Areas: []aoscxgo.OspfArea{{AreaId: "1"}, {AreaId: "0.0.0.1"}}},
+	} {
+		err := router.Create(ctx, c)
+		if !errors.Is(err, aoscxgo.ErrValidation) {
+			t.Errorf("Create(%+v) = %v, want ErrValidation", router, err)
+		}
+	}
+}
+
+func TestOspfRouterCreateRollback(t *testing.T) {
+	srv, c := connect(t)
+	ctx := context.Background()
+	c.HTTPClient = &http.Client{
+		Jar:       c.HTTPClient.Jar,
+		Transport: failingTransport{transport: c.HTTPClient.Transport, method: "POST", suffix: "/areas"},
+	}
+
+	router := aoscxgo.OspfRouter{InstanceTag: 1, Areas: []aoscxgo.OspfArea{{AreaId: "0"}}}
+	err := router.Create(ctx, c)
+	var request_error *aoscxgo.RequestError
+	if !errors.As(err, &request_error) || request_error.StatusCode != http.StatusBadRequest {
+		t.Fatalf("Create = %v, want the failed area", err)
+	}
+
+	// The router is not left behind without its areas
+	if got := srv.Objects("system/vrfs/default/ospf_routers"); len(got) != 0 {
+		t.Errorf("OSPF routers after failed Create = %v", got)
+	}
+	if router.GetStatus() {
+		t.Error("GetStatus after failed Create = true")
+	}
+}
@@ -0,0 +1,258 @@
+package aoscxgo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/exp/slices"
+)
+
+// Class is an IPv4, IPv6 or MAC class map classifying traffic for the
+// entries of a Policy. Update replaces all entries in a single request.
+type Class struct {
+
+	// Connection properties.
+	Name string `json:"name"`
+	// Type is "ipv4", "ipv6" or "mac".
+	Type string `json:"type"`
+	// Entries match like those of an Acl, with Action "match" or "ignore".
+	// Log is not supported by classes.
+	Entries []AclEntry `json:"entries"`
+
+	ClassDetails map[string]interface{} `json:"details"`
+	materialized bool
+	uri          string
+}
+
+// checkValues validates the class and normalizes its entries as returned by
+// Get, sorted by Sequence. op names the calling operation in the returned
+// error.
+func (cl *Class) checkValues(op string) error {
+	if cl.Name == "" || strings.ContainsAny(cl.Name, ",/") {
+		return newValidationError(op, "Name", "invalid class name: "+cl.Name)
+	}
+	if !slices.Contains([]string{"ipv4", "ipv6", "mac"}, cl.Type) {
+		return newValidationError(op, "Type", "valid options are 'ipv4', 'ipv6' or 'mac' received: "+cl.Type)
+	}
+	for _, entry := range cl.Entries {
+		if entry.Log {
+			return newValidationError(op, "Log", "not supported by classes, entry "+strconv.FormatInt(entry.Sequence, 10))
+		}
+	}
+
+	return checkAclEntries(op, cl.Type, cl.Entries, []string{"match", "ignore"})
+}
+
+// classKey returns the escaped key of the class, "name,type".
+func (cl *Class) classKey() string {
+	return url.PathEscape(cl.Name) + "," + cl.Type
+}
+
+// Create performs POST to create the class with its entries on the given
+// Client object.
+func (cl *Class) Create(ctx context.Context, c *Client) error {
+	base_uri := "system/classes"
+
+	err := cl.checkValues("Class.Create")
+	if err != nil {
+		return err
+	}
+
+	err = checkAclGroups(ctx, c, "Class.Create", cl.Type, cl.Entries)
+	if err != nil {
+		return err
+	}
+
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri
+	cl.uri = "/rest/" + c.Version + "/" + base_uri + "/" + cl.classKey()
+
+	postMap := map[string]interface{}{
+		"name":        cl.Name,
+		"type":        cl.Type,
+		"cfg_entries": aclEntriesMap(c, cl.Type, cl.Entries, "type"),
+		"cfg_version": nextCfgVersion(),
+	}
+
+	postBody, _ := json.Marshal(postMap)
+
+	json_body := bytes.NewBuffer(postBody)
+
+	res, err := post(ctx, c, url, json_body)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusCreated {
+		return newRequestError("Class.Create", res)
+	}
+
+	cl.materialized = true
+
+	return nil
+}
+
+// Update performs PUT to replace all entries of the class on the given
+// Client object at once.
+func (cl *Class) Update(ctx context.Context, c *Client) error {
+	base_uri := "system/classes"
+
+	err := cl.checkValues("Class.Update")
+	if err != nil {
+		return err
+	}
+
+	err = checkAclGroups(ctx, c, "Class.Update", cl.Type, cl.Entries)
+	if err != nil {
+		return err
+	}
+
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri + "/" + cl.classKey()
+
+	putMap := map[string]interface{}{
+		"cfg_entries": aclEntriesMap(c, cl.Type, cl.Entries, "type"),
+		"cfg_version": nextCfgVersion(),
+	}
+
+	putBody, _ := json.Marshal(putMap)
+
+	json_body := bytes.NewBuffer(putBody)
+
+	res, err := put(ctx, c, url, json_body)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return newRequestError("Class.Update", res)
+	}
+
+	return nil
+}
+
+// Delete performs DELETE to remove the class from the given Client object.
+// The switch refuses to delete a class used by a policy.
+func (cl *Class) Delete(ctx context.Context, c *Client) error {
+	base_uri := "system/classes"
+
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri + "/" + cl.classKey()
+
+	res, err := delete(ctx, c, url)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusNoContent && res.StatusCode != http.StatusOK {
+		return newRequestError("Class.Delete", res)
+	}
+
+	cl.materialized = false
+
+	return nil
+}
+
+// Get performs GET to retrieve the class with its entries from the given
+// Client object.
+func (cl *Class) Get(ctx context.Context, c *Client) error {
+	base_uri := "system/classes"
+	cl.uri = "/rest/" + c.Version + "/" + base_uri + "/" + cl.classKey()
+
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri + "/" + cl.classKey() + "?depth=2"
+
+	res, body, err := get(ctx, c, url)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		cl.materialized = false
+		return newRequestError("Class.Get", res)
+	}
+
+	payload := classPayload{}
+	err = json.NewDecoder(res.Body).Decode(&payload)
+	if err != nil {
+		return fmt.Errorf("Class.Get: decoding %s: %w", cl.Name, err)
+	}
+	cl.Entries = decodeAclEntries(payload.CfgEntries)
+
+	if cl.ClassDetails == nil {
+		cl.ClassDetails = map[string]interface{}{}
+	}
+	for key, value := range body {
+		cl.ClassDetails[key] = value
+	}
+
+	cl.materialized = true
+
+	return nil
+}
+
+// GetStatus returns True if the class exists on Client object or False if
+// not.
+func (cl *Class) GetStatus() bool {
+	return cl.materialized
+}
+
+// GetURI returns URI of the class.
+func (cl *Class) GetURI() string {
+	return cl.uri
+}
+
+// classPayload is the class as returned by the switch.
+type classPayload struct {
+	CfgEntries map[string]acePayload `json:"cfg_entries"`
+}
+
+// ListClasses performs GET to retrieve all classes with their entries from
+// the given Client object in a single request, sorted by name and type.
+func ListClasses(ctx context.Context, c *Client) ([]Class, error) {
+	base_uri := "system/classes"
+	url_str := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri + "?depth=2&attributes=name,type,cfg_entries"
+
+	res, _, err := get(ctx, c, url_str)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, newRequestError("ListClasses", res)
+	}
+
+	payloads := map[string]classPayload{}
+	err = json.NewDecoder(res.Body).Decode(&payloads)
+	if err != nil {
+		return nil, fmt.Errorf("ListClasses: decoding classes: %w", err)
+	}
+
+	classes := []Class{}
+	for key, payload := range payloads {
+		if unescaped, err := url.PathUnescape(key); err == nil {
+			key = unescaped
+		}
+		name, class_type, _ := strings.Cut(key, ",")
+		cl := Class{
+			Name:         name,
+			Type:         class_type,
+			Entries:      decodeAclEntries(payload.CfgEntries),
+			materialized: true,
+		}
+		cl.uri = "/rest/" + c.Version + "/" + base_uri + "/" + cl.classKey()
+		classes = append(classes, cl)
+	}
+
+	sort.Slice(classes, func(x, y int) bool {
+		if classes[x].Name != classes[y].Name {
+			return classes[x].Name < classes[y].Name
+		}
+		return classes[x].Type < classes[y].Type
+	})
+
+	return classes, nil
+}
@@ -0,0 +1,92 @@
+package aoscxgo_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aruba/aoscxgo"
+)
+
+func TestClassCRUD(t *testing.T) {
+	_, c := connect(t)
+	ctx := context.Background()
+	versions := cfgVersions(c, "cfg_version")
+
+	voice := aoscxgo.Class{Name: "voice", Type: "ipv4", Entries: []aoscxgo.AclEntry{
+		{Sequence: 10, Action: "match", Protocol: "udp", DestinationPort: "16384-32767"},
+	}}
+	err := voice.Create(ctx, c)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	voice.Entries = []aoscxgo.AclEntry{
+		{Sequence: 10, Action: "ignore", Destination: "10.0.0.1"},
+		{Sequence: 20, Action: "match", Dscp: "EF"},
+	}
+	err = voice.Update(ctx, c)
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	got := aoscxgo.Class{Name: "voice", Type: "ipv4"}
+	err = got.Get(ctx, c)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if len(got.Entries) != 2 || got.Entries[0] != (aoscxgo.AclEntry{Sequence: 10, Action: "ignore", Destination: "10.0.0.1/32"}) ||
+		got.Entries[1] != (aoscxgo.AclEntry{Sequence: 20, Action: "match", Dscp: "46"}) {
+		t.Errorf("Get = %+v", got.Entries)
+	}
+	if len(*versions) != 2 || (*versions)[0] >= (*versions)[1] {
+		t.Errorf("cfg_version sent = %v, want ascending", *versions)
+	}
+
+	err = voice.Delete(ctx, c)
+	if err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	err = got.Get(ctx, c)
+	if !errors.Is(err, aoscxgo.ErrNotFound) {
+		t.Errorf("Get after Delete = %v, want ErrNotFound", err)
+	}
+
+	for _, invalid := range []aoscxgo.Class{
+		{Name: "voice", Type: "ip"},
+		{Name: "voice", Type: "ipv4", Entries: []aoscxgo.AclEntry{{Sequence: 1, Action: "permit"}}},
+		{Name: "voice", Type: "ipv4", Entries: []aoscxgo.AclEntry{{Sequence: 1, Action: "match", Log: true}}},
+	} {
+		err := invalid.Create(ctx, c)
+		if !errors.Is(err, aoscxgo.ErrValidation) {
+			t.Errorf("Create(%+v) = %v, want ErrValidation", invalid, err)
+		}
+	}
+}
+
+func TestListClasses(t *testing.T) {
+	srv, c := connect(t)
+	ctx := context.Background()
+
+	cl := aoscxgo.Class{Name: "video", Type: "ipv6", Entries: []aoscxgo.AclEntry{{Sequence: 1, Action: "match"}}}
+	err := cl.Create(ctx, c)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	// The switch escapes the names in the keys of the classes
+	srv.SetObject("system/classes", "best%20effort,ipv4", map[string]interface{}{
+		"name":        "best effort",
+		"type":        "ipv4",
+		"cfg_entries": map[string]interface{}{"1": map[string]interface{}{"type": "match"}},
+	})
+
+	classes, err := aoscxgo.ListClasses(ctx, c)
+	if err != nil {
+		t.Fatalf("ListClasses: %v", err)
+	}
+	if len(classes) != 2 || classes[0].Name != "best effort" || classes[0].Type != "ipv4" ||
+		len(classes[0].Entries) != 1 || classes[0].Entries[0].Action != "match" || classes[1].Name != "video" ||
+		classes[0].GetURI() != "/rest/"+c.Version+"/system/classes/best%20effort,ipv4" {
+		t.Errorf("ListClasses = %+v", classes)
+	}
+}
@@ -0,0 +1,382 @@
+package aoscxgo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+
+	"golang.org/x/exp/slices"
+)
+
+// QosDscpMapEntry maps a DSCP code point of trusted traffic to a local
+// priority and color. The switch holds an entry for each code point 0-63,
+// so entries are retrieved and updated but not created or deleted.
+type QosDscpMapEntry struct {
+
+	// Connection properties.
+	CodePoint     int `json:"code_point"`
+	LocalPriority int `json:"local_priority"`
+	// Color is "green", "yellow" or "red", defaults to "green".
+	Color       string `json:"color"`
+	Description string `json:"description"`
+
+	QosDscpMapEntryDetails map[string]interface{} `json:"details"`
+	materialized           bool
+	uri                    string
+}
+
+// QosCosMapEntry maps an 802.1p priority (CoS) of trusted traffic to a local
+// priority and color. The switch holds an entry for each code point 0-7, so
+// entries are retrieved and updated but not created or deleted.
+type QosCosMapEntry struct {
+
+	// Connection properties.
+	CodePoint     int `json:"code_point"`
+	LocalPriority int `json:"local_priority"`
+	// Color is "green", "yellow" or "red", defaults to "green".
+	Color       string `json:"color"`
+	Description string `json:"description"`
+
+	QosCosMapEntryDetails map[string]interface{} `json:"details"`
+	materialized          bool
+	uri                   string
+}
+
+// qosMapEntryPayload is an entry of a DSCP or CoS map as returned by the
+// switch.
+type qosMapEntryPayload struct {
+	LocalPriority int    `json:"local_priority"`
+	Color         string `json:"color"`
+	Description   string `json:"description"`
+}
+
+// checkQosMapEntry validates an entry of a DSCP or CoS map with code points
+// up to max_code_point and sets its default color.
+func checkQosMapEntry(op string, code_point int, max_code_point int, local_priority int, color *string) error {
+	if code_point < 0 || code_point > max_code_point {
+		return newValidationError(op, "CodePoint", "valid range is 0-"+strconv.Itoa(max_code_point)+" received: "+strconv.Itoa(code_point))
+	}
+	if local_priority < 0 || local_priority > 7 {
+		return newValidationError(op, "LocalPriority", "valid range is 0-7 received: "+strconv.Itoa(local_priority))
+	}
+	if *color == "" {
+		*color = "green"
+	}
+	if !slices.Contains([]string{"green", "yellow", "red"}, *color) {
+		return newValidationError(op, "Color", "valid options are 'green', 'yellow' or 'red' received: "+*color)
+	}
+	return nil
+}
+
+// updateQosMapEntry performs PUT to update the entry at url of a DSCP or
+// CoS map.
+func updateQosMapEntry(ctx context.Context, c *Client, op string, url string, local_priority int, color string, description string) error {
+	putMap := map[string]interface{}{
+		"local_priority": local_priority,
+		"color":          color,
+		"description":    description,
+	}
+
+	putBody, _ := json.Marshal(putMap)
+
+	json_body := bytes.NewBuffer(putBody)
+
+	res, err := put(ctx, c, url, json_body)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return newRequestError(op, res)
+	}
+
+	return nil
+}
+
+// getQosMapEntry performs GET to retrieve the entry at url of a DSCP or CoS
+// map.
+func getQosMapEntry(ctx context.Context, c *Client, op string, url string) (qosMapEntryPayload, map[string]interface{}, error) {
+	payload := qosMapEntryPayload{}
+
+	res, body, err := get(ctx, c, url)
+	if err != nil {
+		return payload, nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return payload, nil, newRequestError(op, res)
+	}
+
+	err = json.NewDecoder(res.Body).Decode(&payload)
+	if err != nil {
+		return payload, nil, fmt.Errorf("%s: decoding map entry: %w", op, err)
+	}
+
+	return payload, body, nil
+}
+
+// listQosMap performs GET to retrieve all entries of the DSCP or CoS map at
+// url, keyed by code point.
+func listQosMap(ctx context.Context, c *Client, op string, url string) (map[int]qosMapEntryPayload, []int, error) {
+	res, _, err := get(ctx, c, url+"?depth=2&attributes=local_priority,color,description")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, nil, newRequestError(op, res)
+	}
+
+	payloads := map[string]qosMapEntryPayload{}
+	err = json.NewDecoder(res.Body).Decode(&payloads)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: decoding map entries: %w", op, err)
+	}
+
+	entries := map[int]qosMapEntryPayload{}
+	code_points := []int{}
+	for code_point_str, payload := range payloads {
+		code_point, _ := strconv.Atoi(code_point_str)
+		entries[code_point] = payload
+		code_points = append(code_points, code_point)
+	}
+	sort.Ints(code_points)
+
+	return entries, code_points, nil
+}
+
+// Update performs PUT to update the DSCP map entry on the given Client
+// object.
+func (m *QosDscpMapEntry) Update(ctx context.Context, c *Client) error {
+	err := checkQosMapEntry("QosDscpMapEntry.Update", m.CodePoint, 63, m.LocalPriority, &m.Color)
+	if err != nil {
+		return err
+	}
+
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/system/qos_dscp_map_entries/" + strconv.Itoa(m.CodePoint)
+
+	return updateQosMapEntry(ctx, c, "QosDscpMapEntry.Update", url, m.LocalPriority, m.Color, m.Description)
+}
+
+// Get performs GET to retrieve the DSCP map entry from the given Client
+// object.
+func (m *QosDscpMapEntry) Get(ctx context.Context, c *Client) error {
+	base_uri := "system/qos_dscp_map_entries"
+	m.uri = "/rest/" + c.Version + "/" + base_uri + "/" + strconv.Itoa(m.CodePoint)
+
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri + "/" + strconv.Itoa(m.CodePoint)
+
+	payload, body, err := getQosMapEntry(ctx, c, "QosDscpMapEntry.Get", url)
+	if err != nil {
+		m.materialized = false
+		return err
+	}
+
+	m.LocalPriority = payload.LocalPriority
+	m.Color = payload.Color
+	m.Description = payload.Description
+
+	if m.QosDscpMapEntryDetails == nil {
+		m.QosDscpMapEntryDetails = map[string]interface{}{}
+	}
+	for key, value := range body {
+		m.QosDscpMapEntryDetails[key] = value
+	}
+
+	m.materialized = true
+
+	return nil
+}
+
+// GetStatus returns True if the DSCP map entry exists on Client object or
+// False if not.
+func (m *QosDscpMapEntry) GetStatus() bool {
+	return m.materialized
+}
+
+// GetURI returns URI of the DSCP map entry.
+func (m *QosDscpMapEntry) GetURI() string {
+	return m.uri
+}
+
+// ListQosDscpMap performs GET to retrieve all entries of the DSCP map from
+// the given Client object, sorted by code point.
+func ListQosDscpMap(ctx context.Context, c *Client) ([]QosDscpMapEntry, error) {
+	base_uri := "system/qos_dscp_map_entries"
+	url_str := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri
+
+	payloads, code_points, err := listQosMap(ctx, c, "ListQosDscpMap", url_str)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []QosDscpMapEntry{}
+	for _, code_point := range code_points {
+		entries = append(entries, QosDscpMapEntry{
+			CodePoint:     code_point,
+			LocalPriority: payloads[code_point].LocalPriority,
+			Color:         payloads[code_point].Color,
+			Description:   payloads[code_point].Description,
+			materialized:  true,
+			uri:           "/rest/" + c.Version + "/" + base_uri + "/" + strconv.Itoa(code_point),
+		})
+	}
+
+	return entries, nil
+}
+
+// Update performs PUT to update the CoS map entry on the given Client
+// object.
+func (m *QosCosMapEntry) Update(ctx context.Context, c *Client) error {
+	err := checkQosMapEntry("QosCosMapEntry.Update", m.CodePoint, 7, m.LocalPriority, &m.Color)
+	if err != nil {
+		return err
+	}
+
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/system/qos_cos_map_entries/" + strconv.Itoa(m.CodePoint)
+
+	return updateQosMapEntry(ctx, c, "QosCosMapEntry.Update", url, m.LocalPriority, m.Color, m.Description)
+}
+
+// Get performs GET to retrieve the CoS map entry from the given Client
+// object.
+func (m *QosCosMapEntry) Get(ctx context.Context, c *Client) error {
+	base_uri := "system/qos_cos_map_entries"
+	m.uri = "/rest/" + c.Version + "/" + base_uri + "/" + strconv.Itoa(m.CodePoint)
+
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri + "/" + strconv.Itoa(m.CodePoint)
+
+	payload, body, err := getQosMapEntry(ctx, c, "QosCosMapEntry.Get", url)
+	if err != nil {
+		m.materialized = false
+		return err
+	}
+
+	m.LocalPriority = payload.LocalPriority
+	m.Color = payload.Color
+	m.Description = payload.Description
+
+	if m.QosCosMapEntryDetails == nil {
+		m.QosCosMapEntryDetails = map[string]interface{}{}
+	}
+	for key, value := range body {
+		m.QosCosMapEntryDetails[key] = value
+	}
+
+	m.materialized = true
+
+	return nil
+}
+
+// GetStatus returns True if the CoS map entry exists on Client object or
+// False if not.
+func (m *QosCosMapEntry) GetStatus() bool {
+	return m.materialized
+}
+
+// GetURI returns URI of the CoS map entry.
+func (m *QosCosMapEntry) GetURI() string {
+	return m.uri
+}
+
+// ListQosCosMap performs GET to retrieve all entries of the CoS map from the
+// given Client object, sorted by code point.
+func ListQosCosMap(ctx context.Context, c *Client) ([]QosCosMapEntry, error) {
+	base_uri := "system/qos_cos_map_entries"
+	url_str := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri
+
+	payloads, code_points, err := listQosMap(ctx, c, "ListQosCosMap", url_str)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []QosCosMapEntry{}
+	for _, code_point := range code_points {
+		entries = append(entries, QosCosMapEntry{
+			CodePoint:     code_point,
+			LocalPriority: payloads[code_point].LocalPriority,
+			Color:         payloads[code_point].Color,
+			Description:   payloads[code_point].Description,
+			materialized:  true,
+			uri:           "/rest/" + c.Version + "/" + base_uri + "/" + strconv.Itoa(code_point),
+		})
+	}
+
+	return entries, nil
+}
+
+// checkQosTrust validates a trust setting, "none", "cos" or "dscp".
+func checkQosTrust(op string, trust string) error {
+	if !slices.Contains([]string{"none", "cos", "dscp"}, trust) {
+		return newValidationError(op, "trust", "valid options are 'none', 'cos' or 'dscp' received: "+trust)
+	}
+	return nil
+}
+
+// setQosTrust performs GET of the qos_config of the row at url and PATCH to
+// set its qos_trust to trust or, if trust is empty, remove it, keeping the
+// other QoS settings of the row.
+func setQosTrust(ctx context.Context, c *Client, op string, url string, trust string) error {
+	res, body, err := get(ctx, c, url+"?attributes=qos_config")
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return newRequestError(op, res)
+	}
+
+	qos_config := map[string]interface{}{}
+	if current, ok := body["qos_config"].(map[string]interface{}); ok {
+		for key, value := range current {
+			if key != "qos_trust" {
+				qos_config[key] = value
+			}
+		}
+	}
+	if trust != "" {
+		qos_config["qos_trust"] = trust
+	}
+
+	return patchQos(ctx, c, op, url, map[string]interface{}{
+		"qos_config": qos_config,
+	})
+}
+
+// SetQosTrust performs GET and PATCH to set which priority of received
+// traffic, "none", "cos" or "dscp", all ports of the given Client object
+// trust to assign its local priority. The other settings of qos_config are
+// kept.
+func SetQosTrust(ctx context.Context, c *Client, trust string) error {
+	err := checkQosTrust("SetQosTrust", trust)
+	if err != nil {
+		return err
+	}
+
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/system"
+
+	return setQosTrust(ctx, c, "SetQosTrust", url, trust)
+}
+
+// SetQosTrust performs GET and PATCH to set which priority of traffic
+// received by the Interface on the given Client object, "none", "cos" or
+// "dscp", is trusted, overriding the setting of all ports, or with an empty
+// trust to remove the override. The other settings of qos_config are kept.
+func (i *Interface) SetQosTrust(ctx context.Context, c *Client, trust string) error {
+	if trust != "" {
+		err := checkQosTrust("Interface.SetQosTrust", trust)
+		if err != nil {
+			return err
+		}
+	}
+
+	int_str := url.PathEscape(i.Name)
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/system/interfaces/" + int_str
+
+	return setQosTrust(ctx, c, "Interface.SetQosTrust", url, trust)
+}
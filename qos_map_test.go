@@ -0,0 +1,90 @@
+package aoscxgo_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/aruba/aoscxgo"
+)
+
+func TestQosDscpMap(t *testing.T) {
+	_, c := connect(t)
+	ctx := context.Background()
+
+	entry := aoscxgo.QosDscpMapEntry{CodePoint: 46, LocalPriority: 6, Description: "voice"}
+	err := entry.Update(ctx, c)
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	entries, err := aoscxgo.ListQosDscpMap(ctx, c)
+	if err != nil {
+		t.Fatalf("ListQosDscpMap: %v", err)
+	}
+	if len(entries) != 64 || entries[46].LocalPriority != 6 || entries[46].Color != "green" || entries[46].Description != "voice" {
+		t.Errorf("ListQosDscpMap[46] = %+v", entries[46])
+	}
+
+	invalid := aoscxgo.QosDscpMapEntry{CodePoint: 64}
+	err = invalid.Update(ctx, c)
+	if !errors.Is(err, aoscxgo.ErrValidation) {
+		t.Errorf("Update of code point 64 = %v, want ErrValidation", err)
+	}
+}
+
+func TestSetQosTrust(t *testing.T) {
+	srv, c := connect(t)
+	ctx := context.Background()
+	srv.AddInterface("1/1/1")
+
+	var patches []string
+	c.RequestHook = func(ctx context.Context, info *aoscxgo.RequestInfo) {
+		if info.Method == "PATCH" {
+			patches = append(patches, info.Body)
+		}
+	}
+
+	// Other settings of qos_config are kept
+	i := aoscxgo.Interface{Name: "1/1/1"}
+	obj, _ := srv.Object("system/interfaces", "1/1/1")
+	obj["qos_config"] = map[string]interface{}{"qos_trust": "cos", "qos_queueing": "strict"}
+	srv.SetObject("system/interfaces", "1/1/1", obj)
+
+	err := i.SetQosTrust(ctx, c, "dscp")
+	if err != nil {
+		t.Fatalf("Interface.SetQosTrust: %v", err)
+	}
+	obj, _ = srv.Object("system/interfaces", "1/1/1")
+	want := map[string]interface{}{"qos_trust": "dscp", "qos_queueing": "strict"}
+	if !reflect.DeepEqual(obj["qos_config"], want) {
+		t.Errorf("qos_config = %v, want %v", obj["qos_config"], want)
+	}
+
+	err = i.SetQosTrust(ctx, c, "")
+	if err != nil {
+		t.Fatalf("Interface.SetQosTrust to remove: %v", err)
+	}
+	obj, _ = srv.Object("system/interfaces", "1/1/1")
+	want = map[string]interface{}{"qos_queueing": "strict"}
+	if !reflect.DeepEqual(obj["qos_config"], want) {
+		t.Errorf("qos_config after removing = %v, want %v", obj["qos_config"], want)
+	}
+
+	err = aoscxgo.SetQosTrust(ctx, c, "cos")
+	if err != nil {
+		t.Fatalf("SetQosTrust: %v", err)
+	}
+	if got := srv.System()["qos_config"]; !reflect.DeepEqual(got, map[string]interface{}{"qos_trust": "cos"}) {
+		t.Errorf("system qos_config = %v", got)
+	}
+	if len(patches) != 3 {
+		t.Errorf("sent %d PATCH requests, want 3", len(patches))
+	}
+
+	err = aoscxgo.SetQosTrust(ctx, c, "ip-precedence")
+	if !errors.Is(err, aoscxgo.ErrValidation) {
+		t.Errorf("SetQosTrust(ip-precedence) = %v, want ErrValidation", err)
+	}
+}
@@ -0,0 +1,523 @@
+package aoscxgo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/exp/slices"
+)
+
+// Policy is a classifier policy applying QoS actions to the traffic matched
+// by its classes, in order of the Sequence of its entries. Update replaces
+// all entries in a single request. ApplyPolicy of Interface and Vlan bind it
+// to traffic, SetQosTrust sets which received priority is trusted:
+//
+//	voice := aoscxgo.Class{Name: "VOICE", Type: "ipv4", Entries: []aoscxgo.AclEntry{
+//		{Sequence: 10, Action: "match", Protocol: "udp", DestinationPort: "16384-32767"},
+//	}}
+//	err = voice.Create(ctx, sw)
+//	policy := aoscxgo.Policy{Name: "ACCESS", Entries: []aoscxgo.PolicyEntry{
+//		{Sequence: 10, Class: "VOICE", RemarkDscp: "EF", LocalPriority: "6", PoliceRate: 2000},
+//	}}
+//	err = policy.Create(ctx, sw)
+//	access := aoscxgo.Interface{Name: "1/1/1"}
+//	err = access.ApplyPolicy(ctx, sw, &policy, "in")
+//	err = access.SetQosTrust(ctx, sw, "dscp")
+type Policy struct {
+
+	// Connection properties.
+	Name    string        `json:"name"`
+	Entries []PolicyEntry `json:"entries"`
+
+	PolicyDetails map[string]interface{} `json:"details"`
+	materialized  bool
+	uri           string
+}
+
+// PolicyEntry applies actions to the traffic matched by a Class. At least
+// one action is required.
+type PolicyEntry struct {
+	// Sequence orders the entries, 1-4294967295.
+	Sequence int64 `json:"sequence"`
+	// Class names the Class matched by the entry.
+	Class string `json:"class"`
+	// ClassType is the Type of the Class, defaults to "ipv4".
+	ClassType string `json:"class_type"`
+	// RemarkDscp remarks the DSCP, a value 0-63 or name such as "EF",
+	// unchanged if empty.
+	RemarkDscp string `json:"remark_dscp"`
+	// RemarkPcp remarks the 802.1p priority 0-7, unchanged if empty.
+	RemarkPcp string `json:"remark_pcp"`
+	// LocalPriority queues the traffic with local priority 0-7, unchanged if
+	// empty.
+	LocalPriority string `json:"local_priority"`
+	// PoliceRate drops traffic exceeding the rate in kbps, not policed if
+	// zero. PoliceBurst is the burst size in KB.
+	PoliceRate  int    `json:"police_rate"`
+	PoliceBurst int    `json:"police_burst"`
+	Comment     string `json:"comment"`
+}
+
+// checkValues validates the policy and normalizes its entries as returned by
+// Get, sorted by Sequence. op names the calling operation in the returned
+// error.
+func (p *Policy) checkValues(op string) error {
+	if p.Name == "" || strings.Contains(p.Name, "/") {
+		return newValidationError(op, "Name", "invalid policy name: "+p.Name)
+	}
+
+	seen := map[int64]bool{}
+	for index := range p.Entries {
+		entry := &p.Entries[index]
+		sequence_str := strconv.FormatInt(entry.Sequence, 10)
+
+		if entry.Sequence < 1 || entry.Sequence > 4294967295 {
+			return newValidationError(op, "Sequence", "valid range is 1-4294967295 received: "+sequence_str)
+		}
+		if seen[entry.Sequence] {
+			return newValidationError(op, "Sequence", "duplicate sequence "+sequence_str)
+		}
+		seen[entry.Sequence] = true
+
+		if entry.Class == "" {
+			return newValidationError(op, "Class", "missing class in entry "+sequence_str)
+		}
+		if entry.ClassType == "" {
+			entry.ClassType = "ipv4"
+		}
+		if !slices.Contains([]string{"ipv4", "ipv6", "mac"}, entry.ClassType) {
+			return newValidationError(op, "ClassType", "valid options are 'ipv4', 'ipv6' or 'mac' received: "+entry.ClassType+" in entry "+sequence_str)
+		}
+
+		if entry.RemarkDscp == "" && entry.RemarkPcp == "" && entry.LocalPriority == "" && entry.PoliceRate == 0 {
+			return newValidationError(op, "Entries", "missing action in entry "+sequence_str)
+		}
+		if entry.RemarkDscp != "" {
+			value, ok := parseDscp(entry.RemarkDscp)
+			if !ok {
+				return newValidationError(op, "RemarkDscp", "invalid DSCP "+entry.RemarkDscp+" in entry "+sequence_str)
+			}
+			entry.RemarkDscp = strconv.Itoa(value)
+		}
+		for _, field := range [][2]string{{"RemarkPcp", entry.RemarkPcp}, {"LocalPriority", entry.LocalPriority}} {
+			if field[1] == "" {
+				continue
+			}
+			value, err := strconv.Atoi(field[1])
+			if err != nil || value < 0 || value > 7 {
+				return newValidationError(op, field[0], "valid range is 0-7 received: "+field[1]+" in entry "+sequence_str)
+			}
+		}
+		if entry.PoliceRate < 0 || entry.PoliceBurst < 0 {
+			return newValidationError(op, "PoliceRate", "negative rate or burst in entry "+sequence_str)
+		}
+		if entry.PoliceBurst != 0 && entry.PoliceRate == 0 {
+			return newValidationError(op, "PoliceBurst", "requires PoliceRate in entry "+sequence_str)
+		}
+	}
+
+	sort.Slice(p.Entries, func(x, y int) bool {
+		return p.Entries[x].Sequence < p.Entries[y].Sequence
+	})
+	return nil
+}
+
+// checkClasses verifies the classes of the entries exist on the given
+// Client object. A missing class is reported as *DependencyError, other
+// errors are returned unchanged. op names the calling operation in the
+// returned error.
+func (p *Policy) checkClasses(ctx context.Context, c *Client, op string) error {
+	checked := map[string]bool{}
+	for _, entry := range p.Entries {
+		class_key := entry.Class + "," + entry.ClassType
+		if checked[class_key] {
+			continue
+		}
+		cl := Class{Name: entry.Class, Type: entry.ClassType}
+		err := cl.Get(ctx, c)
+		if errors.Is(err, ErrNotFound) {
+			return newDependencyError(op, "missing "+entry.ClassType+" Class "+entry.Class+" - Create Class before Policy", err)
+		}
+		if err != nil {
+			return err
+		}
+		checked[class_key] = true
+	}
+	return nil
+}
+
+// entriesMap returns the entries as sent to the switch, keyed by sequence.
+// Classes are referenced by their URI for the REST version of c.
+func (p *Policy) entriesMap(c *Client) map[string]interface{} {
+	entries := map[string]interface{}{}
+	for _, entry := range p.Entries {
+		action_set := map[string]interface{}{}
+		if entry.RemarkDscp != "" {
+			action_set["dscp"], _ = strconv.Atoi(entry.RemarkDscp)
+		}
+		if entry.RemarkPcp != "" {
+			action_set["pcp"], _ = strconv.Atoi(entry.RemarkPcp)
+		}
+		if entry.LocalPriority != "" {
+			action_set["local_priority"], _ = strconv.Atoi(entry.LocalPriority)
+		}
+		if entry.PoliceRate != 0 {
+			action_set["cir"] = entry.PoliceRate
+			action_set["exceed_action"] = "drop"
+			if entry.PoliceBurst != 0 {
+				action_set["cbs"] = entry.PoliceBurst
+			}
+		}
+
+		policy_entry := map[string]interface{}{
+			"class":      "/rest/" + c.Version + "/system/classes/" + url.PathEscape(entry.Class) + "," + entry.ClassType,
+			"action_set": action_set,
+		}
+		if entry.Comment != "" {
+			policy_entry["comment"] = entry.Comment
+		}
+		entries[strconv.FormatInt(entry.Sequence, 10)] = policy_entry
+	}
+	return entries
+}
+
+// Create performs POST to create the policy with its entries on the given
+// Client object, after checking their classes exist.
+func (p *Policy) Create(ctx context.Context, c *Client) error {
+	base_uri := "system/policies"
+
+	err := p.checkValues("Policy.Create")
+	if err != nil {
+		return err
+	}
+
+	err = p.checkClasses(ctx, c, "Policy.Create")
+	if err != nil {
+		return err
+	}
+
+	policy_str := url.PathEscape(p.Name)
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri
+	p.uri = "/rest/" + c.Version + "/" + base_uri + "/" + policy_str
+
+	postMap := map[string]interface{}{
+		"name":        p.Name,
+		"cfg_entries": p.entriesMap(c),
+		"cfg_version": nextCfgVersion(),
+	}
+
+	postBody, _ := json.Marshal(postMap)
+
+	json_body := bytes.NewBuffer(postBody)
+
+	res, err := post(ctx, c, url, json_body)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusCreated {
+		return newRequestError("Policy.Create", res)
+	}
+
+	p.materialized = true
+
+	return nil
+}
+
+// Update performs PUT to replace all entries of the policy on the given
+// Client object at once, after checking their classes exist.
+func (p *Policy) Update(ctx context.Context, c *Client) error {
+	base_uri := "system/policies"
+
+	err := p.checkValues("Policy.Update")
+	if err != nil {
+		return err
+	}
+
+	err = p.checkClasses(ctx, c, "Policy.Update")
+	if err != nil {
+		return err
+	}
+
+	policy_str := url.PathEscape(p.Name)
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri + "/" + policy_str
+
+	putMap := map[string]interface{}{
+		"cfg_entries": p.entriesMap(c),
+		"cfg_version": nextCfgVersion(),
+	}
+
+	putBody, _ := json.Marshal(putMap)
+
+	json_body := bytes.NewBuffer(putBody)
+
+	res, err := put(ctx, c, url, json_body)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return newRequestError("Policy.Update", res)
+	}
+
+	return nil
+}
+
+// Delete performs DELETE to remove the policy from the given Client object.
+// The switch refuses to delete a policy that is applied.
+func (p *Policy) Delete(ctx context.Context, c *Client) error {
+	base_uri := "system/policies"
+
+	policy_str := url.PathEscape(p.Name)
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri + "/" + policy_str
+
+	res, err := delete(ctx, c, url)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusNoContent && res.StatusCode != http.StatusOK {
+		return newRequestError("Policy.Delete", res)
+	}
+
+	p.materialized = false
+
+	return nil
+}
+
+// Get performs GET to retrieve the policy with its entries from the given
+// Client object.
+func (p *Policy) Get(ctx context.Context, c *Client) error {
+	base_uri := "system/policies"
+	policy_str := url.PathEscape(p.Name)
+	p.uri = "/rest/" + c.Version + "/" + base_uri + "/" + policy_str
+
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri + "/" + policy_str + "?depth=2"
+
+	res, body, err := get(ctx, c, url)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		p.materialized = false
+		return newRequestError("Policy.Get", res)
+	}
+
+	payload := policyPayload{}
+	err = json.NewDecoder(res.Body).Decode(&payload)
+	if err != nil {
+		return fmt.Errorf("Policy.Get: decoding %s: %w", p.Name, err)
+	}
+	payload.decode(p)
+
+	if p.PolicyDetails == nil {
+		p.PolicyDetails = map[string]interface{}{}
+	}
+	for key, value := range body {
+		p.PolicyDetails[key] = value
+	}
+
+	p.materialized = true
+
+	return nil
+}
+
+// GetStatus returns True if the policy exists on Client object or False if
+// not.
+func (p *Policy) GetStatus() bool {
+	return p.materialized
+}
+
+// GetURI returns URI of the policy.
+func (p *Policy) GetURI() string {
+	return p.uri
+}
+
+// policyEntryPayload is an entry of a policy as returned by the switch.
+type policyEntryPayload struct {
+	Class     string `json:"class"`
+	Comment   string `json:"comment"`
+	ActionSet struct {
+		Dscp          *int `json:"dscp"`
+		Pcp           *int `json:"pcp"`
+		LocalPriority *int `json:"local_priority"`
+		Cir           int  `json:"cir"`
+		Cbs           int  `json:"cbs"`
+	} `json:"action_set"`
+}
+
+// policyPayload is the policy as returned by the switch.
+type policyPayload struct {
+	CfgEntries map[string]policyEntryPayload `json:"cfg_entries"`
+}
+
+// decode sets the entries of p from the payload, sorted by Sequence.
+func (payload *policyPayload) decode(p *Policy) {
+	p.Entries = []PolicyEntry{}
+	for sequence_str, policy_entry := range payload.CfgEntries {
+		entry := PolicyEntry{
+			PoliceRate:  policy_entry.ActionSet.Cir,
+			PoliceBurst: policy_entry.ActionSet.Cbs,
+			Comment:     policy_entry.Comment,
+		}
+		entry.Sequence, _ = strconv.ParseInt(sequence_str, 10, 64)
+
+		class_key := policy_entry.Class[strings.LastIndex(policy_entry.Class, "/")+1:]
+		if unescaped, err := url.PathUnescape(class_key); err == nil {
+			class_key = unescaped
+		}
+		entry.Class, entry.ClassType, _ = strings.Cut(class_key, ",")
+
+		if policy_entry.ActionSet.Dscp != nil {
+			entry.RemarkDscp = strconv.Itoa(*policy_entry.ActionSet.Dscp)
+		}
+		if policy_entry.ActionSet.Pcp != nil {
+			entry.RemarkPcp = strconv.Itoa(*policy_entry.ActionSet.Pcp)
+		}
+		if policy_entry.ActionSet.LocalPriority != nil {
+			entry.LocalPriority = strconv.Itoa(*policy_entry.ActionSet.LocalPriority)
+		}
+
+		p.Entries = append(p.Entries, entry)
+	}
+
+	sort.Slice(p.Entries, func(x, y int) bool {
+		return p.Entries[x].Sequence < p.Entries[y].Sequence
+	})
+}
+
+// ListPolicies performs GET to retrieve all policies with their entries from
+// the given Client object in a single request, sorted by name.
+func ListPolicies(ctx context.Context, c *Client) ([]Policy, error) {
+	base_uri := "system/policies"
+	url_str := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri + "?depth=2&attributes=name,cfg_entries"
+
+	res, _, err := get(ctx, c, url_str)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, newRequestError("ListPolicies", res)
+	}
+
+	payloads := map[string]policyPayload{}
+	err = json.NewDecoder(res.Body).Decode(&payloads)
+	if err != nil {
+		return nil, fmt.Errorf("ListPolicies: decoding policies: %w", err)
+	}
+
+	policies := []Policy{}
+	for key, payload := range payloads {
+		p := Policy{
+			Name:         key,
+			materialized: true,
+		}
+		if unescaped, err := url.PathUnescape(key); err == nil {
+			p.Name = unescaped
+		}
+		payload.decode(&p)
+		p.uri = "/rest/" + c.Version + "/" + base_uri + "/" + url.PathEscape(p.Name)
+		policies = append(policies, p)
+	}
+
+	sort.Slice(policies, func(x, y int) bool {
+		return policies[x].Name < policies[y].Name
+	})
+
+	return policies, nil
+}
+
+// policyAttribute returns the attribute applying a policy in the given
+// direction, "in" or "out".
+func policyAttribute(op string, direction string) (string, error) {
+	if direction != "in" && direction != "out" {
+		return "", newValidationError(op, "direction", "valid options are 'in' or 'out' received: "+direction)
+	}
+	return "policy_" + direction + "_cfg", nil
+}
+
+// applyPolicy performs PATCH to set or, if policy is nil, clear the policy
+// applied by attribute on the row at url.
+func applyPolicy(ctx context.Context, c *Client, op string, url string, attribute string, policy *Policy) error {
+	uri := ""
+	if policy != nil {
+		// Check a copy, Get would replace the entries of the caller
+		current := Policy{Name: policy.Name}
+		err := current.Get(ctx, c)
+		if errors.Is(err, ErrNotFound) {
+			return newDependencyError(op, "missing Policy "+policy.Name+" - Create Policy first", err)
+		}
+		if err != nil {
+			return err
+		}
+		uri = current.GetURI()
+	}
+
+	return patchCfgReference(ctx, c, op, url, attribute, uri)
+}
+
+// ApplyPolicy performs PATCH to apply the policy to the traffic received,
+// direction "in", or sent, direction "out", by the Interface on the given
+// Client object. It replaces any policy applied in that direction.
+func (i *Interface) ApplyPolicy(ctx context.Context, c *Client, policy *Policy, direction string) error {
+	attribute, err := policyAttribute("Interface.ApplyPolicy", direction)
+	if err != nil {
+		return err
+	}
+
+	int_str := url.PathEscape(i.Name)
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/system/interfaces/" + int_str
+
+	return applyPolicy(ctx, c, "Interface.ApplyPolicy", url, attribute, policy)
+}
+
+// UnapplyPolicy performs PATCH to remove the policy applied in direction
+// "in" or "out" from the Interface on the given Client object.
+func (i *Interface) UnapplyPolicy(ctx context.Context, c *Client, direction string) error {
+	attribute, err := policyAttribute("Interface.UnapplyPolicy", direction)
+	if err != nil {
+		return err
+	}
+
+	int_str := url.PathEscape(i.Name)
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/system/interfaces/" + int_str
+
+	return applyPolicy(ctx, c, "Interface.UnapplyPolicy", url, attribute, nil)
+}
+
+// ApplyPolicy performs PATCH to apply the policy to the traffic of the VLAN
+// in direction "in" or "out" on the given Client object. It replaces any
+// policy applied in that direction.
+func (v *Vlan) ApplyPolicy(ctx context.Context, c *Client, policy *Policy, direction string) error {
+	attribute, err := policyAttribute("Vlan.ApplyPolicy", direction)
+	if err != nil {
+		return err
+	}
+
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/system/vlans/" + strconv.Itoa(v.VlanId)
+
+	return applyPolicy(ctx, c, "Vlan.ApplyPolicy", url, attribute, policy)
+}
+
+// UnapplyPolicy performs PATCH to remove the policy applied in direction
+// "in" or "out" from the VLAN on the given Client object.
+func (v *Vlan) UnapplyPolicy(ctx context.Context, c *Client, direction string) error {
+	attribute, err := policyAttribute("Vlan.UnapplyPolicy", direction)
+	if err != nil {
+		return err
+	}
+
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/system/vlans/" + strconv.Itoa(v.VlanId)
+
+	return applyPolicy(ctx, c, "Vlan.UnapplyPolicy", url, attribute, nil)
+}
@@ -0,0 +1,140 @@
+package aoscxgo_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aruba/aoscxgo"
+)
+
+// createClass creates the IPv4 class "voice".
+func createClass(t *testing.T, c *aoscxgo.Client) {
+	t.Helper()
+	cl := aoscxgo.Class{Name: "voice", Type: "ipv4", Entries: []aoscxgo.AclEntry{{Sequence: 10, Action: "match", Dscp: "EF"}}}
+	err := cl.Create(context.Background(), c)
+	if err != nil {
+		t.Fatalf("Class.Create: %v", err)
+	}
+}
+
+func TestPolicyCRUD(t *testing.T) {
+	_, c := connect(t)
+	ctx := context.Background()
+
+	policy := aoscxgo.Policy{Name: "access", Entries: []aoscxgo.PolicyEntry{
+		{Sequence: 10, Class: "voice", RemarkDscp: "EF", LocalPriority: "6", PoliceRate: 2000},
+	}}
+	err := policy.Create(ctx, c)
+	var dependency *aoscxgo.DependencyError
+	if !errors.As(err, &dependency) || !errors.Is(err, aoscxgo.ErrValidation) || !errors.Is(err, aoscxgo.ErrNotFound) {
+		t.Fatalf("Create with a missing class = %v, want *DependencyError", err)
+	}
+
+	// Failures other than a missing class are returned unchanged
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	err = policy.Create(canceled, c)
+	if !errors.Is(err, context.Canceled) || errors.Is(err, aoscxgo.ErrValidation) {
+		t.Errorf("Create with a canceled context = %v, want context.Canceled", err)
+	}
+
+	createClass(t, c)
+	err = policy.Create(ctx, c)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	policy.Entries = append(policy.Entries, aoscxgo.PolicyEntry{Sequence: 5, Class: "voice", RemarkPcp: "5", Comment: "first"})
+	err = policy.Update(ctx, c)
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	got := aoscxgo.Policy{Name: "access"}
+	err = got.Get(ctx, c)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	want := []aoscxgo.PolicyEntry{
+		{Sequence: 5, Class: "voice", ClassType: "ipv4", RemarkPcp: "5", Comment: "first"},
+		{Sequence: 10, Class: "voice", ClassType: "ipv4", RemarkDscp: "46", LocalPriority: "6", PoliceRate: 2000},
+	}
+	if len(got.Entries) != 2 || got.Entries[0] != want[0] || got.Entries[1] != want[1] {
+		t.Errorf("Get = %+v", got.Entries)
+	}
+
+	policies, err := aoscxgo.ListPolicies(ctx, c)
+	if err != nil {
+		t.Fatalf("ListPolicies: %v", err)
+	}
+	if len(policies) != 1 || policies[0].Name != "access" || len(policies[0].Entries) != 2 {
+		t.Errorf("ListPolicies = %+v", policies)
+	}
+
+	err = policy.Delete(ctx, c)
+	if err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	err = got.Get(ctx, c)
+	if !errors.Is(err, aoscxgo.ErrNotFound) {
+		t.Errorf("Get after Delete = %v, want ErrNotFound", err)
+	}
+
+	for _, invalid := range []aoscxgo.Policy{
+		{Name: "a/b"},
+		{Name: "access", Entries: []aoscxgo.PolicyEntry{{Sequence: 1, Class: "voice"}}},
+		{Name: "access", Entries: []aoscxgo.PolicyEntry{{Sequence: 1, Class: "voice", RemarkPcp: "8"}}},
+		{Name: "access", Entries: []aoscxgo.PolicyEntry{{Sequence: 1, Class: "voice", RemarkDscp: "XX"}}},
+		{Name: "access", Entries: []aoscxgo.PolicyEntry{{Sequence: 1, Class: "voice", LocalPriority: "1", PoliceBurst: 10}}},
+	} {
+		err := invalid.Create(ctx, c)
+		if !errors.Is(err, aoscxgo.ErrValidation) {
+			t.Errorf("Create(%+v) = %v, want ErrValidation", invalid, err)
+		}
+	}
+}
+
+func TestApplyPolicy(t *testing.T) {
+	srv, c := connect(t)
+	ctx := context.Background()
+	srv.AddInterface("1/1/1")
+	i := aoscxgo.Interface{Name: "1/1/1"}
+	createClass(t, c)
+
+	policy := aoscxgo.Policy{Name: "access", Entries: []aoscxgo.PolicyEntry{{Sequence: 10, Class: "voice", LocalPriority: "6"}}}
+	err := policy.Create(ctx, c)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	// Applying leaves the entries of the caller alone
+	desired := aoscxgo.Policy{Name: "access", Entries: []aoscxgo.PolicyEntry{{Sequence: 20, Class: "voice", LocalPriority: "7"}}}
+	err = i.ApplyPolicy(ctx, c, &desired, "in")
+	if err != nil {
+		t.Fatalf("ApplyPolicy: %v", err)
+	}
+	if len(desired.Entries) != 1 || desired.Entries[0].Sequence != 20 {
+		t.Errorf("ApplyPolicy changed the entries to %+v", desired.Entries)
+	}
+
+	obj, _ := srv.Object("system/interfaces", "1/1/1")
+	if obj["policy_in_cfg"] != "/rest/"+c.Version+"/system/policies/access" || obj["policy_in_cfg_version"] == nil {
+		t.Errorf("interface = %v", obj)
+	}
+
+	err = i.UnapplyPolicy(ctx, c, "in")
+	if err != nil {
+		t.Fatalf("UnapplyPolicy: %v", err)
+	}
+	obj, _ = srv.Object("system/interfaces", "1/1/1")
+	if obj["policy_in_cfg"] != nil {
+		t.Errorf("policy_in_cfg after UnapplyPolicy = %v", obj["policy_in_cfg"])
+	}
+
+	missing := aoscxgo.Policy{Name: "missing"}
+	err = i.ApplyPolicy(ctx, c, &missing, "in")
+	if !errors.Is(err, aoscxgo.ErrValidation) || !errors.Is(err, aoscxgo.ErrNotFound) {
+		t.Errorf("ApplyPolicy of a missing policy = %v, want ErrValidation and ErrNotFound", err)
+	}
+}
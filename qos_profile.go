@@ -0,0 +1,647 @@
+package aoscxgo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// QueueProfile maps local priorities to the egress queues of ports. It is
+// applied to all ports with ApplyQosProfiles.
+type QueueProfile struct {
+
+	// Connection properties.
+	Name   string              `json:"name"`
+	Queues []QueueProfileEntry `json:"queues"`
+
+	QueueProfileDetails map[string]interface{} `json:"details"`
+	materialized        bool
+	uri                 string
+}
+
+// QueueProfileEntry maps local priorities to a queue of a QueueProfile.
+type QueueProfileEntry struct {
+	// Queue is the queue number 0-7.
+	Queue int `json:"queue"`
+	// LocalPriorities are the local priorities 0-7 of the queue.
+	LocalPriorities []int  `json:"local_priorities"`
+	Description     string `json:"description"`
+}
+
+// ScheduleProfile sets how the egress queues of ports are serviced. It is
+// applied to all ports with ApplyQosProfiles or to a port with
+// Interface.ApplyScheduleProfile.
+type ScheduleProfile struct {
+
+	// Connection properties.
+	Name   string                 `json:"name"`
+	Queues []ScheduleProfileEntry `json:"queues"`
+
+	ScheduleProfileDetails map[string]interface{} `json:"details"`
+	materialized           bool
+	uri                    string
+}
+
+// ScheduleProfileEntry sets how a queue of a ScheduleProfile is serviced.
+type ScheduleProfileEntry struct {
+	// Queue is the queue number 0-7.
+	Queue int `json:"queue"`
+	// Algorithm is "dwrr" or "strict", defaults to "dwrr".
+	Algorithm string `json:"algorithm"`
+	// Weight is the DWRR weight 1-127, defaults to 1. Not used by strict
+	// priority queues.
+	Weight int `json:"weight"`
+}
+
+// checkQosProfileName validates the name of a queue or schedule profile.
+func checkQosProfileName(op string, name string) error {
+	if name == "" || strings.Contains(name, "/") {
+		return newValidationError(op, "Name", "invalid profile name: "+name)
+	}
+	return nil
+}
+
+// checkQueue validates a queue number, unique within a profile.
+func checkQueue(op string, queue int, seen map[int]bool) error {
+	queue_str := strconv.Itoa(queue)
+	if queue < 0 || queue > 7 {
+		return newValidationError(op, "Queue", "valid range is 0-7 received: "+queue_str)
+	}
+	if seen[queue] {
+		return newValidationError(op, "Queue", "duplicate queue "+queue_str)
+	}
+	seen[queue] = true
+	return nil
+}
+
+// checkValues validates the profile and normalizes its queues as returned by
+// Get, sorted by Queue. op names the calling operation in the returned
+// error.
+func (q *QueueProfile) checkValues(op string) error {
+	err := checkQosProfileName(op, q.Name)
+	if err != nil {
+		return err
+	}
+
+	seen_queues := map[int]bool{}
+	seen_priorities := map[int]bool{}
+	for index := range q.Queues {
+		entry := &q.Queues[index]
+
+		err := checkQueue(op, entry.Queue, seen_queues)
+		if err != nil {
+			return err
+		}
+
+		for _, priority := range entry.LocalPriorities {
+			priority_str := strconv.Itoa(priority)
+			if priority < 0 || priority > 7 {
+				return newValidationError(op, "LocalPriorities", "valid range is 0-7 received: "+priority_str+" in queue "+strconv.Itoa(entry.Queue))
+			}
+			if seen_priorities[priority] {
+				return newValidationError(op, "LocalPriorities", "local priority "+priority_str+" mapped to more than one queue")
+			}
+			seen_priorities[priority] = true
+		}
+		if entry.LocalPriorities == nil {
+			entry.LocalPriorities = []int{}
+		}
+		sort.Ints(entry.LocalPriorities)
+	}
+
+	sort.Slice(q.Queues, func(x, y int) bool {
+		return q.Queues[x].Queue < q.Queues[y].Queue
+	})
+	return nil
+}
+
+// entriesMap returns the queues as sent to the switch, keyed by queue number.
+func (q *QueueProfile) entriesMap() map[string]map[string]interface{} {
+	entries := map[string]map[string]interface{}{}
+	for _, entry := range q.Queues {
+		entries[strconv.Itoa(entry.Queue)] = map[string]interface{}{
+			"local_priorities": entry.LocalPriorities,
+			"description":      entry.Description,
+		}
+	}
+	return entries
+}
+
+// Create performs POST to create the queue profile and its queues on the
+// given Client object. If a queue cannot be created the profile is deleted
+// again.
+func (q *QueueProfile) Create(ctx context.Context, c *Client) error {
+	base_uri := "system/q_profiles"
+
+	err := q.checkValues("QueueProfile.Create")
+	if err != nil {
+		return err
+	}
+
+	profile_str := url.PathEscape(q.Name)
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri
+	q.uri = "/rest/" + c.Version + "/" + base_uri + "/" + profile_str
+
+	err = createQosProfile(ctx, c, "QueueProfile.Create", url, q.Name)
+	if err != nil {
+		return err
+	}
+
+	err = replaceQosProfileEntries(ctx, c, "QueueProfile.Create", url+"/"+profile_str+"/q_profile_entries", q.entriesMap())
+	if err != nil {
+		// Do not leave the profile behind without its queues
+		return errors.Join(err, q.Delete(ctx, c))
+	}
+
+	q.materialized = true
+
+	return nil
+}
+
+// Update performs PUT, POST and DELETE on the queues of the profile on the
+// given Client object to match Queues. The queues are changed one request at
+// a time, so the update is not atomic: on error the profile may hold some
+// queues already updated, call Update again to complete it.
+func (q *QueueProfile) Update(ctx context.Context, c *Client) error {
+	base_uri := "system/q_profiles"
+
+	err := q.checkValues("QueueProfile.Update")
+	if err != nil {
+		return err
+	}
+
+	profile_str := url.PathEscape(q.Name)
+	entries_url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri + "/" + profile_str + "/q_profile_entries"
+
+	return replaceQosProfileEntries(ctx, c, "QueueProfile.Update", entries_url, q.entriesMap())
+}
+
+// Delete performs DELETE to remove the queue profile from the given Client
+// object. The switch refuses to delete a profile that is applied.
+func (q *QueueProfile) Delete(ctx context.Context, c *Client) error {
+	base_uri := "system/q_profiles"
+
+	profile_str := url.PathEscape(q.Name)
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri + "/" + profile_str
+
+	res, err := delete(ctx, c, url)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusNoContent && res.StatusCode != http.StatusOK {
+		return newRequestError("QueueProfile.Delete", res)
+	}
+
+	q.materialized = false
+
+	return nil
+}
+
+// Get performs GET to retrieve the queue profile with its queues from the
+// given Client object.
+func (q *QueueProfile) Get(ctx context.Context, c *Client) error {
+	base_uri := "system/q_profiles"
+	profile_str := url.PathEscape(q.Name)
+	q.uri = "/rest/" + c.Version + "/" + base_uri + "/" + profile_str
+
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri + "/" + profile_str
+
+	res, body, err := get(ctx, c, url)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		q.materialized = false
+		return newRequestError("QueueProfile.Get", res)
+	}
+
+	res, _, err = get(ctx, c, url+"/q_profile_entries?depth=2")
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return newRequestError("QueueProfile.Get", res)
+	}
+
+	payloads := map[string]struct {
+		LocalPriorities []int  `json:"local_priorities"`
+		Description     string `json:"description"`
+	}{}
+	err = json.NewDecoder(res.Body).Decode(&payloads)
+	if err != nil {
+		return fmt.Errorf("QueueProfile.Get: decoding queues of %s: %w", q.Name, err)
+	}
+
+	q.Queues = []QueueProfileEntry{}
+	for queue_str, payload := range payloads {
+		entry := QueueProfileEntry{
+			LocalPriorities: payload.LocalPriorities,
+			Description:     payload.Description,
+		}
+		entry.Queue, _ = strconv.Atoi(queue_str)
+		if entry.LocalPriorities == nil {
+			entry.LocalPriorities = []int{}
+		}
+		sort.Ints(entry.LocalPriorities)
+		q.Queues = append(q.Queues, entry)
+	}
+	sort.Slice(q.Queues, func(x, y int) bool {
+		return q.Queues[x].Queue < q.Queues[y].Queue
+	})
+
+	if q.QueueProfileDetails == nil {
+		q.QueueProfileDetails = map[string]interface{}{}
+	}
+	for key, value := range body {
+		q.QueueProfileDetails[key] = value
+	}
+
+	q.materialized = true
+
+	return nil
+}
+
+// GetStatus returns True if the queue profile exists on Client object or
+// False if not.
+func (q *QueueProfile) GetStatus() bool {
+	return q.materialized
+}
+
+// GetURI returns URI of the queue profile.
+func (q *QueueProfile) GetURI() string {
+	return q.uri
+}
+
+// checkValues validates the profile and sets the defaults of its queues,
+// sorted by Queue. op names the calling operation in the returned error.
+func (s *ScheduleProfile) checkValues(op string) error {
+	err := checkQosProfileName(op, s.Name)
+	if err != nil {
+		return err
+	}
+
+	seen_queues := map[int]bool{}
+	for index := range s.Queues {
+		entry := &s.Queues[index]
+
+		err := checkQueue(op, entry.Queue, seen_queues)
+		if err != nil {
+			return err
+		}
+
+		if entry.Algorithm == "" {
+			entry.Algorithm = "dwrr"
+		}
+		switch entry.Algorithm {
+		case "dwrr":
+			if entry.Weight == 0 {
+				entry.Weight = 1
+			}
+			if entry.Weight < 1 || entry.Weight > 127 {
+				return newValidationError(op, "Weight", "valid range is 1-127 received: "+strconv.Itoa(entry.Weight)+" in queue "+strconv.Itoa(entry.Queue))
+			}
+		case "strict":
+			entry.Weight = 0
+		default:
+			return newValidationError(op, "Algorithm", "valid options are 'dwrr' or 'strict' received: "+entry.Algorithm+" in queue "+strconv.Itoa(entry.Queue))
+		}
+	}
+
+	sort.Slice(s.Queues, func(x, y int) bool {
+		return s.Queues[x].Queue < s.Queues[y].Queue
+	})
+	return nil
+}
+
+// entriesMap returns the queues as sent to the switch, keyed by queue number.
+func (s *ScheduleProfile) entriesMap() map[string]map[string]interface{} {
+	entries := map[string]map[string]interface{}{}
+	for _, entry := range s.Queues {
+		queue := map[string]interface{}{
+			"algorithm": entry.Algorithm,
+		}
+		if entry.Algorithm == "dwrr" {
+			queue["weight"] = entry.Weight
+		}
+		entries[strconv.Itoa(entry.Queue)] = queue
+	}
+	return entries
+}
+
+// Create performs POST to create the schedule profile and its queues on the
+// given Client object. If a queue cannot be created the profile is deleted
+// again.
+func (s *ScheduleProfile) Create(ctx context.Context, c *Client) error {
+	base_uri := "system/qos"
+
+	err := s.checkValues("ScheduleProfile.Create")
+	if err != nil {
+		return err
+	}
+
+	profile_str := url.PathEscape(s.Name)
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri
+	s.uri = "/rest/" + c.Version + "/" + base_uri + "/" + profile_str
+
+	err = createQosProfile(ctx, c, "ScheduleProfile.Create", url, s.Name)
+	if err != nil {
+		return err
+	}
+
+	err = replaceQosProfileEntries(ctx, c, "ScheduleProfile.Create", url+"/"+profile_str+"/queues", s.entriesMap())
+	if err != nil {
+		// Do not leave the profile behind without its queues
+		return errors.Join(err, s.Delete(ctx, c))
+	}
+
+	s.materialized = true
+
+	return nil
+}
+
+// Update performs PUT, POST and DELETE on the queues of the profile on the
+// given Client object to match Queues. The queues are changed one request at
+// a time, so the update is not atomic: on error the profile may hold some
+// queues already updated, call Update again to complete it.
+func (s *ScheduleProfile) Update(ctx context.Context, c *Client) error {
+	base_uri := "system/qos"
+
+	err := s.checkValues("ScheduleProfile.Update")
+	if err != nil {
+		return err
+	}
+
+	profile_str := url.PathEscape(s.Name)
+	entries_url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri + "/" + profile_str + "/queues"
+
+	return replaceQosProfileEntries(ctx, c, "ScheduleProfile.Update", entries_url, s.entriesMap())
+}
+
+// Delete performs DELETE to remove the schedule profile from the given
+// Client object. The switch refuses to delete a profile that is applied.
+func (s *ScheduleProfile) Delete(ctx context.Context, c *Client) error {
+	base_uri := "system/qos"
+
+	profile_str := url.PathEscape(s.Name)
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri + "/" + profile_str
+
+	res, err := delete(ctx, c, url)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusNoContent && res.StatusCode != http.StatusOK {
+		return newRequestError("ScheduleProfile.Delete", res)
+	}
+
+	s.materialized = false
+
+	return nil
+}
+
+// Get performs GET to retrieve the schedule profile with its queues from the
+// given Client object.
+func (s *ScheduleProfile) Get(ctx context.Context, c *Client) error {
+	base_uri := "system/qos"
+	profile_str := url.PathEscape(s.Name)
+	s.uri = "/rest/" + c.Version + "/" + base_uri + "/" + profile_str
+
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri + "/" + profile_str
+
+	res, body, err := get(ctx, c, url)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		s.materialized = false
+		return newRequestError("ScheduleProfile.Get", res)
+	}
+
+	res, _, err = get(ctx, c, url+"/queues?depth=2")
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return newRequestError("ScheduleProfile.Get", res)
+	}
+
+	payloads := map[string]struct {
+		Algorithm string `json:"algorithm"`
+		Weight    int    `json:"weight"`
+	}{}
+	err = json.NewDecoder(res.Body).Decode(&payloads)
+	if err != nil {
+		return fmt.Errorf("ScheduleProfile.Get: decoding queues of %s: %w", s.Name, err)
+	}
+
+	s.Queues = []ScheduleProfileEntry{}
+	for queue_str, payload := range payloads {
+		entry := ScheduleProfileEntry{
+			Algorithm: payload.Algorithm,
+			Weight:    payload.Weight,
+		}
+		entry.Queue, _ = strconv.Atoi(queue_str)
+		s.Queues = append(s.Queues, entry)
+	}
+	sort.Slice(s.Queues, func(x, y int) bool {
+		return s.Queues[x].Queue < s.Queues[y].Queue
+	})
+
+	if s.ScheduleProfileDetails == nil {
+		s.ScheduleProfileDetails = map[string]interface{}{}
+	}
+	for key, value := range body {
+		s.ScheduleProfileDetails[key] = value
+	}
+
+	s.materialized = true
+
+	return nil
+}
+
+// GetStatus returns True if the schedule profile exists on Client object or
+// False if not.
+func (s *ScheduleProfile) GetStatus() bool {
+	return s.materialized
+}
+
+// GetURI returns URI of the schedule profile.
+func (s *ScheduleProfile) GetURI() string {
+	return s.uri
+}
+
+// createQosProfile performs POST to create the queue or schedule profile
+// with the given name in the table at url.
+func createQosProfile(ctx context.Context, c *Client, op string, url string, name string) error {
+	postBody, _ := json.Marshal(map[string]interface{}{
+		"name": name,
+	})
+
+	json_body := bytes.NewBuffer(postBody)
+
+	res, err := post(ctx, c, url, json_body)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusCreated {
+		return newRequestError(op, res)
+	}
+
+	return nil
+}
+
+// replaceQosProfileEntries updates, creates and deletes the queues of a
+// queue or schedule profile in the table at entries_url to match entries,
+// keyed by queue number.
+func replaceQosProfileEntries(ctx context.Context, c *Client, op string, entries_url string, entries map[string]map[string]interface{}) error {
+	res, current, err := get(ctx, c, entries_url)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return newRequestError(op, res)
+	}
+
+	queues := []string{}
+	for queue_str := range entries {
+		queues = append(queues, queue_str)
+	}
+	sort.Strings(queues)
+
+	for _, queue_str := range queues {
+		if _, ok := current[queue_str]; ok {
+			putBody, _ := json.Marshal(entries[queue_str])
+
+			res, err := put(ctx, c, entries_url+"/"+queue_str, bytes.NewBuffer(putBody))
+			if err != nil {
+				return err
+			}
+
+			if res.StatusCode != http.StatusOK {
+				return newRequestError(op, res)
+			}
+			continue
+		}
+
+		postMap := map[string]interface{}{}
+		for key, value := range entries[queue_str] {
+			postMap[key] = value
+		}
+		postMap["queue_number"], _ = strconv.Atoi(queue_str)
+
+		postBody, _ := json.Marshal(postMap)
+
+		res, err := post(ctx, c, entries_url, bytes.NewBuffer(postBody))
+		if err != nil {
+			return err
+		}
+
+		if res.StatusCode != http.StatusCreated {
+			return newRequestError(op, res)
+		}
+	}
+
+	for queue_str := range current {
+		if _, ok := entries[queue_str]; ok {
+			continue
+		}
+
+		res, err := delete(ctx, c, entries_url+"/"+queue_str)
+		if err != nil {
+			return err
+		}
+
+		if res.StatusCode != http.StatusNoContent && res.StatusCode != http.StatusOK {
+			return newRequestError(op, res)
+		}
+	}
+
+	return nil
+}
+
+// ApplyQosProfiles performs PATCH to apply the queue profile and schedule
+// profile with the given names to all ports of the given Client object,
+// after checking they exist. A missing profile is reported as
+// *DependencyError.
+func ApplyQosProfiles(ctx context.Context, c *Client, queue_profile string, schedule_profile string) error {
+	q := QueueProfile{Name: queue_profile}
+	err := q.Get(ctx, c)
+	if errors.Is(err, ErrNotFound) {
+		return newDependencyError("ApplyQosProfiles", "missing QueueProfile "+queue_profile+" - Create QueueProfile first", err)
+	}
+	if err != nil {
+		return err
+	}
+	s := ScheduleProfile{Name: schedule_profile}
+	err = s.Get(ctx, c)
+	if errors.Is(err, ErrNotFound) {
+		return newDependencyError("ApplyQosProfiles", "missing ScheduleProfile "+schedule_profile+" - Create ScheduleProfile first", err)
+	}
+	if err != nil {
+		return err
+	}
+
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/system"
+
+	return patchQos(ctx, c, "ApplyQosProfiles", url, map[string]interface{}{
+		"q_profile_default": q.GetURI(),
+		"qos_default":       s.GetURI(),
+	})
+}
+
+// ApplyScheduleProfile performs PATCH to apply the schedule profile with the
+// given name to the Interface on the given Client object, overriding the
+// one applied to all ports, or with an empty name to remove it.
+func (i *Interface) ApplyScheduleProfile(ctx context.Context, c *Client, schedule_profile string) error {
+	var uri interface{}
+	if schedule_profile != "" {
+		s := ScheduleProfile{Name: schedule_profile}
+		err := s.Get(ctx, c)
+		if errors.Is(err, ErrNotFound) {
+			return newDependencyError("Interface.ApplyScheduleProfile", "missing ScheduleProfile "+schedule_profile+" - Create ScheduleProfile first", err)
+		}
+		if err != nil {
+			return err
+		}
+		uri = s.GetURI()
+	}
+
+	int_str := url.PathEscape(i.Name)
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/system/interfaces/" + int_str
+
+	return patchQos(ctx, c, "Interface.ApplyScheduleProfile", url, map[string]interface{}{
+		"qos": uri,
+	})
+}
+
+// patchQos performs PATCH of the given QoS attributes of the row at url.
+func patchQos(ctx context.Context, c *Client, op string, url string, patchMap map[string]interface{}) error {
+	patchBody, _ := json.Marshal(patchMap)
+
+	json_body := bytes.NewBuffer(patchBody)
+
+	res, err := patch(ctx, c, url, json_body)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusNoContent {
+		return newRequestError(op, res)
+	}
+
+	return nil
+}
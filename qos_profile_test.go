@@ -0,0 +1,222 @@
+package aoscxgo_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/aruba/aoscxgo"
+)
+
+// failingTransport answers requests whose method and path match with 400
+// Bad Request and forwards the others.
+type failingTransport struct {
+	transport http.RoundTripper
+	method    string
+	suffix    string
+}
+
+func (f failingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method == f.method && strings.HasSuffix(req.URL.Path, f.suffix) {
+		return &http.Response{
+			StatusCode: http.StatusBadRequest,
+			Status:     "400 Bad Request",
+			Header:     http.Header{},
+			Body:       http.NoBody,
+			Request:    req,
+		}, nil
+	}
+	return f.transport.RoundTrip(req)
+}
+
+func TestQueueProfileCRUD(t *testing.T) {
+	_, c := connect(t)
+	ctx := context.Background()
+
+	profile := aoscxgo.QueueProfile{Name: "leaf", Queues: []aoscxgo.QueueProfileEntry{
+		{Queue: 7, LocalPriorities: []int{7, 6}, Description: "voice"},
+		{Queue: 0, LocalPriorities: []int{0, 1, 2}},
+	}}
+	err := profile.Create(ctx, c)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	profile.Queues = []aoscxgo.QueueProfileEntry{
+		{Queue: 0, LocalPriorities: []int{0, 1, 2, 3, 4, 5}},
+		{Queue: 1, LocalPriorities: []int{6, 7}},
+	}
+	err = profile.Update(ctx, c)
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	got := aoscxgo.QueueProfile{Name: "leaf"}
+	err = got.Get(ctx, c)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if len(got.Queues) != 2 || got.Queues[0].Queue != 0 || len(got.Queues[0].LocalPriorities) != 6 ||
+		got.Queues[1].Queue != 1 || got.Queues[1].LocalPriorities[0] != 6 {
+		t.Errorf("Get = %+v", got.Queues)
+	}
+
+	err = profile.Delete(ctx, c)
+	if err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	err = got.Get(ctx, c)
+	if !errors.Is(err, aoscxgo.ErrNotFound) {
+		t.Errorf("Get after Delete = %v, want ErrNotFound", err)
+	}
+
+	for _, invalid := range []aoscxgo.QueueProfile{
+		{Name: "a/b"},
+		{Name: "leaf", Queues: []aoscxgo.QueueProfileEntry{{Queue: 8}}},
+		{Name: "leaf", Queues: []aoscxgo.QueueProfileEntry{{Queue: 1}, {Queue: 1}}},
+		{Name: "leaf", Queues: []aoscxgo.QueueProfileEntry{{Queue: 1, LocalPriorities: []int{1}}, {Queue: 2, LocalPriorities: []int{1}}}},
+	} {
+		err := invalid.Create(ctx, c)
+		if !errors.Is(err, aoscxgo.ErrValidation) {
+			t.Errorf("Create(%+v) = %v, want ErrValidation", invalid, err)
+		}
+	}
+}
+
+func TestQueueProfileCreateRollback(t *testing.T) {
+	srv, c := connect(t)
+	ctx := context.Background()
+	c.HTTPClient = &http.Client{
+		Transport: failingTransport{transport: c.HTTPClient.Transport, method: "POST", suffix: "/q_profile_entries"},
+	}
+
+	profile := aoscxgo.QueueProfile{Name: "leaf", Queues: []aoscxgo.QueueProfileEntry{{Queue: 0, LocalPriorities: []int{0}}}}
+	err := profile.Create(ctx, c)
+	var request_error *aoscxgo.RequestError
+	if !errors.As(err, &request_error) || request_error.StatusCode != http.StatusBadRequest {
+		t.Fatalf("Create with a failing queue = %v, want 400 Bad Request", err)
+	}
+	if _, ok := srv.Object("system/q_profiles", "leaf"); ok || profile.GetStatus() {
+		t.Errorf("Create left the profile without its queues")
+	}
+}
+
+func TestScheduleProfileCRUD(t *testing.T) {
+	_, c := connect(t)
+	ctx := context.Background()
+
+	profile := aoscxgo.ScheduleProfile{Name: "leaf", Queues: []aoscxgo.ScheduleProfileEntry{
+		{Queue: 7, Algorithm: "strict"},
+		{Queue: 0},
+	}}
+	err := profile.Create(ctx, c)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	got := aoscxgo.ScheduleProfile{Name: "leaf"}
+	err = got.Get(ctx, c)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if len(got.Queues) != 2 || got.Queues[0] != (aoscxgo.ScheduleProfileEntry{Queue: 0, Algorithm: "dwrr", Weight: 1}) ||
+		got.Queues[1] != (aoscxgo.ScheduleProfileEntry{Queue: 7, Algorithm: "strict"}) {
+		t.Errorf("Get = %+v", got.Queues)
+	}
+
+	profile.Queues = []aoscxgo.ScheduleProfileEntry{{Queue: 0, Weight: 10}}
+	err = profile.Update(ctx, c)
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	err = got.Get(ctx, c)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if len(got.Queues) != 1 || got.Queues[0].Weight != 10 {
+		t.Errorf("queues after Update = %+v", got.Queues)
+	}
+
+	invalid := aoscxgo.ScheduleProfile{Name: "leaf", Queues: []aoscxgo.ScheduleProfileEntry{{Queue: 0, Algorithm: "wfq"}}}
+	err = invalid.Update(ctx, c)
+	if !errors.Is(err, aoscxgo.ErrValidation) {
+		t.Errorf("Update with an invalid algorithm = %v, want ErrValidation", err)
+	}
+
+	err = profile.Delete(ctx, c)
+	if err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+}
+
+func TestScheduleProfileCreateRollback(t *testing.T) {
+	srv, c := connect(t)
+	ctx := context.Background()
+	c.HTTPClient = &http.Client{
+		Transport: failingTransport{transport: c.HTTPClient.Transport, method: "POST", suffix: "/queues"},
+	}
+
+	profile := aoscxgo.ScheduleProfile{Name: "leaf", Queues: []aoscxgo.ScheduleProfileEntry{{Queue: 0}}}
+	err := profile.Create(ctx, c)
+	var request_error *aoscxgo.RequestError
+	if !errors.As(err, &request_error) || request_error.StatusCode != http.StatusBadRequest {
+		t.Fatalf("Create with a failing queue = %v, want 400 Bad Request", err)
+	}
+	if _, ok := srv.Object("system/qos", "leaf"); ok || profile.GetStatus() {
+		t.Errorf("Create left the profile without its queues")
+	}
+}
+
+func TestApplyQosProfiles(t *testing.T) {
+	srv, c := connect(t)
+	ctx := context.Background()
+	srv.AddInterface("1/1/1")
+
+	queues := aoscxgo.QueueProfile{Name: "leaf", Queues: []aoscxgo.QueueProfileEntry{{Queue: 0, LocalPriorities: []int{0}}}}
+	err := queues.Create(ctx, c)
+	if err != nil {
+		t.Fatalf("QueueProfile.Create: %v", err)
+	}
+
+	err = aoscxgo.ApplyQosProfiles(ctx, c, "leaf", "leaf")
+	var dependency *aoscxgo.DependencyError
+	if !errors.As(err, &dependency) || !errors.Is(err, aoscxgo.ErrValidation) || !errors.Is(err, aoscxgo.ErrNotFound) {
+		t.Fatalf("ApplyQosProfiles with a missing ScheduleProfile = %v, want *DependencyError", err)
+	}
+
+	schedule := aoscxgo.ScheduleProfile{Name: "leaf", Queues: []aoscxgo.ScheduleProfileEntry{{Queue: 0}}}
+	err = schedule.Create(ctx, c)
+	if err != nil {
+		t.Fatalf("ScheduleProfile.Create: %v", err)
+	}
+
+	err = aoscxgo.ApplyQosProfiles(ctx, c, "leaf", "leaf")
+	if err != nil {
+		t.Fatalf("ApplyQosProfiles: %v", err)
+	}
+	system := srv.System()
+	if system["q_profile_default"] != "/rest/"+c.Version+"/system/q_profiles/leaf" ||
+		system["qos_default"] != "/rest/"+c.Version+"/system/qos/leaf" {
+		t.Errorf("system = %v", system)
+	}
+
+	i := aoscxgo.Interface{Name: "1/1/1"}
+	err = i.ApplyScheduleProfile(ctx, c, "leaf")
+	if err != nil {
+		t.Fatalf("ApplyScheduleProfile: %v", err)
+	}
+	err = i.ApplyScheduleProfile(ctx, c, "")
+	if err != nil {
+		t.Fatalf("ApplyScheduleProfile to remove: %v", err)
+	}
+	obj, _ := srv.Object("system/interfaces", "1/1/1")
+	if obj["qos"] != nil {
+		t.Errorf("qos after removing = %v", obj["qos"])
+	}
+	err = i.ApplyScheduleProfile(ctx, c, "missing")
+	if !errors.As(err, &dependency) {
+		t.Errorf("ApplyScheduleProfile of a missing profile = %v, want *DependencyError", err)
+	}
+}
@@ -0,0 +1,102 @@
+package aoscxgo
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// tokenBucket limits the rate of requests to rate per second with bursts of
+// up to burst requests.
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// newTokenBucket returns a full bucket. A burst below 1 is raised to 1.
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// wait blocks until a token is available or the context is done.
+func (b *tokenBucket) wait(ctx context.Context) error {
+	for {
+		b.mu.Lock()
+		now := time.Now()
+		b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+		b.last = now
+
+		if b.tokens >= 1 {
+			b.tokens--
+			b.mu.Unlock()
+			return nil
+		}
+
+		wait := time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+		b.mu.Unlock()
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// initLimits creates the rate limiter and in-flight semaphore from the
+// RateLimit, RateBurst and MaxInFlight settings of the Client.
+func (c *Client) initLimits() {
+	c.state.Lock()
+	defer c.state.Unlock()
+
+	c.rate_limiter = nil
+	if c.RateLimit > 0 {
+		c.rate_limiter = newTokenBucket(c.RateLimit, c.RateBurst)
+	}
+
+	c.in_flight = nil
+	if c.MaxInFlight > 0 {
+		c.in_flight = make(chan struct{}, c.MaxInFlight)
+	}
+}
+
+// acquire waits for the rate limit and a free in-flight slot. The returned
+// function releases the slot.
+func (c *Client) acquire(ctx context.Context) (func(), error) {
+	c.state.Lock()
+	rate_limiter := c.rate_limiter
+	in_flight := c.in_flight
+	c.state.Unlock()
+
+	if rate_limiter != nil {
+		err := rate_limiter.wait(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if in_flight == nil {
+		return func() {}, nil
+	}
+
+	select {
+	case in_flight <- struct{}{}:
+		return func() { <-in_flight }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
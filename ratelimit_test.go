@@ -0,0 +1,109 @@
+package aoscxgo_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aruba/aoscxgo"
+	"github.com/aruba/aoscxgo/aoscxtest"
+)
+
+func TestRateLimit(t *testing.T) {
+	srv := aoscxtest.NewServer()
+	defer srv.Close()
+	ctx := context.Background()
+
+	client := srv.Client()
+	client.RateLimit = 100
+	client.RateBurst = 1
+	c, err := aoscxgo.Connect(ctx, client)
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer c.Logout(ctx)
+
+	start := time.Now()
+	for i := 0; i < 10; i++ {
+		vlan := aoscxgo.Vlan{VlanId: 1}
+		err := vlan.Get(ctx, c)
+		if err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+	}
+
+	// 10 requests at 100 per second take at least 90ms after the burst
+	if elapsed := time.Since(start); elapsed < 85*time.Millisecond {
+		t.Errorf("10 requests took %v, want them rate limited", elapsed)
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	vlan := aoscxgo.Vlan{VlanId: 1}
+	err = vlan.Get(canceled, c)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Get waiting for the rate limit = %v, want context.Canceled", err)
+	}
+}
+
+func TestMaxInFlight(t *testing.T) {
+	srv := aoscxtest.NewServer()
+	defer srv.Close()
+	ctx := context.Background()
+
+	in_flight := atomic.Int32{}
+	max_in_flight := atomic.Int32{}
+	client := srv.Client()
+	transport := client.HTTPClient.Transport
+	client.HTTPClient = &http.Client{
+		Jar: client.HTTPClient.Jar,
+		Transport: transportFunc(func(req *http.Request) (*http.Response, error) {
+			current := in_flight.Add(1)
+			defer in_flight.Add(-1)
+			for {
+				seen := max_in_flight.Load()
+				if current <= seen || max_in_flight.CompareAndSwap(seen, current) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			return transport.RoundTrip(req)
+		}),
+	}
+	client.MaxInFlight = 2
+	c, err := aoscxgo.Connect(ctx, client)
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer c.Logout(ctx)
+
+	// One Client is shared by many goroutines
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			vlan := aoscxgo.Vlan{VlanId: 100 + id, Name: "users"}
+			errs <- vlan.Create(ctx, c)
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("Create: %v", err)
+		}
+	}
+	if max_in_flight.Load() > 2 {
+		t.Errorf("%d requests were in flight, want at most 2", max_in_flight.Load())
+	}
+	if len(srv.Objects("system/vlans")) != 21 {
+		t.Errorf("switch holds %d VLANs, want 21", len(srv.Objects("system/vlans")))
+	}
+}
@@ -0,0 +1,118 @@
+package aoscxgo
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy configures how requests that fail transiently are retried,
+// e.g. when the switch answers 503 while the configuration daemon is busy or
+// resets the connection.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts including the first one.
+	// Values below 2 disable retries.
+	MaxAttempts int
+	// BaseDelay is the delay before the first retry. It doubles on every
+	// following retry up to MaxDelay.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// Jitter is the fraction, between 0 and 1, of each delay that is
+	// randomized so that concurrent clients do not retry in lockstep.
+	Jitter float64
+	// Retryable reports whether a failed attempt may be retried. Either res
+	// or err is set. DefaultRetryable is used when nil.
+	Retryable func(res *http.Response, err error) bool
+	// RetryNonIdempotent allows POST requests to be retried. By default they
+	// are not, as replaying a POST that reached the switch may create the
+	// resource twice or fail with a conflict.
+	RetryNonIdempotent bool
+}
+
+// DefaultRetryPolicy returns a RetryPolicy suited to AOS-CX switches: up to
+// four attempts starting at half a second with 20% jitter.
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts: 4,
+		BaseDelay:   500 * time.Millisecond,
+		MaxDelay:    8 * time.Second,
+		Jitter:      0.2,
+	}
+}
+
+// DefaultRetryable retries transport errors, such as connection resets and
+// timeouts, and the 429, 502, 503 and 504 statuses. Context cancellation is
+// never retried.
+func DefaultRetryable(res *http.Response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+
+	switch res.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// shouldRetry reports whether another attempt should be made after the
+// given attempt of a request with the given method.
+func (p *RetryPolicy) shouldRetry(ctx context.Context, attempt int, method string, res *http.Response, err error) bool {
+	if attempt >= p.MaxAttempts || ctx.Err() != nil {
+		return false
+	}
+	if !p.RetryNonIdempotent && !isIdempotent(method) {
+		return false
+	}
+
+	retryable := p.Retryable
+	if retryable == nil {
+		retryable = DefaultRetryable
+	}
+	return retryable(res, err)
+}
+
+// delay returns how long to wait after the given attempt. A Retry-After
+// header in seconds on res extends the delay.
+func (p *RetryPolicy) delay(attempt int, res *http.Response) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if p.MaxDelay > 0 && delay >= p.MaxDelay {
+			break
+		}
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+
+	if p.Jitter > 0 && delay > 0 {
+		spread := float64(delay) * p.Jitter
+		delay += time.Duration(spread * (2*rand.Float64() - 1))
+	}
+
+	if res != nil {
+		if seconds, err := strconv.Atoi(res.Header.Get("Retry-After")); err == nil {
+			retry_after := time.Duration(seconds) * time.Second
+			if retry_after > delay {
+				delay = retry_after
+			}
+		}
+	}
+
+	return delay
+}
+
+// isIdempotent returns true for methods that can be replayed safely. PATCH
+// is included as the AOS-CX REST API applies absolute values rather than
+// increments.
+func isIdempotent(method string) bool {
+	switch method {
+	case "GET", "HEAD", "OPTIONS", "PUT", "DELETE", "PATCH":
+		return true
+	}
+	return false
+}
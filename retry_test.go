@@ -0,0 +1,181 @@
+package aoscxgo_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aruba/aoscxgo"
+)
+
+// flakyClient makes the first failures requests of the given method fail
+// with err, or answer 503 Service Unavailable when err is nil, and counts
+// the attempts.
+func flakyClient(c *aoscxgo.Client, method string, failures int, err error, attempts *int) {
+	transport := c.HTTPClient.Transport
+	c.HTTPClient = &http.Client{
+		Jar: c.HTTPClient.Jar,
+		Transport: transportFunc(func(req *http.Request) (*http.Response, error) {
+			if req.Method != method {
+				return transport.RoundTrip(req)
+			}
+			*attempts++
+			if *attempts > failures {
+				return transport.RoundTrip(req)
+			}
+			if err != nil {
+				return nil, err
+			}
+			return &http.Response{
+				StatusCode: http.StatusServiceUnavailable,
+				Status:     "503 Service Unavailable",
+				Header:     http.Header{},
+				Body:       http.NoBody,
+				Request:    req,
+			}, nil
+		}),
+	}
+}
+
+// fastRetries returns a RetryPolicy of the given attempts without delays.
+func fastRetries(attempts int) *aoscxgo.RetryPolicy {
+	return &aoscxgo.RetryPolicy{MaxAttempts: attempts, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}
+}
+
+func TestRetry(t *testing.T) {
+	_, c := connect(t)
+	ctx := context.Background()
+
+	attempts := 0
+	flakyClient(c, "GET", 2, nil, &attempts)
+	c.RetryPolicy = fastRetries(3)
+
+	vlan := aoscxgo.Vlan{VlanId: 1}
+	err := vlan.Get(ctx, c)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if attempts != 3 {
+		t.Errorf("Get took %d attempts, want 3", attempts)
+	}
+}
+
+func TestRetryExhausted(t *testing.T) {
+	_, c := connect(t)
+	ctx := context.Background()
+
+	attempts := 0
+	flakyClient(c, "GET", 10, nil, &attempts)
+	c.RetryPolicy = fastRetries(3)
+
+	vlan := aoscxgo.Vlan{VlanId: 1}
+	err := vlan.Get(ctx, c)
+	var request_error *aoscxgo.RequestError
+	if !errors.As(err, &request_error) || request_error.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("Get = %v, want the last 503", err)
+	}
+	if attempts != 3 {
+		t.Errorf("Get took %d attempts, want 3", attempts)
+	}
+
+	// Without RetryPolicy nothing is retried
+	attempts = 0
+	c.RetryPolicy = nil
+	vlan.Get(ctx, c)
+	if attempts != 1 {
+		t.Errorf("Get without RetryPolicy took %d attempts, want 1", attempts)
+	}
+}
+
+func TestRetryTransportError(t *testing.T) {
+	_, c := connect(t)
+	ctx := context.Background()
+
+	attempts := 0
+	flakyClient(c, "GET", 1, errors.New("connection reset by peer"), &attempts)
+	c.RetryPolicy = fastRetries(2)
+
+	vlan := aoscxgo.Vlan{VlanId: 1}
+	err := vlan.Get(ctx, c)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if attempts != 2 {
+		t.Errorf("Get took %d attempts, want 2", attempts)
+	}
+}
+
+func TestRetryNonIdempotent(t *testing.T) {
+	srv, c := connect(t)
+	ctx := context.Background()
+
+	// POST may have reached the switch, so it is not replayed by default
+	attempts := 0
+	flakyClient(c, "POST", 1, nil, &attempts)
+	c.RetryPolicy = fastRetries(3)
+
+	vlan := aoscxgo.Vlan{VlanId: 10, Name: "users"}
+	err := vlan.Create(ctx, c)
+	var request_error *aoscxgo.RequestError
+	if !errors.As(err, &request_error) || request_error.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("Create = %v, want 503", err)
+	}
+	if attempts != 1 {
+		t.Errorf("Create took %d attempts, want 1", attempts)
+	}
+
+	attempts = 0
+	c.RetryPolicy.RetryNonIdempotent = true
+	err = vlan.Create(ctx, c)
+	if err != nil {
+		t.Fatalf("Create with RetryNonIdempotent: %v", err)
+	}
+	if attempts != 2 {
+		t.Errorf("Create took %d attempts, want 2", attempts)
+	}
+	if _, ok := srv.Object("system/vlans", "10"); !ok {
+		t.Error("VLAN 10 was not created")
+	}
+}
+
+func TestRetryCanceled(t *testing.T) {
+	_, c := connect(t)
+
+	attempts := 0
+	flakyClient(c, "GET", 10, nil, &attempts)
+	c.RetryPolicy = &aoscxgo.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Hour}
+
+	// The backoff ends with the context
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	vlan := aoscxgo.Vlan{VlanId: 1}
+	err := vlan.Get(ctx, c)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Get = %v, want context.DeadlineExceeded", err)
+	}
+	if attempts != 1 {
+		t.Errorf("Get took %d attempts, want 1", attempts)
+	}
+}
+
+func TestDefaultRetryable(t *testing.T) {
+	for _, status := range []int{http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout} {
+		if !aoscxgo.DefaultRetryable(&http.Response{StatusCode: status}, nil) {
+			t.Errorf("DefaultRetryable(%d) = false", status)
+		}
+	}
+	for _, status := range []int{http.StatusOK, http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError} {
+		if aoscxgo.DefaultRetryable(&http.Response{StatusCode: status}, nil) {
+			t.Errorf("DefaultRetryable(%d) = true", status)
+		}
+	}
+	if aoscxgo.DefaultRetryable(nil, context.Canceled) || aoscxgo.DefaultRetryable(nil, context.DeadlineExceeded) {
+		t.Error("DefaultRetryable retries context errors")
+	}
+	if !aoscxgo.DefaultRetryable(nil, errors.New("connection reset by peer")) {
+		t.Error("DefaultRetryable does not retry transport errors")
+	}
+}
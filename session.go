@@ -0,0 +1,160 @@
+package aoscxgo
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// session_slots holds one semaphore per switch and user so that MaxSessions
+// is enforced across every Client of the process.
+var (
+	session_slots    = map[string]chan struct{}{}
+	session_slots_mu sync.Mutex
+)
+
+// acquireSession blocks until a session slot for the Client's switch and
+// user is free or the context is done. It is a no-op when MaxSessions is unset.
+func acquireSession(ctx context.Context, c *Client) error {
+	c.state.Lock()
+	held := c.slot_held
+	c.state.Unlock()
+
+	if c.MaxSessions <= 0 || held {
+		return nil
+	}
+
+	key := c.Hostname + "|" + c.Username
+
+	session_slots_mu.Lock()
+	// the first Client to connect sets the cap for the switch and user
+	slots, ok := session_slots[key]
+	if !ok {
+		slots = make(chan struct{}, c.MaxSessions)
+		session_slots[key] = slots
+	}
+	session_slots_mu.Unlock()
+
+	select {
+	case slots <- struct{}{}:
+		c.state.Lock()
+		c.slot_held = true
+		c.state.Unlock()
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("waiting for a free session on %s: %w", c.Hostname, ctx.Err())
+	}
+}
+
+// releaseSession frees the session slot taken by acquireSession.
+func releaseSession(c *Client) {
+	c.state.Lock()
+	held := c.slot_held
+	c.slot_held = false
+	c.state.Unlock()
+
+	if !held {
+		return
+	}
+
+	session_slots_mu.Lock()
+	slots := session_slots[c.Hostname+"|"+c.Username]
+	session_slots_mu.Unlock()
+
+	<-slots
+}
+
+// getSession returns the current session cookie and CSRF token.
+func (c *Client) getSession() (*http.Cookie, string) {
+	c.session.RLock()
+	defer c.session.RUnlock()
+	return c.Cookie, c.Csrf
+}
+
+// setSession stores a new session cookie and CSRF token.
+func (c *Client) setSession(cookie *http.Cookie, csrf string) {
+	c.session.Lock()
+	defer c.session.Unlock()
+	c.Cookie = cookie
+	c.Csrf = csrf
+}
+
+// reauthenticate logs in again after the session identified by stale was
+// rejected by the switch. Concurrent callers holding the same stale cookie
+// only trigger a single login.
+func (c *Client) reauthenticate(ctx context.Context, stale *http.Cookie) error {
+	c.session.Lock()
+	defer c.session.Unlock()
+
+	if c.Cookie != stale {
+		// another request already refreshed the session
+		return nil
+	}
+
+	username, password, err := c.credentials(ctx)
+	if err != nil {
+		return err
+	}
+
+	cookie, csrf, err := login(ctx, c, username, password)
+	if err != nil {
+		return fmt.Errorf("re-authenticating to %s: %w", c.Hostname, err)
+	}
+	c.Cookie = cookie
+	c.Csrf = csrf
+	c.logger().InfoContext(ctx, "aoscxgo session re-established", "hostname", c.Hostname)
+
+	return nil
+}
+
+// sessionExpired reports whether the switch rejected the request because the
+// session is no longer valid.
+func sessionExpired(res *http.Response) bool {
+	return res.StatusCode == http.StatusUnauthorized || res.StatusCode == http.StatusForbidden
+}
+
+// startKeepalive runs a lightweight GET every KeepaliveInterval until
+// stopKeepalive is called. Expired sessions are refreshed by the request
+// helpers, so errors are ignored here.
+func (c *Client) startKeepalive() {
+	c.stopKeepalive()
+
+	stop := make(chan struct{})
+	c.state.Lock()
+	c.stop_keepalive = stop
+	c.state.Unlock()
+	interval := c.KeepaliveInterval
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/system?attributes=hostname"
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				ctx, cancel := context.WithTimeout(context.Background(), interval)
+				res, err := request(ctx, c, "GET", url, nil, map[string]string{"accept": "*/*"})
+				if err == nil {
+					res.Body.Close()
+				}
+				cancel()
+			}
+		}
+	}()
+}
+
+// stopKeepalive stops the keepalive goroutine if one is running.
+func (c *Client) stopKeepalive() {
+	c.state.Lock()
+	defer c.state.Unlock()
+
+	if c.stop_keepalive != nil {
+		close(c.stop_keepalive)
+		c.stop_keepalive = nil
+	}
+}
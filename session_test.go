@@ -0,0 +1,134 @@
+package aoscxgo_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aruba/aoscxgo"
+	"github.com/aruba/aoscxgo/aoscxtest"
+)
+
+func TestReauthenticateOnce(t *testing.T) {
+	srv, c := connect(t)
+	ctx := context.Background()
+
+	logins := atomic.Int32{}
+	c.RequestHook = func(ctx context.Context, info *aoscxgo.RequestInfo) {
+		if strings.HasSuffix(info.URL, "/login") {
+			logins.Add(1)
+		}
+	}
+	srv.ExpireSessions()
+
+	// Requests failing on the same expired session share a single login
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			vlan := aoscxgo.Vlan{VlanId: 1}
+			errs <- vlan.Get(ctx, c)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("Get after session expiry: %v", err)
+		}
+	}
+	if logins.Load() != 1 {
+		t.Errorf("logged in %d times, want 1", logins.Load())
+	}
+}
+
+func TestKeepalive(t *testing.T) {
+	srv := aoscxtest.NewServer()
+	defer srv.Close()
+	ctx := context.Background()
+
+	keepalives := atomic.Int32{}
+	client := srv.Client()
+	client.KeepaliveInterval = 10 * time.Millisecond
+	client.RequestHook = func(ctx context.Context, info *aoscxgo.RequestInfo) {
+		if strings.HasSuffix(info.URL, "/system?attributes=hostname") {
+			keepalives.Add(1)
+		}
+	}
+
+	c, err := aoscxgo.Connect(ctx, client)
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for keepalives.Load() < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if keepalives.Load() < 2 {
+		t.Fatalf("sent %d keepalives, want at least 2", keepalives.Load())
+	}
+
+	err = c.Logout(ctx)
+	if err != nil {
+		t.Fatalf("Logout: %v", err)
+	}
+
+	// Keepalives stop with the session
+	sent := keepalives.Load()
+	time.Sleep(50 * time.Millisecond)
+	if keepalives.Load() != sent {
+		t.Errorf("sent %d keepalives after Logout", keepalives.Load()-sent)
+	}
+}
+
+func TestMaxSessions(t *testing.T) {
+	srv := aoscxtest.NewServer()
+	defer srv.Close()
+	ctx := context.Background()
+
+	first := srv.Client()
+	first.MaxSessions = 1
+	_, err := aoscxgo.Connect(ctx, first)
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+
+	// The second session waits for the first one to log out
+	second := srv.Client()
+	second.MaxSessions = 1
+	wait_ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	_, err = aoscxgo.Connect(wait_ctx, second)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Connect over MaxSessions = %v, want context.DeadlineExceeded", err)
+	}
+
+	connected := make(chan error, 1)
+	go func() {
+		_, err := aoscxgo.Connect(ctx, second)
+		connected <- err
+	}()
+
+	err = first.Logout(ctx)
+	if err != nil {
+		t.Fatalf("Logout: %v", err)
+	}
+
+	select {
+	case err := <-connected:
+		if err != nil {
+			t.Fatalf("Connect after Logout: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Connect did not get the session freed by Logout")
+	}
+	second.Logout(ctx)
+}
@@ -0,0 +1,690 @@
+package aoscxgo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/exp/slices"
+)
+
+// StaticRoute is an IPv4 or IPv6 static route of a VRF.
+type StaticRoute struct {
+
+	// Connection properties.
+	// Vrf is the VRF of the route, defaults to "default".
+	Vrf string `json:"vrf"`
+	// Prefix is the destination network, e.g. "10.0.0.0/24" or
+	// "2001:db8::/32".
+	Prefix string `json:"prefix"`
+	// Type is "forward" to route to Nexthops, "blackhole" to silently drop
+	// or "reject" to drop with an ICMP unreachable, defaults to "forward".
+	Type string `json:"type"`
+	// Nexthops of the route. Routes of type "blackhole" or "reject" may
+	// hold one without IpAddress and Interface to set its Distance and Tag.
+	Nexthops []StaticNexthop `json:"nexthops"`
+
+	StaticRouteDetails map[string]interface{} `json:"details"`
+	materialized       bool
+	uri                string
+}
+
+// StaticNexthop is a next hop of a StaticRoute, given by IP address,
+// interface or both.
+type StaticNexthop struct {
+	IpAddress string `json:"ip_address"`
+	// Interface is the name of the outgoing interface, e.g. "1/1/1".
+	Interface string `json:"interface"`
+	// Distance is the administrative distance 1-255, defaults to 1.
+	Distance int `json:"distance"`
+	// Tag is the route tag, zero for none. Tags require REST API v10.09 or
+	// newer.
+	Tag int `json:"tag"`
+}
+
+// static_route_tag_version is the first REST version holding the tag of
+// static next hops.
+const static_route_tag_version = "v10.09"
+
+// StaticRouteChanges lists the prefixes changed by ReconcileStaticRoutes.
+type StaticRouteChanges struct {
+	Created []string `json:"created"`
+	Updated []string `json:"updated"`
+	Deleted []string `json:"deleted"`
+}
+
+// normalize validates the route and sets its defaults, with Prefix in
+// canonical form. op names the calling operation in the returned error.
+func (r *StaticRoute) normalize(op string) error {
+	if r.Vrf == "" {
+		r.Vrf = "default"
+	}
+	if r.Type == "" {
+		r.Type = "forward"
+	}
+	if !slices.Contains([]string{"forward", "blackhole", "reject"}, r.Type) {
+		return newValidationError(op, "Type", "valid options are 'forward', 'blackhole' or 'reject' received: "+r.Type)
+	}
+
+	ip, network, err := net.ParseCIDR(r.Prefix)
+	if err != nil {
+		return newValidationError(op, "Prefix", "invalid prefix: "+r.Prefix)
+	}
+	if !ip.Equal(network.IP) {
+		return newValidationError(op, "Prefix", "host bits set in prefix, expected "+network.String()+" received: "+r.Prefix)
+	}
+	r.Prefix = network.String()
+	is_ipv4 := network.IP.To4() != nil
+
+	if r.Type == "forward" && len(r.Nexthops) == 0 {
+		return newValidationError(op, "Nexthops", "forward route requires a next hop")
+	}
+	if r.Type != "forward" && len(r.Nexthops) > 1 {
+		return newValidationError(op, "Nexthops", r.Type+" route takes at most one next hop")
+	}
+
+	for index := range r.Nexthops {
+		nexthop := &r.Nexthops[index]
+		if nexthop.Distance == 0 {
+			nexthop.Distance = 1
+		}
+		if nexthop.Distance < 1 || nexthop.Distance > 255 {
+			return newValidationError(op, "Distance", "valid range is 1-255 received: "+strconv.Itoa(nexthop.Distance))
+		}
+		if nexthop.Tag < 0 {
+			return newValidationError(op, "Tag", "must not be negative")
+		}
+
+		if r.Type != "forward" {
+			if nexthop.IpAddress != "" || nexthop.Interface != "" {
+				return newValidationError(op, "Nexthops", r.Type+" route takes no next hop address or interface")
+			}
+			continue
+		}
+
+		if nexthop.IpAddress == "" && nexthop.Interface == "" {
+			return newValidationError(op, "Nexthops", "next hop requires IpAddress or Interface")
+		}
+		if nexthop.IpAddress != "" {
+			nexthop_ip := net.ParseIP(nexthop.IpAddress)
+			if nexthop_ip == nil || (nexthop_ip.To4() != nil) != is_ipv4 {
+				return newValidationError(op, "IpAddress", "invalid next hop for "+r.Prefix+": "+nexthop.IpAddress)
+			}
+			nexthop.IpAddress = nexthop_ip.String()
+		}
+	}
+
+	return nil
+}
+
+// checkVersion rejects next hop tags when the REST version of the Client
+// does not support them.
+func (r *StaticRoute) checkVersion(op string, c *Client) error {
+	if c.VersionAtLeast(static_route_tag_version) {
+		return nil
+	}
+	for _, nexthop := range r.Nexthops {
+		if nexthop.Tag != 0 {
+			return newValidationError(op, "Tag", "route tags require REST version "+static_route_tag_version+" or newer, connected with "+c.Version)
+		}
+	}
+	return nil
+}
+
+// staticRoutesURI returns the URI of the static routes of the VRF below the
+// REST version.
+func staticRoutesURI(vrf string) string {
+	return "system/vrfs/" + url.PathEscape(vrf) + "/static_routes"
+}
+
+// Create performs POST to create the static route and its next hops on the
+// given Client object. If a next hop fails the route is deleted again.
+func (r *StaticRoute) Create(ctx context.Context, c *Client) error {
+	err := r.normalize("StaticRoute.Create")
+	if err != nil {
+		return err
+	}
+	err = r.checkVersion("StaticRoute.Create", c)
+	if err != nil {
+		return err
+	}
+
+	base_uri := staticRoutesURI(r.Vrf)
+	route_str := url.PathEscape(r.Prefix)
+	vrf_str := url.PathEscape(r.Vrf)
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri
+	r.uri = "/rest/" + c.Version + "/" + base_uri + "/" + route_str
+
+	address_family := "ipv4"
+	if strings.Contains(r.Prefix, ":") {
+		address_family = "ipv6"
+	}
+
+	postMap := map[string]interface{}{
+		"prefix":         r.Prefix,
+		"address_family": address_family,
+		"type":           r.Type,
+		"vrf":            "/rest/" + c.Version + "/system/vrfs/" + vrf_str,
+	}
+
+	postBody, _ := json.Marshal(postMap)
+
+	json_body := bytes.NewBuffer(postBody)
+
+	res, err := post(ctx, c, url, json_body)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusCreated {
+		return newRequestError("StaticRoute.Create", res)
+	}
+
+	err = r.createNexthops(ctx, c, "StaticRoute.Create")
+	if err != nil {
+		// Do not leave the route behind without its next hops
+		return errors.Join(err, r.Delete(ctx, c))
+	}
+
+	r.materialized = true
+
+	return nil
+}
+
+// createNexthops performs POST to create the next hops of the route.
+func (r *StaticRoute) createNexthops(ctx context.Context, c *Client, op string) error {
+	route_str := url.PathEscape(r.Prefix)
+	nexthops_url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + staticRoutesURI(r.Vrf) + "/" + route_str + "/static_nexthops"
+
+	for index, nexthop := range r.Nexthops {
+		err := postNexthop(ctx, c, op, nexthops_url, index, nexthop)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// postNexthop performs POST to create a next hop with the given ID.
+func postNexthop(ctx context.Context, c *Client, op string, nexthops_url string, id int, nexthop StaticNexthop) error {
+	postMap := map[string]interface{}{
+		"id":       id,
+		"distance": nexthop.Distance,
+	}
+	if c.VersionAtLeast(static_route_tag_version) {
+		postMap["tag"] = nexthop.Tag
+	}
+	if nexthop.IpAddress != "" {
+		postMap["ip_address"] = nexthop.IpAddress
+	}
+	if nexthop.Interface != "" {
+		postMap["port"] = "/rest/" + c.Version + "/system/interfaces/" + url.PathEscape(nexthop.Interface)
+	}
+
+	postBody, _ := json.Marshal(postMap)
+
+	json_body := bytes.NewBuffer(postBody)
+
+	res, err := post(ctx, c, nexthops_url, json_body)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusCreated {
+		return newRequestError(op, res)
+	}
+
+	return nil
+}
+
+// Update performs PATCH to update the static route on the given Client
+// object. Its next hops are matched with those on the switch by address and
+// interface: unchanged next hops are left alone, those with another
+// Distance or Tag are patched, and new next hops are created before the
+// stale ones are deleted so the route keeps forwarding.
+func (r *StaticRoute) Update(ctx context.Context, c *Client) error {
+	err := r.normalize("StaticRoute.Update")
+	if err != nil {
+		return err
+	}
+	err = r.checkVersion("StaticRoute.Update", c)
+	if err != nil {
+		return err
+	}
+
+	route_str := url.PathEscape(r.Prefix)
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + staticRoutesURI(r.Vrf) + "/" + route_str
+
+	patchBody, _ := json.Marshal(map[string]interface{}{"type": r.Type})
+
+	json_body := bytes.NewBuffer(patchBody)
+
+	res, err := patch(ctx, c, url, json_body)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusNoContent {
+		return newRequestError("StaticRoute.Update", res)
+	}
+
+	nexthops_url := url + "/static_nexthops"
+	ids, current, err := getStaticNexthopIds(ctx, c, "StaticRoute.Update", nexthops_url)
+	if err != nil {
+		return err
+	}
+
+	next_id := 0
+	if len(ids) > 0 {
+		next_id = ids[len(ids)-1] + 1
+	}
+
+	matched := map[int]bool{}
+	for _, nexthop := range r.Nexthops {
+		match_id := -1
+		for _, id := range ids {
+			if !matched[id] && current[id].IpAddress == nexthop.IpAddress && current[id].Interface == nexthop.Interface {
+				match_id = id
+				break
+			}
+		}
+
+		if match_id < 0 {
+			err = postNexthop(ctx, c, "StaticRoute.Update", nexthops_url, next_id, nexthop)
+			if err != nil {
+				return err
+			}
+			next_id++
+			continue
+		}
+
+		matched[match_id] = true
+		if current[match_id] == nexthop {
+			continue
+		}
+
+		patchMap := map[string]interface{}{"distance": nexthop.Distance}
+		if c.VersionAtLeast(static_route_tag_version) {
+			patchMap["tag"] = nexthop.Tag
+		}
+
+		patchBody, _ := json.Marshal(patchMap)
+
+		res, err := patch(ctx, c, nexthops_url+"/"+strconv.Itoa(match_id), bytes.NewBuffer(patchBody))
+		if err != nil {
+			return err
+		}
+
+		if res.StatusCode != http.StatusNoContent {
+			return newRequestError("StaticRoute.Update", res)
+		}
+	}
+
+	for _, id := range ids {
+		if matched[id] {
+			continue
+		}
+
+		res, err := delete(ctx, c, nexthops_url+"/"+strconv.Itoa(id))
+		if err != nil {
+			return err
+		}
+
+		if res.StatusCode != http.StatusNoContent && res.StatusCode != http.StatusOK {
+			return newRequestError("StaticRoute.Update", res)
+		}
+	}
+
+	return nil
+}
+
+// Delete performs DELETE to remove the static route and its next hops from
+// the given Client object.
+func (r *StaticRoute) Delete(ctx context.Context, c *Client) error {
+	err := r.normalizePrefix("StaticRoute.Delete")
+	if err != nil {
+		return err
+	}
+
+	route_str := url.PathEscape(r.Prefix)
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + staticRoutesURI(r.Vrf) + "/" + route_str
+
+	res, err := delete(ctx, c, url)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusNoContent && res.StatusCode != http.StatusOK {
+		return newRequestError("StaticRoute.Delete", res)
+	}
+
+	r.materialized = false
+
+	return nil
+}
+
+// Get performs GET to retrieve the static route and its next hops from the
+// given Client object.
+func (r *StaticRoute) Get(ctx context.Context, c *Client) error {
+	err := r.normalizePrefix("StaticRoute.Get")
+	if err != nil {
+		return err
+	}
+
+	base_uri := staticRoutesURI(r.Vrf)
+	route_str := url.PathEscape(r.Prefix)
+	r.uri = "/rest/" + c.Version + "/" + base_uri + "/" + route_str
+
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri + "/" + route_str
+
+	res, body, err := get(ctx, c, url)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		r.materialized = false
+		return newRequestError("StaticRoute.Get", res)
+	}
+
+	r.Type, _ = body["type"].(string)
+	if r.Type == "" {
+		r.Type = "forward"
+	}
+
+	r.Nexthops, err = getStaticNexthops(ctx, c, "StaticRoute.Get", url+"/static_nexthops")
+	if err != nil {
+		return err
+	}
+
+	if r.StaticRouteDetails == nil {
+		r.StaticRouteDetails = map[string]interface{}{}
+	}
+	for key, value := range body {
+		r.StaticRouteDetails[key] = value
+	}
+
+	r.materialized = true
+
+	return nil
+}
+
+// GetStatus returns True if the static route exists on Client object or
+// False if not.
+func (r *StaticRoute) GetStatus() bool {
+	return r.materialized
+}
+
+// GetURI returns URI of the static route.
+func (r *StaticRoute) GetURI() string {
+	return r.uri
+}
+
+// normalizePrefix validates Prefix and Vrf to identify an existing route,
+// with Prefix in canonical form.
+func (r *StaticRoute) normalizePrefix(op string) error {
+	if r.Vrf == "" {
+		r.Vrf = "default"
+	}
+	_, network, err := net.ParseCIDR(r.Prefix)
+	if err != nil {
+		return newValidationError(op, "Prefix", "invalid prefix: "+r.Prefix)
+	}
+	r.Prefix = network.String()
+	return nil
+}
+
+// getStaticNexthops performs GET to retrieve the next hops of a route at
+// the given URL, ordered by ID.
+func getStaticNexthops(ctx context.Context, c *Client, op string, url string) ([]StaticNexthop, error) {
+	ids, nexthops, err := getStaticNexthopIds(ctx, c, op, url)
+	if err != nil {
+		return nil, err
+	}
+
+	sorted := []StaticNexthop{}
+	for _, id := range ids {
+		sorted = append(sorted, nexthops[id])
+	}
+	return sorted, nil
+}
+
+// getStaticNexthopIds performs GET to retrieve the next hops of a route at
+// the given URL keyed by ID, with the sorted IDs.
+func getStaticNexthopIds(ctx context.Context, c *Client, op string, url string) ([]int, map[int]StaticNexthop, error) {
+	res, _, err := get(ctx, c, url+"?depth=2")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, nil, newRequestError(op, res)
+	}
+
+	payloads := map[string]staticNexthopPayload{}
+	err = json.NewDecoder(res.Body).Decode(&payloads)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: decoding next hops: %w", op, err)
+	}
+
+	ids, nexthops := decodeStaticNexthops(payloads)
+	return ids, nexthops, nil
+}
+
+// staticNexthopPayload is a next hop as returned by the switch.
+type staticNexthopPayload struct {
+	Id        int             `json:"id"`
+	IpAddress *string         `json:"ip_address"`
+	Port      json.RawMessage `json:"port"`
+	Distance  int             `json:"distance"`
+	Tag       int             `json:"tag"`
+}
+
+// decodeStaticNexthops returns the next hops of the payloads keyed by ID,
+// with the sorted IDs.
+func decodeStaticNexthops(payloads map[string]staticNexthopPayload) ([]int, map[int]StaticNexthop) {
+	ids := []int{}
+	nexthops := map[int]StaticNexthop{}
+	for _, payload := range payloads {
+		nexthop := StaticNexthop{
+			Distance: payload.Distance,
+			Tag:      payload.Tag,
+		}
+		if payload.IpAddress != nil {
+			nexthop.IpAddress = *payload.IpAddress
+		}
+		if ports := referenceKeys(payload.Port); len(ports) > 0 {
+			nexthop.Interface = ports[0]
+		}
+		ids = append(ids, payload.Id)
+		nexthops[payload.Id] = nexthop
+	}
+	sort.Ints(ids)
+
+	return ids, nexthops
+}
+
+// ListStaticRoutes performs GET to retrieve the static routes of a VRF,
+// "default" if empty, with their next hops from the given Client object in
+// a single request, sorted by prefix.
+func ListStaticRoutes(ctx context.Context, c *Client, vrf string) ([]StaticRoute, error) {
+	if vrf == "" {
+		vrf = "default"
+	}
+
+	base_uri := staticRoutesURI(vrf)
+	url_str := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri
+
+	// Depth 3 returns the next hops with their routes
+	res, body, err := get(ctx, c, url_str+"?depth=3&attributes=prefix,type,static_nexthops")
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, newRequestError("ListStaticRoutes", res)
+	}
+
+	payloads := map[string]struct {
+		StaticNexthops map[string]staticNexthopPayload `json:"static_nexthops"`
+	}{}
+	err = json.NewDecoder(res.Body).Decode(&payloads)
+	if err != nil {
+		return nil, fmt.Errorf("ListStaticRoutes: decoding routes: %w", err)
+	}
+
+	routes := []StaticRoute{}
+	for prefix, value := range body {
+		details, _ := value.(map[string]interface{})
+		route_str := url.PathEscape(prefix)
+
+		r := StaticRoute{
+			Vrf:                vrf,
+			Prefix:             prefix,
+			StaticRouteDetails: details,
+			materialized:       true,
+			uri:                "/rest/" + c.Version + "/" + base_uri + "/" + route_str,
+		}
+		r.Type, _ = details["type"].(string)
+		if r.Type == "" {
+			r.Type = "forward"
+		}
+
+		ids, nexthops := decodeStaticNexthops(payloads[prefix].StaticNexthops)
+		r.Nexthops = []StaticNexthop{}
+		for _, id := range ids {
+			r.Nexthops = append(r.Nexthops, nexthops[id])
+		}
+
+		routes = append(routes, r)
+	}
+
+	sort.Slice(routes, func(a, b int) bool {
+		return routes[a].Prefix < routes[b].Prefix
+	})
+
+	return routes, nil
+}
+
+// ReconcileStaticRoutes makes the static routes of a VRF, "default" if
+// empty, match the given routes: missing routes are created, differing
+// routes are updated and routes not given are deleted. Routes already as
+// desired are not changed, so reconciling again changes nothing. The
+// returned error joins the errors of all failed routes.
+func ReconcileStaticRoutes(ctx context.Context, c *Client, vrf string, routes []StaticRoute) (StaticRouteChanges, error) {
+	op := "ReconcileStaticRoutes"
+	changes := StaticRouteChanges{}
+
+	if vrf == "" {
+		vrf = "default"
+	}
+
+	desired := map[string]StaticRoute{}
+	for _, r := range routes {
+		if r.Vrf == "" {
+			r.Vrf = vrf
+		}
+		if r.Vrf != vrf {
+			return changes, newValidationError(op, "Vrf", "route "+r.Prefix+" is in VRF "+r.Vrf+" not "+vrf)
+		}
+		r.Nexthops = append([]StaticNexthop(nil), r.Nexthops...)
+		err := r.normalize(op)
+		if err != nil {
+			return changes, err
+		}
+		err = r.checkVersion(op, c)
+		if err != nil {
+			return changes, err
+		}
+		if _, ok := desired[r.Prefix]; ok {
+			return changes, newValidationError(op, "Prefix", "duplicate route "+r.Prefix)
+		}
+		desired[r.Prefix] = r
+	}
+
+	existing_routes, err := ListStaticRoutes(ctx, c, vrf)
+	if err != nil {
+		return changes, err
+	}
+	existing := map[string]StaticRoute{}
+	for _, r := range existing_routes {
+		existing[r.Prefix] = r
+	}
+
+	var errs []error
+	for _, r := range existing_routes {
+		if _, ok := desired[r.Prefix]; ok {
+			continue
+		}
+		err := r.Delete(ctx, c)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: deleting %s: %w", op, r.Prefix, err))
+			continue
+		}
+		changes.Deleted = append(changes.Deleted, r.Prefix)
+	}
+
+	prefixes := []string{}
+	for prefix := range desired {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+
+	for _, prefix := range prefixes {
+		r := desired[prefix]
+		current, ok := existing[prefix]
+		if !ok {
+			err := r.Create(ctx, c)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: creating %s: %w", op, prefix, err))
+				continue
+			}
+			changes.Created = append(changes.Created, prefix)
+			continue
+		}
+
+		if current.Type == r.Type && sameNexthops(current.Nexthops, r.Nexthops) {
+			continue
+		}
+		err := r.Update(ctx, c)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: updating %s: %w", op, prefix, err))
+			continue
+		}
+		changes.Updated = append(changes.Updated, prefix)
+	}
+
+	return changes, errors.Join(errs...)
+}
+
+// sameNexthops returns true if both lists hold the same next hops in any
+// order, as Update keeps the IDs of unchanged next hops.
+func sameNexthops(a []StaticNexthop, b []StaticNexthop) bool {
+	less := func(x StaticNexthop, y StaticNexthop) bool {
+		if x.IpAddress != y.IpAddress {
+			return x.IpAddress < y.IpAddress
+		}
+		if x.Interface != y.Interface {
+			return x.Interface < y.Interface
+		}
+		if x.Distance != y.Distance {
+			return x.Distance < y.Distance
+		}
+		return x.Tag < y.Tag
+	}
+
+	sorted_a := append([]StaticNexthop(nil), a...)
+	sorted_b := append([]StaticNexthop(nil), b...)
+	sort.Slice(sorted_a, func(i, j int) bool { return less(sorted_a[i], sorted_a[j]) })
+	sort.Slice(sorted_b, func(i, j int) bool { return less(sorted_b[i], sorted_b[j]) })
+	return slices.Equal(sorted_a, sorted_b)
+}
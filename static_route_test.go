@@ -0,0 +1,220 @@
+package aoscxgo_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/aruba/aoscxgo"
+)
+
+func TestStaticRouteCRUD(t *testing.T) {
+	srv, c := connect(t)
+	ctx := context.Background()
+	srv.AddInterface("1/1/1")
+
+	route := aoscxgo.StaticRoute{
+		Prefix: "10.1.0.0/16",
+		Nexthops: []aoscxgo.StaticNexthop{
+			{IpAddress: "192.168.0.1"},
+			{Interface: "1/1/1", Distance: 10},
+		},
+	}
+	err := route.Create(ctx, c)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	got := aoscxgo.StaticRoute{Prefix: "10.1.0.0/16"}
+	err = got.Get(ctx, c)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.Vrf != "default" || got.Type != "forward" || len(got.Nexthops) != 2 ||
+		got.Nexthops[0] != (aoscxgo.StaticNexthop{IpAddress: "192.168.0.1", Distance: 1}) ||
+		got.Nexthops[1] != (aoscxgo.StaticNexthop{Interface: "1/1/1", Distance: 10}) {
+		t.Errorf("Get = %+v", got)
+	}
+
+	err = route.Delete(ctx, c)
+	if err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	err = got.Get(ctx, c)
+	if !errors.Is(err, aoscxgo.ErrNotFound) {
+		t.Errorf("Get after Delete = %v, want ErrNotFound", err)
+	}
+
+	invalid := aoscxgo.StaticRoute{Prefix: "10.1.0.1/16", Nexthops: []aoscxgo.StaticNexthop{{IpAddress: "192.168.0.1"}}}
+	err = invalid.Create(ctx, c)
+	if !errors.Is(err, aoscxgo.ErrValidation) {
+		t.Errorf("Create with host bits = %v, want ErrValidation", err)
+	}
+}
+
+func TestStaticRouteUpdateNexthops(t *testing.T) {
+	_, c := connect(t)
+	ctx := context.Background()
+
+	route := aoscxgo.StaticRoute{
+		Prefix: "10.1.0.0/16",
+		Nexthops: []aoscxgo.StaticNexthop{
+			{IpAddress: "192.168.0.1"},
+			{IpAddress: "192.168.0.2"},
+			{IpAddress: "192.168.0.3"},
+		},
+	}
+	err := route.Create(ctx, c)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	var requests []string
+	c.RequestHook = func(ctx context.Context, info *aoscxgo.RequestInfo) {
+		if info.Method != "GET" && strings.Contains(info.URL, "/static_nexthops") {
+			path := info.URL[strings.Index(info.URL, "/static_nexthops"):]
+			requests = append(requests, info.Method+" "+path+" "+info.Body)
+		}
+	}
+
+	// .1 is kept, .2 changes distance, .3 is replaced by .4
+	route.Nexthops = []aoscxgo.StaticNexthop{
+		{IpAddress: "192.168.0.1"},
+		{IpAddress: "192.168.0.2", Distance: 20},
+		{IpAddress: "192.168.0.4"},
+	}
+	err = route.Update(ctx, c)
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	c.RequestHook = nil
+
+	want := []string{
+		`PATCH /static_nexthops/1 {"distance":20,"tag":0}`,
+		`POST /static_nexthops {"distance":1,"id":3,"ip_address":"192.168.0.4","tag":0}`,
+		`DELETE /static_nexthops/2 `,
+	}
+	if strings.Join(requests, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Update sent\n%s\nwant\n%s", strings.Join(requests, "\n"), strings.Join(want, "\n"))
+	}
+
+	got := aoscxgo.StaticRoute{Prefix: "10.1.0.0/16"}
+	err = got.Get(ctx, c)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if len(got.Nexthops) != 3 || got.Nexthops[1].Distance != 20 || got.Nexthops[2].IpAddress != "192.168.0.4" {
+		t.Errorf("Get = %+v", got.Nexthops)
+	}
+}
+
+func TestReconcileStaticRoutes(t *testing.T) {
+	srv, c := connect(t)
+	ctx := context.Background()
+
+	for _, prefix := range []string{"10.1.0.0/16", "10.2.0.0/16"} {
+		route := aoscxgo.StaticRoute{Prefix: prefix, Nexthops: []aoscxgo.StaticNexthop{{IpAddress: "192.168.0.1"}}}
+		err := route.Create(ctx, c)
+		if err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+	}
+
+	routes := []aoscxgo.StaticRoute{
+		{Prefix: "10.1.0.0/16", Nexthops: []aoscxgo.StaticNexthop{{IpAddress: "192.168.0.2"}, {IpAddress: "192.168.0.1"}}},
+		{Prefix: "10.3.0.0/16", Type: "blackhole"},
+	}
+	changes, err := aoscxgo.ReconcileStaticRoutes(ctx, c, "", routes)
+	if err != nil {
+		t.Fatalf("ReconcileStaticRoutes: %v", err)
+	}
+	if strings.Join(changes.Created, ",") != "10.3.0.0/16" || strings.Join(changes.Updated, ",") != "10.1.0.0/16" ||
+		strings.Join(changes.Deleted, ",") != "10.2.0.0/16" {
+		t.Errorf("changes = %+v", changes)
+	}
+
+	changes, err = aoscxgo.ReconcileStaticRoutes(ctx, c, "", routes)
+	if err != nil {
+		t.Fatalf("ReconcileStaticRoutes: %v", err)
+	}
+	if len(changes.Created)+len(changes.Updated)+len(changes.Deleted) != 0 {
+		t.Errorf("reconciling again changed %+v", changes)
+	}
+	if got := srv.Objects("system/vrfs/default/static_routes"); len(got) != 2 {
+		t.Errorf("static routes = %v", got)
+	}
+}
+
+func TestStaticRouteCreateRollback(t *testing.T) {
+	srv, c := connect(t)
+	ctx := context.Background()
+	c.HTTPClient = &http.Client{
+		Jar:       c.HTTPClient.Jar,
+		Transport: failingTransport{transport: c.HTTPClient.Transport, method: "POST", suffix: "/static_nexthops"},
+	}
+
+	route := aoscxgo.StaticRoute{Prefix: "10.1.0.0/16", Nexthops: []aoscxgo.StaticNexthop{{IpAddress: "192.168.0.1"}}}
+	err := route.Create(ctx, c)
+	var request_error *aoscxgo.RequestError
+	if !errors.As(err, &request_error) || request_error.StatusCode != http.StatusBadRequest {
+		t.Fatalf("Create = %v, want the failed next hop", err)
+	}
+
+	// The route is not left behind without its next hops
+	if got := srv.Objects("system/vrfs/default/static_routes"); len(got) != 0 {
+		t.Errorf("static routes after failed Create = %v", got)
+	}
+	if route.GetStatus() {
+		t.Error("GetStatus after failed Create = true")
+	}
+}
+
+func TestListStaticRoutes(t *testing.T) {
+	srv, c := connect(t)
+	ctx := context.Background()
+	srv.AddInterface("1/1/1")
+
+	for _, route := range []aoscxgo.StaticRoute{
+		{Prefix: "10.1.0.0/16", Nexthops: []aoscxgo.StaticNexthop{{IpAddress: "192.168.0.1", Tag: 5}, {Interface: "1/1/1", Distance: 10}}},
+		{Prefix: "2001:db8::/32", Nexthops: []aoscxgo.StaticNexthop{{IpAddress: "2001:db8:ffff::1"}}},
+		{Prefix: "10.99.0.0/16", Type: "blackhole"},
+	} {
+		err := route.Create(ctx, c)
+		if err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+	}
+
+	// Routes and next hops come in a single request
+	requests := 0
+	c.RequestHook = func(ctx context.Context, info *aoscxgo.RequestInfo) {
+		requests++
+	}
+	routes, err := aoscxgo.ListStaticRoutes(ctx, c, "")
+	if err != nil {
+		t.Fatalf("ListStaticRoutes: %v", err)
+	}
+	if requests != 1 {
+		t.Errorf("ListStaticRoutes sent %d requests, want 1", requests)
+	}
+
+	if len(routes) != 3 {
+		t.Fatalf("ListStaticRoutes = %+v, want 3 routes", routes)
+	}
+	if routes[0].Prefix != "10.1.0.0/16" || len(routes[0].Nexthops) != 2 ||
+		routes[0].Nexthops[0] != (aoscxgo.StaticNexthop{IpAddress: "192.168.0.1", Distance: 1, Tag: 5}) ||
+		routes[0].Nexthops[1] != (aoscxgo.StaticNexthop{Interface: "1/1/1", Distance: 10}) {
+		t.Errorf("routes[0] = %+v", routes[0])
+	}
+	if routes[1].Prefix != "10.99.0.0/16" || routes[1].Type != "blackhole" || len(routes[1].Nexthops) != 0 {
+		t.Errorf("routes[1] = %+v", routes[1])
+	}
+	if routes[2].Prefix != "2001:db8::/32" || len(routes[2].Nexthops) != 1 || routes[2].Nexthops[0].IpAddress != "2001:db8:ffff::1" {
+		t.Errorf("routes[2] = %+v", routes[2])
+	}
+	if !routes[0].GetStatus() || !strings.HasSuffix(routes[0].GetURI(), "/static_routes/10.1.0.0%2F16") {
+		t.Errorf("routes[0] status %t URI %q", routes[0].GetStatus(), routes[0].GetURI())
+	}
+}
@@ -2,122 +2,197 @@ package aoscxgo
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
-	"log"
+	"io"
 	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
 )
 
-// A custom error struct
-type RequestError struct {
-	StatusCode string
-
-	Err error
-}
+// request sends a request with the session cookie and CSRF token of the given
+// Client. If the switch reports the session as expired the Client logs in
+// again and the request is replayed once. Failed attempts are retried as
+// configured by the RetryPolicy of the Client. Transport errors, including
+// context cancellation, are returned wrapped with the method and URL.
+func request(ctx context.Context, client *Client, method string, url string, body io.Reader, headers map[string]string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: %w", method, url, err)
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	req.Close = false
 
-// A custom error string
-func (r *RequestError) Error() string {
-	return fmt.Sprintf("Status : %v\nError %v", r.StatusCode, r.Err)
+	policy := client.RetryPolicy
+	attempt := 1
+
+	for {
+		res, err := sendWithSession(ctx, client, req)
+
+		if policy == nil || !policy.shouldRetry(ctx, attempt, method, res, err) {
+			if err != nil {
+				return nil, fmt.Errorf("%s %s: %w", method, url, err)
+			}
+			return res, nil
+		}
+
+		delay := policy.delay(attempt, res)
+		client.logger().DebugContext(ctx, "aoscxgo retrying request",
+			"method", method, "url", url, "attempt", attempt, "delay", delay, "error", err)
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("%s %s: %w", method, url, ctx.Err())
+		case <-time.After(delay):
+		}
+
+		attempt++
+		req, err = cloneRequest(ctx, req)
+		if err != nil {
+			return nil, fmt.Errorf("%s %s: %w", method, url, err)
+		}
+	}
 }
 
-// delete performs DELETE to the given URL and returns the response.
-func delete(client *Client, url string) *http.Response {
-	req, _ := http.NewRequest("DELETE", url, nil)
-	req.Header.Set("accept", "*/*")
-	req.Close = false
-
-	req.AddCookie(client.Cookie)
-	res, err := client.Transport.RoundTrip(req)
-	//Handle Error
+// sendWithSession sends req, logging in again and replaying it once if the
+// switch reports the session as expired.
+func sendWithSession(ctx context.Context, client *Client, req *http.Request) (*http.Response, error) {
+	res, cookie, err := send(client, req)
 	if err != nil {
-		log.Fatalf("An Error Occured %v %v", err, res.Status)
+		return nil, err
 	}
 
-	return res
+	if sessionExpired(res) && cookie != nil {
+		err = client.reauthenticate(ctx, cookie)
+		if err != nil {
+			return nil, err
+		}
+
+		replay, err := cloneRequest(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		res, _, err = send(client, replay)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return res, nil
 }
 
-// get performs GET to the given URL and returns the data response.
-func get(client *Client, url string) (*http.Response, map[string]interface{}) {
-	req, _ := http.NewRequest("GET", url, nil)
+// cloneRequest returns a copy of req with a fresh body so it can be sent again.
+func cloneRequest(ctx context.Context, req *http.Request) (*http.Request, error) {
+	clone := req.Clone(ctx)
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		clone.Body = body
+	}
+	return clone, nil
+}
 
-	req.Header.Set("accept", "*/*")
-	req.Header.Set("x-csrf-token", client.Csrf)
-	req.Close = false
-	req.AddCookie(client.Cookie)
-	res, err := client.Transport.RoundTrip(req)
-	if err != nil {
-		fmt.Println(err)
+// send attaches the current session to req and performs the round trip. The
+// cookie used is returned so an expired session can be identified.
+func send(client *Client, req *http.Request) (*http.Response, *http.Cookie, error) {
+	cookie, csrf := client.getSession()
+
+	req.Header.Del("Cookie")
+	req.Header.Set("x-csrf-token", csrf)
+	// A cookie jar on the HTTPClient already sends the session cookie
+	if cookie != nil && client.HTTPClient.Jar == nil {
+		req.AddCookie(cookie)
 	}
 
-	body := make(map[string]interface{})
-	err = json.NewDecoder(res.Body).Decode(&body)
+	res, err := roundTrip(client, req)
+	return res, cookie, err
+}
 
-	return res, body
+// delete performs DELETE to the given URL and returns the response.
+func delete(ctx context.Context, client *Client, url string) (*http.Response, error) {
+	return request(ctx, client, "DELETE", url, nil, map[string]string{"accept": "*/*"})
 }
 
 // get performs GET to the given URL and returns the data response.
-func get_accept_text(client *Client, url string) (*http.Response, error) {
-	req, _ := http.NewRequest("GET", url, nil)
-	req.Header.Set("Accept", "text/plain")
-	// req.Header.Set("Content-Type", "text/plain")
-	req.Header.Set("x-csrf-token", client.Csrf)
-	req.Close = false
-	req.AddCookie(client.Cookie)
-	res, err := client.Transport.RoundTrip(req)
+func get(ctx context.Context, client *Client, url string) (*http.Response, map[string]interface{}, error) {
+	res, err := request(ctx, client, "GET", url, nil, map[string]string{"accept": "*/*"})
 	if err != nil {
-		fmt.Println(err)
+		return nil, nil, err
 	}
 
-	return res, err
-}
-
-// get performs GET to the given URL and returns the data response.
-func post(client *Client, url string, json_body *bytes.Buffer) *http.Response {
-	req, _ := http.NewRequest("POST", url, json_body)
-	req.Header.Set("Content-Type", "text/plain")
-	req.Header.Set("x-csrf-token", client.Csrf)
-	req.Close = false
+	// Keep the buffered body readable so errors can report the switch's message
+	raw_body, _ := io.ReadAll(res.Body)
+	res.Body = io.NopCloser(bytes.NewReader(raw_body))
 
-	req.AddCookie(client.Cookie)
-	res, err := client.Transport.RoundTrip(req)
-	//Handle Error
-	if err != nil {
-		log.Fatalf("An Error Occured %v %v", err, res.Status)
-	}
+	body := make(map[string]interface{})
+	// Non-JSON bodies (e.g. error pages) leave body empty, callers check the
+	// status first.
+	json.Unmarshal(raw_body, &body)
 
-	return res
+	return res, body, nil
 }
 
-// put performs PUT to the given URL and returns the response.
-func put(client *Client, url string, json_body *bytes.Buffer) *http.Response {
-	req, _ := http.NewRequest("PUT", url, json_body)
-	req.Header.Set("accept", "*/*")
-	req.Header.Set("x-csrf-token", client.Csrf)
-	req.Close = false
+// get_accept_text performs GET to the given URL and returns the plain text response.
+func get_accept_text(ctx context.Context, client *Client, url string) (*http.Response, error) {
+	return request(ctx, client, "GET", url, nil, map[string]string{"Accept": "text/plain"})
+}
 
-	req.AddCookie(client.Cookie)
-	res, err := client.Transport.RoundTrip(req)
-	//Handle Error
-	if err != nil {
-		log.Fatalf("An Error Occured %v %v", err, res.Status)
-	}
+// post performs POST to the given URL and returns the response.
+func post(ctx context.Context, client *Client, url string, json_body *bytes.Buffer) (*http.Response, error) {
+	return request(ctx, client, "POST", url, json_body, map[string]string{"Content-Type": "text/plain"})
+}
 
-	return res
+// put performs PUT to the given URL and returns the response.
+func put(ctx context.Context, client *Client, url string, json_body *bytes.Buffer) (*http.Response, error) {
+	return request(ctx, client, "PUT", url, json_body, map[string]string{"accept": "*/*"})
 }
 
 // patch performs PATCH to the given URL and returns the response.
-func patch(client *Client, url string, json_body *bytes.Buffer) *http.Response {
-	req, _ := http.NewRequest("PATCH", url, json_body)
-	req.Header.Set("accept", "*/*")
-	req.Header.Set("x-csrf-token", client.Csrf)
-	req.Close = false
+func patch(ctx context.Context, client *Client, url string, json_body *bytes.Buffer) (*http.Response, error) {
+	return request(ctx, client, "PATCH", url, json_body, map[string]string{"accept": "*/*"})
+}
 
-	req.AddCookie(client.Cookie)
-	res, err := client.Transport.RoundTrip(req)
-	//Handle Error
-	if err != nil {
-		log.Fatalf("An Error Occured %v %v", err, res.Status)
+// referenceKeys returns the keys of the rows referenced by a REST attribute.
+// Depending on the request depth references are returned as a URI, a list of
+// URIs or a map of keys to URIs, e.g.
+//
+//	"vrf": {"default": "/rest/v10.09/system/vrfs/default"}
+//
+// Keys are taken from the last path segment of URIs and unescaped.
+func referenceKeys(raw json.RawMessage) []string {
+	var keys []string
+
+	reference_map := map[string]interface{}{}
+	if json.Unmarshal(raw, &reference_map) == nil {
+		for key := range reference_map {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		return keys
 	}
 
-	return res
+	var uris []string
+	var uri string
+	if json.Unmarshal(raw, &uri) == nil && uri != "" {
+		uris = []string{uri}
+	} else {
+		json.Unmarshal(raw, &uris)
+	}
+
+	for _, uri := range uris {
+		key := uri[strings.LastIndex(uri, "/")+1:]
+		if unescaped, err := url.PathUnescape(key); err == nil {
+			key = unescaped
+		}
+		keys = append(keys, key)
+	}
+	return keys
 }
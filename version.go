@@ -0,0 +1,179 @@
+package aoscxgo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"golang.org/x/exp/slices"
+)
+
+// SupportedVersions lists the REST API versions supported by this package,
+// oldest first.
+var SupportedVersions = []string{"v10.08", "v10.09", "v10.10", "v10.11", "v10.12", "v10.13"}
+
+// DefaultVersion is used when the switch does not answer version discovery.
+const DefaultVersion = "v10.09"
+
+// negotiateVersion queries the REST versions offered by the switch and
+// returns the requested version if both sides support it, or the highest
+// version supported by both when requested is empty.
+func negotiateVersion(ctx context.Context, c *Client, requested string) (string, error) {
+	if requested != "" && !slices.Contains(SupportedVersions, requested) {
+		return "", newValidationError("Connect", "Version",
+			fmt.Sprintf("REST version %s is not supported, supported versions are %v", requested, SupportedVersions))
+	}
+
+	offered, err := discoverVersions(ctx, c)
+	if err != nil {
+		return "", err
+	}
+
+	if offered == nil {
+		// Discovery unavailable, trust the caller or fall back to the default
+		if requested != "" {
+			return requested, nil
+		}
+		return DefaultVersion, nil
+	}
+
+	if requested != "" {
+		if !slices.Contains(offered, requested) {
+			return "", newValidationError("Connect", "Version",
+				fmt.Sprintf("REST version %s is not offered by %s, offered versions are %v", requested, c.Hostname, offered))
+		}
+		return requested, nil
+	}
+
+	negotiated := ""
+	for _, version := range SupportedVersions {
+		if slices.Contains(offered, version) {
+			negotiated = version
+		}
+	}
+	if negotiated == "" {
+		return "", newValidationError("Connect", "Version",
+			fmt.Sprintf("no common REST version with %s, offered versions are %v", c.Hostname, offered))
+	}
+
+	return negotiated, nil
+}
+
+// discoverVersions performs GET on /rest which lists the REST versions
+// offered by the switch, e.g.
+//
+//	{
+//	  "latest": {"version": "v10.13", "prefix": "/rest/v10.13", ...},
+//	  "v10.09": {"version": "v10.09", "prefix": "/rest/v10.09", ...},
+//	  ...
+//	}
+//
+// A nil slice is returned if the switch does not support discovery.
+func discoverVersions(ctx context.Context, c *Client) ([]string, error) {
+	url := "https://" + c.Hostname + "/rest"
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("GET %s: %w", url, err)
+	}
+	req.Header.Set("accept", "*/*")
+
+	res, err := roundTrip(c, req)
+	if err != nil {
+		return nil, fmt.Errorf("GET %s: %w", url, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, nil
+	}
+
+	body := map[string]interface{}{}
+	if json.NewDecoder(res.Body).Decode(&body) != nil {
+		return nil, nil
+	}
+
+	var versions []string
+	for key, value := range body {
+		if key == "latest" {
+			if latest, ok := value.(map[string]interface{}); ok {
+				if version, ok := latest["version"].(string); ok && !slices.Contains(versions, version) {
+					versions = append(versions, version)
+				}
+			}
+			continue
+		}
+		if _, _, ok := parseVersion(key); ok && !slices.Contains(versions, key) {
+			versions = append(versions, key)
+		}
+	}
+
+	return versions, nil
+}
+
+// getFirmware retrieves the firmware version and platform of the switch.
+func (c *Client) getFirmware(ctx context.Context) error {
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/system?attributes=firmware_version,platform_name"
+
+	res, body, err := get(ctx, c, url)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return newRequestError("Connect", res)
+	}
+
+	if value, ok := body["firmware_version"].(string); ok {
+		c.FirmwareVersion = value
+	}
+	if value, ok := body["platform_name"].(string); ok {
+		c.Platform = value
+	}
+
+	return nil
+}
+
+// VersionAtLeast returns true if the negotiated REST version of the Client is
+// the given version or newer, for resources sending attributes that only
+// exist in newer versions such as the tag of static routes.
+func (c *Client) VersionAtLeast(version string) bool {
+	return compareVersions(c.Version, version) >= 0
+}
+
+// parseVersion splits a REST version such as "v10.09" into its major and
+// minor numbers.
+func parseVersion(version string) (int, int, bool) {
+	parts := strings.SplitN(strings.TrimPrefix(version, "v"), ".", 2)
+	if !strings.HasPrefix(version, "v") || len(parts) != 2 {
+		return 0, 0, false
+	}
+
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, false
+	}
+	minor, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, 0, false
+	}
+
+	return major, minor, true
+}
+
+// compareVersions returns -1, 0 or 1 if a is older than, the same as or
+// newer than b. Unparsable versions sort first.
+func compareVersions(a string, b string) int {
+	a_major, a_minor, _ := parseVersion(a)
+	b_major, b_minor, _ := parseVersion(b)
+
+	switch {
+	case a_major < b_major || (a_major == b_major && a_minor < b_minor):
+		return -1
+	case a_major == b_major && a_minor == b_minor:
+		return 0
+	}
+	return 1
+}
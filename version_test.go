@@ -0,0 +1,126 @@
+package aoscxgo_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aruba/aoscxgo"
+	"github.com/aruba/aoscxgo/aoscxtest"
+)
+
+// connectVersion connects a Client to a fake switch offering the given REST
+// versions, requesting version or negotiating one if it is empty.
+func connectVersion(t *testing.T, offered []string, version string) (*aoscxtest.Server, *aoscxgo.Client, error) {
+	t.Helper()
+
+	srv := aoscxtest.NewServer()
+	t.Cleanup(srv.Close)
+	srv.Versions = offered
+
+	client := srv.Client()
+	client.Version = version
+	c, err := aoscxgo.Connect(context.Background(), client)
+	if err == nil {
+		t.Cleanup(func() { c.Logout(context.Background()) })
+	}
+	return srv, c, err
+}
+
+func TestNegotiateVersion(t *testing.T) {
+	_, c, err := connectVersion(t, []string{"v10.04", "v10.08", "v10.13", "v10.99"}, "")
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+
+	// The highest version supported by both sides wins
+	if c.Version != "v10.13" {
+		t.Errorf("Version = %q, want v10.13", c.Version)
+	}
+	if c.FirmwareVersion != aoscxtest.DefaultFirmwareVersion || c.Platform != aoscxtest.DefaultPlatform {
+		t.Errorf("FirmwareVersion = %q, Platform = %q, want the switch's", c.FirmwareVersion, c.Platform)
+	}
+	if !c.VersionAtLeast("v10.09") || !c.VersionAtLeast("v10.13") || c.VersionAtLeast("v10.14") || c.VersionAtLeast("v11.00") {
+		t.Errorf("VersionAtLeast does not order versions around %s", c.Version)
+	}
+}
+
+func TestNegotiateVersionErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		offered []string
+		version string
+		want    string
+	}{
+		{"unsupported", []string{"v10.09"}, "v9.99", "not supported"},
+		{"not offered", []string{"v10.08", "v10.09"}, "v10.12", "not offered"},
+		{"no common", []string{"v10.04"}, "", "no common REST version"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, _, err := connectVersion(t, test.offered, test.version)
+			if !errors.Is(err, aoscxgo.ErrValidation) || !strings.Contains(err.Error(), test.want) {
+				t.Errorf("Connect = %v, want a validation error containing %q", err, test.want)
+			}
+		})
+	}
+}
+
+func TestVersionGate(t *testing.T) {
+	ctx := context.Background()
+	route := aoscxgo.StaticRoute{
+		Prefix:   "10.1.0.0/16",
+		Nexthops: []aoscxgo.StaticNexthop{{IpAddress: "192.168.0.1", Tag: 100}},
+	}
+
+	// v10.09 and newer hold the tag of static routes
+	srv, c, err := connectVersion(t, []string{"v10.08", "v10.09"}, "v10.09")
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	err = route.Create(ctx, c)
+	if err != nil {
+		t.Fatalf("Create on v10.09: %v", err)
+	}
+	if nexthop, _ := srv.Object("system/vrfs/default/static_routes/10.1.0.0%2F16/static_nexthops", "0"); nexthop["tag"] != float64(100) {
+		t.Errorf("next hop on v10.09 = %v, want tag 100", nexthop)
+	}
+
+	// v10.08 rejects tags before sending anything and leaves the
+	// attribute out of the bodies
+	srv, c, err = connectVersion(t, []string{"v10.08", "v10.09"}, "v10.08")
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	var bodies []string
+	c.RequestHook = func(ctx context.Context, info *aoscxgo.RequestInfo) {
+		if info.Method == "POST" || info.Method == "PATCH" {
+			bodies = append(bodies, info.Body)
+		}
+	}
+
+	err = route.Create(ctx, c)
+	var validation_error *aoscxgo.ValidationError
+	if !errors.As(err, &validation_error) || validation_error.Field != "Tag" {
+		t.Fatalf("Create with tag on v10.08 = %v, want a Tag ValidationError", err)
+	}
+	if len(bodies) != 0 {
+		t.Errorf("sent %v for a rejected route", bodies)
+	}
+
+	route.Nexthops[0].Tag = 0
+	err = route.Create(ctx, c)
+	if err != nil {
+		t.Fatalf("Create on v10.08: %v", err)
+	}
+	for _, body := range bodies {
+		if strings.Contains(body, "tag") {
+			t.Errorf("sent %s on v10.08, want no tag", body)
+		}
+	}
+	if _, ok := srv.Object("system/vrfs/default/static_routes/10.1.0.0%2F16/static_nexthops", "0"); !ok {
+		t.Error("next hop was not created on v10.08")
+	}
+}
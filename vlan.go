@@ -2,36 +2,172 @@ package aoscxgo
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
 	"strconv"
+	"strings"
+
+	"golang.org/x/exp/slices"
 )
 
 type Vlan struct {
 
 	// Connection properties.
-	VlanId       int                    `json:"id"`
-	Name         string                 `json:"name"`
-	Description  string                 `json:"description"`
-	AdminState   string                 `json:"admin_state"`
+	VlanId      int    `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	AdminState  string `json:"admin_state"`
+	// Voice marks the VLAN as voice VLAN.
+	Voice bool `json:"voice"`
+	// Snooping configures IGMP and MLD snooping.
+	Snooping VlanSnooping `json:"snooping"`
+
+	// Retrieved by Get. Type is "static", "dynamic" or "internal".
+	Type            string `json:"type"`
+	OperState       string `json:"oper_state"`
+	OperStateReason string `json:"oper_state_reason"`
+	// FloodEnabledSubsystems lists the subsystems flooding on the VLAN.
+	FloodEnabledSubsystems []string `json:"flood_enabled_subsystems"`
+	// Acls holds the ACLs applied to the VLAN.
+	Acls VlanAcls `json:"acls"`
+	// Members is retrieved by GetMembers.
+	Members VlanMembers `json:"members"`
+	// DeleteMode selects how Delete handles interfaces using the VLAN.
+	DeleteMode VlanDeleteMode `json:"delete_mode"`
+
+	// VlanDetails holds every attribute retrieved by Get as returned by the
+	// switch, including those without a field above.
 	VlanDetails  map[string]interface{} `json:"details"`
-	materialized bool                   `json:"materialized"`
-	uri          string                 `json:"uri"`
+	materialized bool
+	uri          string
+}
+
+// VlanSnooping holds the IGMP and MLD snooping settings of a VLAN.
+type VlanSnooping struct {
+	Igmp bool `json:"igmp"`
+	Mld  bool `json:"mld"`
+	// Ports on which snooping is blocked or leaves are processed fast,
+	// retrieved by Get.
+	IgmpBlockPorts     []string `json:"igmp_block_ports"`
+	IgmpFastleavePorts []string `json:"igmp_fastleave_ports"`
+	MldBlockPorts      []string `json:"mld_block_ports"`
+	MldFastleavePorts  []string `json:"mld_fastleave_ports"`
+}
+
+// VlanAcls holds the names of the ACLs applied to a VLAN per direction.
+type VlanAcls struct {
+	Ipv4In  string `json:"ipv4_in"`
+	Ipv4Out string `json:"ipv4_out"`
+	Ipv6In  string `json:"ipv6_in"`
+	Ipv6Out string `json:"ipv6_out"`
+	MacIn   string `json:"mac_in"`
+	MacOut  string `json:"mac_out"`
+}
+
+// VlanMembers holds the names of the interfaces carrying a VLAN.
+type VlanMembers struct {
+	// Access interfaces with the VLAN as access VLAN.
+	Access []string `json:"access"`
+	// Trunk interfaces allowing the VLAN, including those allowing all VLANs.
+	Trunk []string `json:"trunk"`
+	// Native trunk interfaces with the VLAN as native VLAN.
+	Native []string `json:"native"`
+}
+
+// vlanPayload is a VLAN as returned by the REST API. References are decoded
+// with referenceKeys as their format depends on the request depth.
+type vlanPayload struct {
+	Name                   *string  `json:"name"`
+	Description            *string  `json:"description"`
+	Admin                  *string  `json:"admin"`
+	Type                   *string  `json:"type"`
+	OperState              *string  `json:"oper_state"`
+	OperStateReason        *string  `json:"oper_state_reason"`
+	Voice                  *bool    `json:"voice"`
+	FloodEnabledSubsystems []string `json:"flood_enabled_subsystems"`
+	MgmdEnable             struct {
+		Igmp bool `json:"igmp"`
+		Mld  bool `json:"mld"`
+	} `json:"mgmd_enable"`
+	MgmdIgmpBlockPorts     json.RawMessage `json:"mgmd_igmp_block_ports"`
+	MgmdIgmpFastleavePorts json.RawMessage `json:"mgmd_igmp_fastleave_ports"`
+	MgmdMldBlockPorts      json.RawMessage `json:"mgmd_mld_block_ports"`
+	MgmdMldFastleavePorts  json.RawMessage `json:"mgmd_mld_fastleave_ports"`
+	Aclv4In                json.RawMessage `json:"aclv4_in_cfg"`
+	Aclv4Out               json.RawMessage `json:"aclv4_out_cfg"`
+	Aclv6In                json.RawMessage `json:"aclv6_in_cfg"`
+	Aclv6Out               json.RawMessage `json:"aclv6_out_cfg"`
+	AclmacIn               json.RawMessage `json:"aclmac_in_cfg"`
+	AclmacOut              json.RawMessage `json:"aclmac_out_cfg"`
+}
+
+// decode sets the fields of v from the payload.
+func (p *vlanPayload) decode(v *Vlan) {
+	if p.Name != nil {
+		v.Name = *p.Name
+	}
+	if p.Description != nil {
+		v.Description = *p.Description
+	}
+	if p.Admin != nil {
+		v.AdminState = *p.Admin
+	}
+	if p.Type != nil {
+		v.Type = *p.Type
+	}
+	if p.OperState != nil {
+		v.OperState = *p.OperState
+	}
+	if p.OperStateReason != nil {
+		v.OperStateReason = *p.OperStateReason
+	}
+	if p.Voice != nil {
+		v.Voice = *p.Voice
+	}
+	v.FloodEnabledSubsystems = p.FloodEnabledSubsystems
+
+	v.Snooping = VlanSnooping{
+		Igmp:               p.MgmdEnable.Igmp,
+		Mld:                p.MgmdEnable.Mld,
+		IgmpBlockPorts:     referenceKeys(p.MgmdIgmpBlockPorts),
+		IgmpFastleavePorts: referenceKeys(p.MgmdIgmpFastleavePorts),
+		MldBlockPorts:      referenceKeys(p.MgmdMldBlockPorts),
+		MldFastleavePorts:  referenceKeys(p.MgmdMldFastleavePorts),
+	}
+
+	v.Acls = VlanAcls{
+		Ipv4In:  aclName(p.Aclv4In),
+		Ipv4Out: aclName(p.Aclv4Out),
+		Ipv6In:  aclName(p.Aclv6In),
+		Ipv6Out: aclName(p.Aclv6Out),
+		MacIn:   aclName(p.AclmacIn),
+		MacOut:  aclName(p.AclmacOut),
+	}
+}
+
+// aclName returns the name of a referenced ACL, whose key is "name,type".
+func aclName(raw json.RawMessage) string {
+	keys := referenceKeys(raw)
+	if len(keys) == 0 {
+		return ""
+	}
+	name, _, _ := strings.Cut(keys[0], ",")
+	return name
 }
 
 // Create performs POST to create VLAN configuration on the given Client object.
-func (v *Vlan) Create(c *Client) error {
+func (v *Vlan) Create(ctx context.Context, c *Client) error {
 	base_uri := "system/vlans"
 	vlan_str := strconv.Itoa(v.VlanId)
 	url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri
 	v.uri = "/rest/" + c.Version + "/" + base_uri + "/" + vlan_str
 
 	if v.VlanId == 0 || v.Name == "" {
-		return &RequestError{
-			StatusCode: "Missing Required Values VlanId & Name",
-			Err:        errors.New("Create Error"),
-		}
+		return newValidationError("Vlan.Create", "VlanId", "missing required values VlanId & Name")
 	}
 
 	postMap := map[string]interface{}{
@@ -46,18 +182,27 @@ func (v *Vlan) Create(c *Client) error {
 	if v.AdminState != "" {
 		postMap["admin"] = v.AdminState
 	}
+	if v.Voice {
+		postMap["voice"] = true
+	}
+	if v.Snooping.Igmp || v.Snooping.Mld {
+		postMap["mgmd_enable"] = map[string]interface{}{
+			"igmp": v.Snooping.Igmp,
+			"mld":  v.Snooping.Mld,
+		}
+	}
 
 	postBody, _ := json.Marshal(postMap)
 
 	json_body := bytes.NewBuffer(postBody)
 
-	res := post(c, url, json_body)
+	res, err := post(ctx, c, url, json_body)
+	if err != nil {
+		return err
+	}
 
 	if res.Status != "201 Created" {
-		return &RequestError{
-			StatusCode: res.Status,
-			Err:        errors.New("Create Error"),
-		}
+		return newRequestError("Vlan.Create", res)
 	}
 
 	v.materialized = true
@@ -66,17 +211,13 @@ func (v *Vlan) Create(c *Client) error {
 }
 
 // Update performs PATCH to update VLAN configuration on the given Client object.
-func (v *Vlan) Update(c *Client) error {
-	base_uri := "system/vlans"
-	vlan_str := strconv.Itoa(v.VlanId)
-
-	url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri + "/" + vlan_str
-
+// Voice and Snooping are only sent when set, so a Vlan built with some fields
+// does not clear them. To clear them call Get, change the fields and Update,
+// a Vlan retrieved by Get or ListVlans or created by Create sends them as
+// they are.
+func (v *Vlan) Update(ctx context.Context, c *Client) error {
 	if v.VlanId == 0 || v.Name == "" {
-		return &RequestError{
-			StatusCode: "Missing Required Values VlanId & Name",
-			Err:        errors.New("Update Error"),
-		}
+		return newValidationError("Vlan.Update", "VlanId", "missing required values VlanId & Name")
 	}
 	patchMap := map[string]interface{}{
 		"name":        v.Name,
@@ -85,90 +226,346 @@ func (v *Vlan) Update(c *Client) error {
 		"type":        "static", //default value
 	}
 
+	// Unless the Vlan holds the state of the switch, unset flags are left
+	// alone rather than cleared
+	if v.Voice || v.materialized {
+		patchMap["voice"] = v.Voice
+	}
+	if v.Snooping.Igmp || v.Snooping.Mld || v.materialized {
+		patchMap["mgmd_enable"] = map[string]interface{}{
+			"igmp": v.Snooping.Igmp,
+			"mld":  v.Snooping.Mld,
+		}
+	}
+
+	return patchVlan(ctx, c, "Vlan.Update", v.VlanId, patchMap)
+}
+
+// patchVlan performs PATCH to update the given attributes of a VLAN.
+func patchVlan(ctx context.Context, c *Client, op string, vlan_id int, patchMap map[string]interface{}) error {
+	base_uri := "system/vlans"
+	vlan_str := strconv.Itoa(vlan_id)
+
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri + "/" + vlan_str
+
 	patchBody, _ := json.Marshal(patchMap)
 
 	json_body := bytes.NewBuffer(patchBody)
 
-	res := patch(c, url, json_body)
+	res, err := patch(ctx, c, url, json_body)
+	if err != nil {
+		return err
+	}
 
 	if res.Status != "204 No Content" {
-		return &RequestError{
-			StatusCode: res.Status,
-			Err:        errors.New("Update Error"),
-		}
+		return newRequestError(op, res)
 	}
 
 	return nil
 }
 
-// Delete performs DELETE to remove VLAN configuration from the given Client object.
-func (v *Vlan) Delete(c *Client) error {
-	base_uri := "system/vlans"
+// Delete performs DELETE to remove VLAN configuration from the given Client
+// object. The VLAN is never deleted while a VlanInterface exists for it. By
+// default it is not deleted either while interfaces reference it as access,
+// native or allowed trunk VLAN, a *VlanInUseError listing the references is
+// returned instead. With DeleteMode set to VlanDeleteCascade these
+// interfaces are detached first: access interfaces move to VLAN 1, native
+// VLANs are reset to VLAN 1 and the VLAN is removed from trunks. Trunks
+// allowing all VLANs do not reference the VLAN. A trunk allowing only this
+// VLAN is never emptied, as an empty list allows all VLANs, and the
+// *VlanInUseError lists it in Trunks with nothing detached.
+func (v *Vlan) Delete(ctx context.Context, c *Client) error {
+	in_use := &VlanInUseError{VlanId: v.VlanId}
+
 	// Check if Vlan Interface exists, if so then fail
 	vlan_interface_id := fmt.Sprintf("vlan%d", v.VlanId)
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/system/interfaces/" + vlan_interface_id
 
-	url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri + "/" + vlan_interface_id
+	res, _, err := get(ctx, c, url)
+	if err != nil {
+		return err
+	}
 
-	res, _ := get(c, url)
+	if res.StatusCode == http.StatusOK {
+		in_use.VlanInterface = vlan_interface_id
+	} else if res.StatusCode != http.StatusNotFound {
+		return newRequestError("Vlan.Delete", res)
+	}
 
-	if res.Status == "200 OK" {
-		err_str := "VlanInterface " + vlan_interface_id + " exists - delete VlanInterface before deleting Vlan " + vlan_interface_id
-		return &RequestError{
-			StatusCode: err_str,
-			Err:        errors.New("Delete Error"),
-		}
+	interfaces, err := getInterfaceVlans(ctx, c, "Vlan.Delete")
+	if err != nil {
+		return err
 	}
 
 	vlan_str := strconv.Itoa(v.VlanId)
+	for _, i := range interfaces {
+		if i.references(vlan_str) {
+			in_use.Interfaces = append(in_use.Interfaces, i.name)
+		}
+		if i.onlyAllows([]string{vlan_str}) {
+			in_use.Trunks = append(in_use.Trunks, i.name)
+		}
+	}
+	sort.Strings(in_use.Interfaces)
+	sort.Strings(in_use.Trunks)
+
+	if in_use.VlanInterface != "" || len(in_use.Trunks) > 0 || (len(in_use.Interfaces) > 0 && v.DeleteMode != VlanDeleteCascade) {
+		return in_use
+	}
+
+	for _, i := range interfaces {
+		err = i.detach(ctx, c, "Vlan.Delete", []string{vlan_str})
+		if err != nil {
+			return err
+		}
+	}
+
+	return deleteVlan(ctx, c, "Vlan.Delete", v.VlanId)
+}
+
+// deleteVlan performs DELETE to remove a VLAN.
+func deleteVlan(ctx context.Context, c *Client, op string, vlan_id int) error {
+	base_uri := "system/vlans"
+	vlan_str := strconv.Itoa(vlan_id)
 
-	url = "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri + "/" + vlan_str
-	res = delete(c, url)
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri + "/" + vlan_str
+	res, err := delete(ctx, c, url)
+	if err != nil {
+		return err
+	}
 
 	if res.Status != "204 No Content" {
-		return &RequestError{
-			StatusCode: res.Status,
-			Err:        errors.New("Delete Error"),
+		return newRequestError(op, res)
+	}
+
+	return nil
+}
+
+// VlanDeleteMode selects how Vlan.Delete handles interfaces referencing the
+// VLAN.
+type VlanDeleteMode int
+
+const (
+	// VlanDeleteRefuse refuses to delete a VLAN referenced by interfaces.
+	VlanDeleteRefuse VlanDeleteMode = iota
+	// VlanDeleteCascade detaches referencing interfaces before deleting.
+	VlanDeleteCascade
+)
+
+// VlanInUseError is returned by Vlan.Delete and VlanRange.Delete when the
+// VLAN is still referenced. It matches ErrConflict.
+type VlanInUseError struct {
+	VlanId int
+	// VlanInterface is the name of the VlanInterface of the VLAN, if any.
+	VlanInterface string
+	// Interfaces referencing the VLAN as access, native or trunk VLAN.
+	Interfaces []string
+	// Trunks allowing no other VLAN, which VlanDeleteCascade does not
+	// detach as an empty list allows all VLANs.
+	Trunks []string
+}
+
+// Error lists the references to the VLAN.
+func (e *VlanInUseError) Error() string {
+	var references []string
+	if e.VlanInterface != "" {
+		references = append(references, "VlanInterface "+e.VlanInterface+" - delete VlanInterface first")
+	}
+	if len(e.Interfaces) > 0 {
+		references = append(references, "interfaces "+strings.Join(e.Interfaces, ", "))
+	}
+	if len(e.Trunks) > 0 {
+		references = append(references, "trunks "+strings.Join(e.Trunks, ", ")+" allowing no other VLAN - allow another VLAN first")
+	}
+	return fmt.Sprintf("Vlan.Delete: VLAN %d is in use by %s", e.VlanId, strings.Join(references, " and "))
+}
+
+// Is matches ErrConflict.
+func (e *VlanInUseError) Is(target error) bool {
+	return target == ErrConflict
+}
+
+// interfaceVlans holds the VLAN settings of an interface.
+type interfaceVlans struct {
+	name string
+	// mode is empty for routed interfaces
+	mode string
+	// tag is the access or native VLAN
+	tag string
+	// trunks lists the allowed VLANs, all are allowed if empty
+	trunks []string
+}
+
+// getInterfaceVlans performs GET to retrieve the VLAN settings of all
+// interfaces in one request. Routed interfaces have an empty mode.
+func getInterfaceVlans(ctx context.Context, c *Client, op string) ([]interfaceVlans, error) {
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/system/interfaces?depth=2&attributes=name,vlan_mode,vlan_tag,vlan_trunks"
+
+	res, _, err := get(ctx, c, url)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, newRequestError(op, res)
+	}
+
+	payloads := map[string]struct {
+		VlanMode   *string         `json:"vlan_mode"`
+		VlanTag    json.RawMessage `json:"vlan_tag"`
+		VlanTrunks json.RawMessage `json:"vlan_trunks"`
+	}{}
+	err = json.NewDecoder(res.Body).Decode(&payloads)
+	if err != nil {
+		return nil, fmt.Errorf("%s: decoding interfaces: %w", op, err)
+	}
+
+	interfaces := []interfaceVlans{}
+	for name, payload := range payloads {
+		// Routed interfaces, including VLAN interfaces, have no VLAN mode
+		// and reference no VLAN
+		if payload.VlanMode == nil {
+			interfaces = append(interfaces, interfaceVlans{name: name})
+			continue
+		}
+
+		// Without a tag the access or native VLAN is VLAN 1
+		tag := "1"
+		if tags := referenceKeys(payload.VlanTag); len(tags) > 0 {
+			tag = tags[0]
+		}
+
+		interfaces = append(interfaces, interfaceVlans{
+			name:   name,
+			mode:   *payload.VlanMode,
+			tag:    tag,
+			trunks: referenceKeys(payload.VlanTrunks),
+		})
+	}
+
+	return interfaces, nil
+}
+
+// isTrunk returns true for trunk interfaces.
+func (i *interfaceVlans) isTrunk() bool {
+	return i.mode == "native-untagged" || i.mode == "native-tagged" || i.mode == "trunk"
+}
+
+// isNative returns true if the VLAN is the native VLAN of the trunk.
+func (i *interfaceVlans) isNative(vlan_str string) bool {
+	return i.isTrunk() && i.mode != "trunk" && i.tag == vlan_str
+}
+
+// allows returns true if the trunk allows the VLAN.
+func (i *interfaceVlans) allows(vlan_str string) bool {
+	return i.isTrunk() && (len(i.trunks) == 0 || slices.Contains(i.trunks, vlan_str))
+}
+
+// references returns true if the interface names the VLAN explicitly as
+// access, native or allowed trunk VLAN.
+func (i *interfaceVlans) references(vlan_str string) bool {
+	return (i.mode == "access" && i.tag == vlan_str) || i.isNative(vlan_str) ||
+		(i.isTrunk() && slices.Contains(i.trunks, vlan_str))
+}
+
+// onlyAllows returns true if the trunk names allowed VLANs and all of them
+// are among the given VLANs, so detaching these would leave it allowing all
+// VLANs.
+func (i *interfaceVlans) onlyAllows(vlan_strs []string) bool {
+	if !i.isTrunk() || len(i.trunks) == 0 {
+		return false
+	}
+	for _, trunk := range i.trunks {
+		if !slices.Contains(vlan_strs, trunk) {
+			return false
+		}
+	}
+	return true
+}
+
+// detach performs PATCH to remove the references to the given VLANs from
+// the interface. Callers check onlyAllows first, a trunk is never emptied.
+func (i *interfaceVlans) detach(ctx context.Context, c *Client, op string, vlan_strs []string) error {
+	base_uri := "system/interfaces"
+	vlan_uri := "/rest/" + c.Version + "/system/vlans/"
+	patchMap := map[string]interface{}{}
+
+	if i.mode == "access" && slices.Contains(vlan_strs, i.tag) {
+		patchMap["vlan_tag"] = map[string]interface{}{"1": vlan_uri + "1"}
+	}
+	if i.isTrunk() && i.mode != "trunk" && slices.Contains(vlan_strs, i.tag) {
+		patchMap["vlan_tag"] = nil
+	}
+	if i.isTrunk() && len(i.trunks) > 0 {
+		vlan_trunks := map[string]interface{}{}
+		for _, trunk := range i.trunks {
+			if !slices.Contains(vlan_strs, trunk) {
+				vlan_trunks[trunk] = vlan_uri + trunk
+			}
 		}
+		if len(vlan_trunks) == 0 {
+			return newValidationError(op, "VlanId", "detaching VLANs "+strings.Join(vlan_strs, ",")+" would make trunk "+i.name+" allow all VLANs")
+		}
+		if len(vlan_trunks) != len(i.trunks) {
+			patchMap["vlan_trunks"] = vlan_trunks
+		}
+	}
+
+	if len(patchMap) == 0 {
+		return nil
+	}
+
+	patchBody, _ := json.Marshal(patchMap)
+
+	json_body := bytes.NewBuffer(patchBody)
+
+	int_str := url.PathEscape(i.name)
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri + "/" + int_str
+	res, err := patch(ctx, c, url, json_body)
+	if err != nil {
+		return err
 	}
 
+	if res.StatusCode != http.StatusNoContent {
+		return newRequestError(op, res)
+	}
+
+	c.logger().InfoContext(ctx, "aoscxgo detached interface from VLANs", "interface", i.name, "vlans", vlan_strs)
+
 	return nil
 }
 
 // Get performs GET to retrieve VLAN configuration for the given Client object.
-func (v *Vlan) Get(c *Client) error {
+func (v *Vlan) Get(ctx context.Context, c *Client) error {
 	base_uri := "system/vlans"
 	vlan_str := strconv.Itoa(v.VlanId)
 	v.uri = "/rest/" + c.Version + "/" + base_uri + "/" + vlan_str
 
 	url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri + "/" + vlan_str
 
-	res, body := get(c, url)
+	res, body, err := get(ctx, c, url)
+	if err != nil {
+		return err
+	}
 
 	if res.Status != "200 OK" {
 		v.materialized = false
-		return &RequestError{
-			StatusCode: res.Status + url,
-			Err:        errors.New("Retrieval Error"),
-		}
+		return newRequestError("Vlan.Get", res)
 	}
 
+	payload := vlanPayload{}
+	err = json.NewDecoder(res.Body).Decode(&payload)
+	if err != nil {
+		return fmt.Errorf("Vlan.Get: decoding VLAN %d: %w", v.VlanId, err)
+	}
+	payload.decode(v)
+
 	if v.VlanDetails == nil {
 		v.VlanDetails = map[string]interface{}{}
 	}
 
 	for key, value := range body {
 		v.VlanDetails[key] = value
-		if key == "name" && value != nil {
-			v.Name = value.(string)
-		}
-		if key == "description" && value != nil {
-			v.Description = value.(string)
-		}
-
-		if key == "admin" {
-			v.AdminState = value.(string)
-		}
-
 	}
 
 	v.materialized = true
@@ -185,3 +582,81 @@ func (v *Vlan) GetStatus() bool {
 func (v *Vlan) GetURI() string {
 	return v.uri
 }
+
+// ListVlans performs GET to retrieve all VLANs configured on the given Client
+// object in a single request, ordered by VlanId.
+func ListVlans(ctx context.Context, c *Client) ([]Vlan, error) {
+	base_uri := "system/vlans"
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri + "?depth=2&attributes=id,name,description,admin,type,voice,mgmd_enable,oper_state,oper_state_reason"
+
+	res, body, err := get(ctx, c, url)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, newRequestError("ListVlans", res)
+	}
+
+	payloads := map[string]vlanPayload{}
+	err = json.NewDecoder(res.Body).Decode(&payloads)
+	if err != nil {
+		return nil, fmt.Errorf("ListVlans: decoding VLANs: %w", err)
+	}
+
+	vlans := []Vlan{}
+	for key, payload := range payloads {
+		vlan_id, err := strconv.Atoi(key)
+		if err != nil {
+			continue
+		}
+		details, _ := body[key].(map[string]interface{})
+
+		vlan := Vlan{
+			VlanId:       vlan_id,
+			VlanDetails:  details,
+			materialized: true,
+			uri:          "/rest/" + c.Version + "/" + base_uri + "/" + key,
+		}
+		payload.decode(&vlan)
+
+		vlans = append(vlans, vlan)
+	}
+
+	sort.Slice(vlans, func(a, b int) bool {
+		return vlans[a].VlanId < vlans[b].VlanId
+	})
+
+	return vlans, nil
+}
+
+// GetMembers performs GET to retrieve the interfaces carrying the VLAN from
+// the given Client object and stores them in Members.
+func (v *Vlan) GetMembers(ctx context.Context, c *Client) error {
+	interfaces, err := getInterfaceVlans(ctx, c, "Vlan.GetMembers")
+	if err != nil {
+		return err
+	}
+
+	vlan_str := strconv.Itoa(v.VlanId)
+	members := VlanMembers{}
+
+	for _, i := range interfaces {
+		if i.mode == "access" && i.tag == vlan_str {
+			members.Access = append(members.Access, i.name)
+		}
+		if i.isNative(vlan_str) {
+			members.Native = append(members.Native, i.name)
+		}
+		if i.allows(vlan_str) {
+			members.Trunk = append(members.Trunk, i.name)
+		}
+	}
+
+	sort.Strings(members.Access)
+	sort.Strings(members.Trunk)
+	sort.Strings(members.Native)
+	v.Members = members
+
+	return nil
+}
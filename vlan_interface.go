@@ -2,8 +2,8 @@ package aoscxgo
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -21,11 +21,11 @@ type VlanInterface struct {
 	Ipv6             []interface{}          `json:"ipv6"`
 	Vrf              string                 `json:"vrf"`
 	InterfaceDetails map[string]interface{} `json:"details"`
-	materialized     bool                   `json:"materialized"`
+	materialized     bool
 }
 
 // Create performs POST to create VlanInterface configuration on the given Client object.
-func (v *VlanInterface) Create(c *Client) error {
+func (v *VlanInterface) Create(ctx context.Context, c *Client) error {
 	base_uri := "system/interfaces"
 
 	vlan_interface_id := fmt.Sprintf("vlan%d", v.Vlan.VlanId)
@@ -33,10 +33,7 @@ func (v *VlanInterface) Create(c *Client) error {
 	postMap := map[string]interface{}{}
 
 	if v.Vlan.VlanId == 0 {
-		return &RequestError{
-			StatusCode: "Missing Required Values VlanId",
-			Err:        errors.New("Create Error"),
-		}
+		return newValidationError("VlanInterface.Create", "VlanId", "missing required values VlanId")
 	}
 
 	// Retrieve VLAN from sw if existing
@@ -44,13 +41,15 @@ func (v *VlanInterface) Create(c *Client) error {
 		VlanId: v.Vlan.VlanId,
 	}
 
-	err := tmp_vlan.Get(c)
+	err := tmp_vlan.Get(ctx, c)
 
 	if err != nil {
-		return &RequestError{
-			StatusCode: fmt.Sprintf("Missing VLAN %d - Create Vlan before VlanInterface", v.Vlan.VlanId),
-			Err:        errors.New("Create Error"),
-		}
+		return fmt.Errorf("VlanInterface.Create: missing VLAN %d - Create Vlan before VlanInterface: %w", v.Vlan.VlanId, err)
+	}
+
+	err = checkVrfExists(ctx, c, "VlanInterface.Create", v.Vrf)
+	if err != nil {
+		return err
 	}
 
 	url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri
@@ -61,11 +60,7 @@ func (v *VlanInterface) Create(c *Client) error {
 	postMap["type"] = "vlan"
 	postMap["interfaces"] = []string{fmt.Sprintf("/rest/%s/system/vlans/%s", c.Version, strconv.Itoa(v.Vlan.VlanId))}
 
-	if v.Vrf == "" {
-		postMap["vrf"] = "/rest/" + c.Version + "/system/vrfs/" + "default"
-	} else {
-		postMap["vrf"] = "/rest/" + c.Version + "/system/vrfs/" + v.Vrf
-	}
+	postMap["vrf"] = vrfURI(c, v.Vrf)
 
 	//check if it's ipv6
 	// Validate ipv4 address
@@ -79,40 +74,26 @@ func (v *VlanInterface) Create(c *Client) error {
 			postMap["ip4_address"] = str_ipv4_1
 			postMap["ip4_address_secondary"] = nil
 		} else {
-			status_str := "Invalid Required Value: Ipv4 - ensure addresses are in same ipv4 format: " + str_ipv4_1
-			return &RequestError{
-				StatusCode: status_str,
-				Err:        errors.New("Create Error"),
-			}
+			status_str := "ensure addresses are in ipv4 format: " + str_ipv4_1
+			return newValidationError("VlanInterface.Create", "Ipv4", status_str)
 		}
 	} else if len(v.Ipv4) > 1 {
 		str_ipv4_1 := fmt.Sprintf("%v", v.Ipv4[0])
 		if checkIPAddress(str_ipv4_1) {
 			postMap["ip4_address"] = v.Ipv4[0]
 		} else {
-			fmt.Println("THIS IS THE VALUE vvv")
-			fmt.Println(str_ipv4_1)
-			status_str := "Invalid Required Value: Ipv4 - ensure addresses are in ipv4 format: " + str_ipv4_1
-			return &RequestError{
-				StatusCode: status_str,
-				Err:        errors.New("Create Error"),
-			}
+			status_str := "ensure addresses are in ipv4 format: " + str_ipv4_1
+			return newValidationError("VlanInterface.Create", "Ipv4", status_str)
 		}
 
 		var tmp_splice []string
 		for index := 1; index < len(v.Ipv4); index++ {
 			str_ipv4_tmp := fmt.Sprintf("%v", v.Ipv4[index])
-			fmt.Println("THIS IS THE VALUE vvv")
-			fmt.Println(str_ipv4_tmp)
-			fmt.Println(v.Ipv4)
 			if checkIPAddress(str_ipv4_tmp) {
 				tmp_splice = append(tmp_splice, str_ipv4_tmp)
 			} else {
-				status_str := "Invalid Required Value: Ipv4 - ensure addresses are in ipv4 format: " + str_ipv4_tmp
-				return &RequestError{
-					StatusCode: status_str,
-					Err:        errors.New("Create Error"),
-				}
+				status_str := "ensure addresses are in ipv4 format: " + str_ipv4_tmp
+				return newValidationError("VlanInterface.Create", "Ipv4", status_str)
 			}
 		}
 		postMap["ip4_address_secondary"] = tmp_splice
@@ -130,18 +111,15 @@ func (v *VlanInterface) Create(c *Client) error {
 
 	json_body := bytes.NewBuffer(postBody)
 
-	res := post(c, url, json_body)
+	res, err := post(ctx, c, url, json_body)
+	if err != nil {
+		return err
+	}
 
 	if res.StatusCode != http.StatusCreated {
-		return &RequestError{
-			StatusCode: res.Status,
-			Err:        errors.New("Create Error"),
-		}
+		return newRequestError("VlanInterface.Create", res)
 	}
 
-	// string to track ipv6 Create success
-	failed_ipv6 := ""
-
 	if len(v.Ipv6) == 0 {
 		// What are default values when no ipv6 but routing enabled
 		postMap["ip6_addresses"] = nil
@@ -168,23 +146,19 @@ func (v *VlanInterface) Create(c *Client) error {
 
 					ip6_url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri + "/" + vlan_interface_id + "/" + "ip6_addresses"
 
-					res := post(c, ip6_url, json_body)
+					res, err := post(ctx, c, ip6_url, json_body)
+					if err != nil {
+						return err
+					}
 
 					if res.StatusCode != http.StatusCreated {
-						failed_ipv6 = fmt.Sprintf("\nip6_addresses failed to create %v\nstatus code %v", v.Ipv6, res.Status)
-						return &RequestError{
-							StatusCode: failed_ipv6,
-							Err:        errors.New("Create Error"),
-						}
+						return newRequestError("VlanInterface.Create", res)
 					}
 
 				} else {
-					status_str := "Invalid Required Value: Ipv6 - ensure addresses are in ipv6 address/mask format:" +
+					status_str := "ensure addresses are in ipv6 address/mask format: " +
 						ip_address.(string)
-					return &RequestError{
-						StatusCode: status_str,
-						Err:        errors.New("Create Error"),
-					}
+					return newValidationError("VlanInterface.Create", "Ipv6", status_str)
 				}
 			}
 		}
@@ -197,7 +171,7 @@ func (v *VlanInterface) Create(c *Client) error {
 }
 
 // Update performs PATCH or PUT to update VlanInterface configuration on the given Client object.
-func (v *VlanInterface) Update(c *Client, use_put bool) error {
+func (v *VlanInterface) Update(ctx context.Context, c *Client, use_put bool) error {
 	base_uri := "system/interfaces"
 
 	updateMap := map[string]interface{}{}
@@ -206,25 +180,22 @@ func (v *VlanInterface) Update(c *Client, use_put bool) error {
 
 	tmp_vlan_int := VlanInterface{Vlan: v.Vlan}
 
+	err := checkVrfExists(ctx, c, "VlanInterface.Update", v.Vrf)
+	if err != nil {
+		return err
+	}
+
 	if use_put {
-		err := tmp_vlan_int.Get(c)
+		err := tmp_vlan_int.Get(ctx, c)
 		if err != nil {
-			error_str := "Missing VlanInterface - " + vlan_interface_id
-			return &RequestError{
-				StatusCode: error_str,
-				Err:        errors.New("Update Error"),
-			}
+			return fmt.Errorf("VlanInterface.Update: missing VlanInterface %s: %w", vlan_interface_id, err)
 		}
 		for key, value := range tmp_vlan_int.InterfaceDetails {
 			updateMap[key] = value
 		}
 	}
 
-	if v.Vrf == "" {
-		updateMap["vrf"] = "/rest/" + c.Version + "/system/vrfs/" + "default"
-	} else {
-		updateMap["vrf"] = "/rest/" + c.Version + "/system/vrfs/" + v.Vrf
-	}
+	updateMap["vrf"] = vrfURI(c, v.Vrf)
 
 	//check if it's ipv6
 	// Validate ipv4 address
@@ -238,11 +209,8 @@ func (v *VlanInterface) Update(c *Client, use_put bool) error {
 			updateMap["ip4_address"] = str_ipv4_1
 			updateMap["ip4_address_secondary"] = nil
 		} else {
-			status_str := "Invalid Required Value: Ipv4 - ensure addresses are in poop ipv4 format: " + str_ipv4_1
-			return &RequestError{
-				StatusCode: status_str,
-				Err:        errors.New("Update Error"),
-			}
+			status_str := "ensure addresses are in ipv4 format: " + str_ipv4_1
+			return newValidationError("VlanInterface.Update", "Ipv4", status_str)
 		}
 
 	} else if len(v.Ipv4) > 1 {
@@ -250,27 +218,18 @@ func (v *VlanInterface) Update(c *Client, use_put bool) error {
 		if checkIPAddress(str_ipv4_1) {
 			updateMap["ip4_address"] = v.Ipv4[0]
 		} else {
-			status_str := "Invalid Required Value: Ipv4 - ensure addresses are in butt ipv4 format: \n" + str_ipv4_1
-			return &RequestError{
-				StatusCode: status_str,
-				Err:        errors.New("Update Error"),
-			}
+			status_str := "ensure addresses are in ipv4 format: " + str_ipv4_1
+			return newValidationError("VlanInterface.Update", "Ipv4", status_str)
 		}
 
 		var tmp_splice []string
 		for index := 1; index < len(v.Ipv4); index++ {
 			str_ipv4_tmp := fmt.Sprintf("%v", v.Ipv4[index])
-			fmt.Println("THIS IS THE VALUE vvv")
-			fmt.Println(str_ipv4_tmp)
-			fmt.Println(v.Ipv4)
 			if checkIPAddress(str_ipv4_tmp) {
 				tmp_splice = append(tmp_splice, str_ipv4_tmp)
 			} else {
-				status_str := "Invalid Required Value: Ipv4 - ensure addresses are in ipv4 fdafdas format: " + str_ipv4_tmp
-				return &RequestError{
-					StatusCode: status_str,
-					Err:        errors.New("Update Error"),
-				}
+				status_str := "ensure addresses are in ipv4 format: " + str_ipv4_tmp
+				return newValidationError("VlanInterface.Update", "Ipv4", status_str)
 			}
 		}
 		updateMap["ip4_address_secondary"] = tmp_splice
@@ -281,24 +240,24 @@ func (v *VlanInterface) Update(c *Client, use_put bool) error {
 		updateMap["ip6_addresses"] = nil
 		// retrieve what's existing on the switch and remove
 		ip6_url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri + "/" + vlan_interface_id + "/" + "ip6_addresses"
-		res, body := get(c, ip6_url)
+		res, body, err := get(ctx, c, ip6_url)
+		if err != nil {
+			return err
+		}
 
 		if res.StatusCode != http.StatusOK {
-			return &RequestError{
-				StatusCode: res.Status,
-				Err:        errors.New("Retrieval Error"),
-			}
+			return newRequestError("VlanInterface.Update", res)
 		}
 		for key, _ := range body {
 			tmp_ip6_str := url.QueryEscape(key)
 			del_ip6_url := ip6_url + "/" + tmp_ip6_str
-			res := delete(c, del_ip6_url)
+			res, err := delete(ctx, c, del_ip6_url)
+			if err != nil {
+				return err
+			}
 
 			if res.StatusCode != http.StatusNoContent {
-				return &RequestError{
-					StatusCode: res.Status,
-					Err:        errors.New("Delete Error"),
-				}
+				return newRequestError("VlanInterface.Update", res)
 			}
 		}
 	} else if len(v.Ipv6) > 0 {
@@ -311,13 +270,13 @@ func (v *VlanInterface) Update(c *Client, use_put bool) error {
 		// iterate over the list of IPs
 		// delete the ones that aren't provided
 
-		res, body := get(c, ip6_url)
+		res, body, err := get(ctx, c, ip6_url)
+		if err != nil {
+			return err
+		}
 
 		if res.StatusCode != http.StatusOK {
-			return &RequestError{
-				StatusCode: res.Status,
-				Err:        errors.New("Retrieval Error"),
-			}
+			return newRequestError("VlanInterface.Update", res)
 		}
 
 		var ipv6_slice []string
@@ -333,13 +292,13 @@ func (v *VlanInterface) Update(c *Client, use_put bool) error {
 			if !slices.Contains(ipv6_slice, key) {
 				tmp_ip6_str := url.QueryEscape(key)
 				del_ip6_url := ip6_url + "/" + tmp_ip6_str
-				res := delete(c, del_ip6_url)
+				res, err := delete(ctx, c, del_ip6_url)
+				if err != nil {
+					return err
+				}
 
 				if res.StatusCode != http.StatusNoContent {
-					return &RequestError{
-						StatusCode: res.Status,
-						Err:        errors.New("Delete Error"),
-					}
+					return newRequestError("VlanInterface.Update", res)
 				}
 
 			} else {
@@ -362,25 +321,20 @@ func (v *VlanInterface) Update(c *Client, use_put bool) error {
 
 				json_body := bytes.NewBuffer(ipv6body)
 
-				res := post(c, ip6_url, json_body)
+				res, err := post(ctx, c, ip6_url, json_body)
+				if err != nil {
+					return err
+				}
 
 				// include logic to check if address is existing?
 				if res.StatusCode != http.StatusCreated {
-					status_str := "ip6_addresses failed to update " +
-						str_ipv6 + " status " + res.Status
-					return &RequestError{
-						StatusCode: status_str,
-						Err:        errors.New("Update Error"),
-					}
+					return newRequestError("VlanInterface.Update", res)
 				}
 
 			} else if !checkIPAddress(str_ipv6) {
-				status_str := "Invalid Required Value: Ipv6 - ensure addresses are in ipv6 address/mask format:" +
+				status_str := "ensure addresses are in ipv6 address/mask format: " +
 					str_ipv6
-				return &RequestError{
-					StatusCode: status_str,
-					Err:        errors.New("Update Error"),
-				}
+				return newValidationError("VlanInterface.Update", "Ipv6", status_str)
 			}
 		}
 	}
@@ -415,25 +369,21 @@ func (v *VlanInterface) Update(c *Client, use_put bool) error {
 	json_body := bytes.NewBuffer(updateBody)
 
 	if use_put {
-		res := put(c, url, json_body)
+		res, err := put(ctx, c, url, json_body)
+		if err != nil {
+			return err
+		}
 		if res.StatusCode != http.StatusOK {
-			status_str := url + "||" + string(updateBody) + "PUT status code = " + res.Status
-			return &RequestError{
-				//StatusCode: res.Status,
-				StatusCode: status_str,
-				Err:        errors.New("Update Error"),
-			}
+			return newRequestError("VlanInterface.Update", res)
 		}
 
 	} else {
-		res := patch(c, url, json_body)
+		res, err := patch(ctx, c, url, json_body)
+		if err != nil {
+			return err
+		}
 		if res.StatusCode != http.StatusNoContent {
-			status_str := string(updateBody) + "PATCH status code = " + res.Status
-			return &RequestError{
-				//StatusCode: res.Status,
-				StatusCode: status_str,
-				Err:        errors.New("Update Error"),
-			}
+			return newRequestError("VlanInterface.Update", res)
 		}
 	}
 
@@ -443,51 +393,50 @@ func (v *VlanInterface) Update(c *Client, use_put bool) error {
 }
 
 // Delete performs DELETE to remove VlanInterface configuration from the given Client object.
-func (v *VlanInterface) Delete(c *Client) error {
+func (v *VlanInterface) Delete(ctx context.Context, c *Client) error {
 	base_uri := "system/interfaces"
 	if v.Vlan.VlanId == 0 {
-		return &RequestError{
-			StatusCode: "Missing VlanId unable to configure VlanInterface",
-			Err:        errors.New("Delete Error"),
-		}
+		return newValidationError("VlanInterface.Delete", "VlanId", "missing VlanId unable to configure VlanInterface")
 	}
 	vlan_interface_id := fmt.Sprintf("vlan%d", v.Vlan.VlanId)
 
 	url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri + "/" + vlan_interface_id
 
-	res := delete(c, url)
+	res, err := delete(ctx, c, url)
+	if err != nil {
+		return err
+	}
 
 	if res.StatusCode != http.StatusNoContent && res.StatusCode != http.StatusOK {
-		return &RequestError{
-			StatusCode: res.Status,
-			Err:        errors.New("Delete Error"),
-		}
+		return newRequestError("VlanInterface.Delete", res)
 	}
 
 	return nil
 }
 
 // Get performs GET to retrieve VlanInterface configuration from the given Client object.
-func (v *VlanInterface) Get(c *Client) error {
+func (v *VlanInterface) Get(ctx context.Context, c *Client) error {
 	base_uri := "system/interfaces"
 	if v.Vlan.VlanId == 0 {
-		return &RequestError{
-			StatusCode: "Missing VlanId unable to configure VlanInterface",
-			Err:        errors.New("Get Error"),
-		}
+		return newValidationError("VlanInterface.Get", "VlanId", "missing VlanId unable to configure VlanInterface")
 	}
 	vlan_interface_id := fmt.Sprintf("vlan%d", v.Vlan.VlanId)
 
 	url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri + "/" + vlan_interface_id + "?selector=writable"
 
-	res, body := get(c, url)
+	res, body, err := get(ctx, c, url)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		v.materialized = false
+		return newRequestError("VlanInterface.Get", res)
+	}
 
-	if res.StatusCode != http.StatusOK || len(body) <= 1 {
+	if len(body) <= 1 {
 		v.materialized = false
-		return &RequestError{
-			StatusCode: res.Status,
-			Err:        errors.New("Retrieval Error"),
-		}
+		return fmt.Errorf("VlanInterface.Get: %s: %w", vlan_interface_id, ErrNotFound)
 	}
 
 	if v.InterfaceDetails == nil {
@@ -508,8 +457,7 @@ func (v *VlanInterface) Get(c *Client) error {
 		if key == "ip4_address" && value != nil {
 			var tmp_splice []interface{}
 			tmp_splice = append(tmp_splice, value.(string))
-			if val, ok := body["ip4_address_secondary"]; ok {
-				tmp_addr := val.([]interface{})
+			if tmp_addr, ok := body["ip4_address_secondary"].([]interface{}); ok {
 				if len(tmp_addr) > 0 {
 					for index := 0; index < len(tmp_addr); index++ {
 						tmp_splice = append(tmp_splice, tmp_addr[index])
@@ -531,13 +479,13 @@ func (v *VlanInterface) Get(c *Client) error {
 
 	ip6_url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri + "/" + vlan_interface_id + "/" + "ip6_addresses"
 
-	res, body = get(c, ip6_url)
+	res, body, err = get(ctx, c, ip6_url)
+	if err != nil {
+		return err
+	}
 
 	if res.StatusCode != http.StatusOK {
-		return &RequestError{
-			StatusCode: res.Status,
-			Err:        errors.New("Retrieval Error"),
-		}
+		return newRequestError("VlanInterface.Get", res)
 	}
 
 	var ipv6_slice []string
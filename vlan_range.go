@@ -0,0 +1,361 @@
+package aoscxgo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/exp/slices"
+)
+
+// DefaultVlanRangeConcurrency is the number of VLANs a VlanRange configures
+// in parallel when Concurrency is not set.
+const DefaultVlanRangeConcurrency = 8
+
+// VlanRange creates, updates or deletes many VLANs at once, e.g. to
+// provision a leaf switch. The VLANs are configured in parallel with at most
+// Concurrency requests in flight, further limited by the request limits of
+// the Client.
+type VlanRange struct {
+	// Ranges lists the VLANs as comma separated IDs and ranges, e.g.
+	// "10-20,30,100-199".
+	Ranges string `json:"ranges"`
+	// Name of the VLANs. A "%d" is replaced by the VLAN ID. If empty Create
+	// names the VLANs "VLAN" followed by their ID as done by the switch and
+	// Update leaves their names alone, as it does with an empty Description
+	// and AdminState.
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	AdminState  string `json:"admin_state"`
+	Concurrency int    `json:"concurrency"`
+	// DeleteMode selects how Delete handles interfaces using the VLANs, see
+	// Vlan.Delete. With VlanDeleteCascade all interfaces are detached from
+	// the VLANs of the range before any is deleted.
+	DeleteMode VlanDeleteMode `json:"delete_mode"`
+	// Results holds the outcome per VLAN of the last operation, ordered by
+	// VLAN ID.
+	Results []VlanResult `json:"results"`
+}
+
+// VlanResult is the outcome of a VlanRange operation for one VLAN, Err is
+// nil on success.
+type VlanResult struct {
+	VlanId int   `json:"id"`
+	Err    error `json:"-"`
+}
+
+// Create performs POST to create every VLAN of the range on the given Client
+// object. The returned error joins the errors of all failed VLANs, see
+// Results for the outcome per VLAN.
+func (r *VlanRange) Create(ctx context.Context, c *Client) error {
+	return r.each(ctx, "VlanRange.Create", func(v *Vlan) error {
+		return v.Create(ctx, c)
+	})
+}
+
+// Update performs PATCH to update every VLAN of the range on the given
+// Client object. Only the Name, Description and AdminState that are set are
+// sent, the other attributes of the VLANs are left alone. The returned error
+// joins the errors of all failed VLANs, see Results for the outcome per VLAN.
+func (r *VlanRange) Update(ctx context.Context, c *Client) error {
+	if r.Name == "" && r.Description == "" && r.AdminState == "" {
+		return newValidationError("VlanRange.Update", "Name", "nothing to update, set Name, Description or AdminState")
+	}
+
+	return r.each(ctx, "VlanRange.Update", func(v *Vlan) error {
+		patchMap := map[string]interface{}{}
+		if r.Name != "" {
+			patchMap["name"] = v.Name
+		}
+		if r.Description != "" {
+			patchMap["description"] = r.Description
+		}
+		if r.AdminState != "" {
+			patchMap["admin"] = r.AdminState
+		}
+		return patchVlan(ctx, c, "VlanRange.Update", v.VlanId, patchMap)
+	})
+}
+
+// Delete performs DELETE to remove every VLAN of the range from the given
+// Client object. The interfaces are retrieved once for the whole range, a
+// VLAN with a VlanInterface or, unless DeleteMode is VlanDeleteCascade,
+// referenced by interfaces fails with a *VlanInUseError as with Vlan.Delete.
+// With VlanDeleteCascade all interfaces are detached from the VLANs of the
+// range before any is deleted, except that VLANs of a trunk allowing no VLAN
+// outside the range are kept and listed in Trunks of their error. The returned error joins the errors of all
+// failed VLANs, see Results for the outcome per VLAN.
+func (r *VlanRange) Delete(ctx context.Context, c *Client) error {
+	vlan_ids, err := ParseVlanRange(r.Ranges)
+	if err != nil {
+		return newValidationError("VlanRange.Delete", "Ranges", err.Error())
+	}
+
+	interfaces, err := getInterfaceVlans(ctx, c, "VlanRange.Delete")
+	if err != nil {
+		return err
+	}
+
+	in_use := map[int]*VlanInUseError{}
+	detach := map[string]*VlanInUseError{}
+	var detach_strs []string
+	for _, vlan_id := range vlan_ids {
+		vlan_str := strconv.Itoa(vlan_id)
+		vlan_in_use := &VlanInUseError{VlanId: vlan_id}
+
+		for _, i := range interfaces {
+			if i.name == "vlan"+vlan_str {
+				vlan_in_use.VlanInterface = i.name
+			}
+			if i.references(vlan_str) {
+				vlan_in_use.Interfaces = append(vlan_in_use.Interfaces, i.name)
+			}
+		}
+		sort.Strings(vlan_in_use.Interfaces)
+
+		if vlan_in_use.VlanInterface != "" || (len(vlan_in_use.Interfaces) > 0 && r.DeleteMode != VlanDeleteCascade) {
+			in_use[vlan_id] = vlan_in_use
+		} else if len(vlan_in_use.Interfaces) > 0 {
+			detach[vlan_str] = vlan_in_use
+			detach_strs = append(detach_strs, vlan_str)
+		}
+	}
+
+	// Trunks allowing only VLANs of the range are not emptied, their VLANs
+	// are kept. Dropping VLANs from detach_strs cannot empty another trunk.
+	for _, i := range interfaces {
+		if !i.onlyAllows(detach_strs) {
+			continue
+		}
+		for _, trunk := range i.trunks {
+			vlan_in_use := detach[trunk]
+			vlan_in_use.Trunks = append(vlan_in_use.Trunks, i.name)
+			in_use[vlan_in_use.VlanId] = vlan_in_use
+		}
+		var remaining_strs []string
+		for _, vlan_str := range detach_strs {
+			if !slices.Contains(i.trunks, vlan_str) {
+				remaining_strs = append(remaining_strs, vlan_str)
+			}
+		}
+		detach_strs = remaining_strs
+	}
+	for _, vlan_in_use := range in_use {
+		sort.Strings(vlan_in_use.Trunks)
+	}
+
+	// Detach once for the whole range so concurrent deletes do not race on
+	// the trunks of the same interface
+	if len(detach_strs) > 0 {
+		for _, i := range interfaces {
+			err = i.detach(ctx, c, "VlanRange.Delete", detach_strs)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return r.each(ctx, "VlanRange.Delete", func(v *Vlan) error {
+		if vlan_in_use, ok := in_use[v.VlanId]; ok {
+			return vlan_in_use
+		}
+		return deleteVlan(ctx, c, "VlanRange.Delete", v.VlanId)
+	})
+}
+
+// each runs op for every VLAN of the range with bounded concurrency and
+// records the results.
+func (r *VlanRange) each(ctx context.Context, op_name string, op func(v *Vlan) error) error {
+	vlan_ids, err := ParseVlanRange(r.Ranges)
+	if err != nil {
+		return newValidationError(op_name, "Ranges", err.Error())
+	}
+
+	concurrency := r.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultVlanRangeConcurrency
+	}
+
+	results := make([]VlanResult, len(vlan_ids))
+	slots := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	for index, vlan_id := range vlan_ids {
+		results[index].VlanId = vlan_id
+
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			results[index].Err = ctx.Err()
+			continue
+		}
+
+		wg.Add(1)
+		go func(index int, vlan_id int) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			v := r.vlan(vlan_id)
+			results[index].Err = op(&v)
+		}(index, vlan_id)
+	}
+	wg.Wait()
+
+	r.Results = results
+
+	var errs []error
+	for _, result := range results {
+		if result.Err != nil {
+			errs = append(errs, fmt.Errorf("%s: VLAN %d: %w", op_name, result.VlanId, result.Err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// vlan returns the Vlan with the given ID configured from the range.
+func (r *VlanRange) vlan(vlan_id int) Vlan {
+	name := "VLAN" + strconv.Itoa(vlan_id)
+	if strings.Contains(r.Name, "%d") {
+		name = fmt.Sprintf(r.Name, vlan_id)
+	} else if r.Name != "" {
+		name = r.Name
+	}
+
+	return Vlan{
+		VlanId:      vlan_id,
+		Name:        name,
+		Description: r.Description,
+		AdminState:  r.AdminState,
+	}
+}
+
+// ParseVlanRange returns the sorted VLAN IDs of comma separated IDs and
+// ranges, e.g. "10-20,30". IDs must be between 1 and 4094, duplicates are
+// removed.
+func ParseVlanRange(ranges string) ([]int, error) {
+	seen := map[int]bool{}
+	vlan_ids := []int{}
+
+	for _, part := range strings.Split(ranges, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		first_str, last_str, is_range := strings.Cut(part, "-")
+		first, err := parseVlanId(first_str)
+		if err != nil {
+			return nil, err
+		}
+		last := first
+		if is_range {
+			last, err = parseVlanId(last_str)
+			if err != nil {
+				return nil, err
+			}
+			if last < first {
+				return nil, fmt.Errorf("invalid VLAN range %s", part)
+			}
+		}
+
+		for vlan_id := first; vlan_id <= last; vlan_id++ {
+			if !seen[vlan_id] {
+				seen[vlan_id] = true
+				vlan_ids = append(vlan_ids, vlan_id)
+			}
+		}
+	}
+
+	if len(vlan_ids) == 0 {
+		return nil, fmt.Errorf("no VLANs in range %q", ranges)
+	}
+
+	sort.Ints(vlan_ids)
+	return vlan_ids, nil
+}
+
+// parseVlanId parses a VLAN ID between 1 and 4094.
+func parseVlanId(vlan_str string) (int, error) {
+	vlan_id, err := strconv.Atoi(strings.TrimSpace(vlan_str))
+	if err != nil || vlan_id < 1 || vlan_id > 4094 {
+		return 0, fmt.Errorf("invalid VLAN ID %q, valid IDs are 1-4094", vlan_str)
+	}
+	return vlan_id, nil
+}
+
+// FormatVlanRange returns VLAN IDs as comma separated IDs and ranges, e.g.
+// "10-20,30".
+func FormatVlanRange(vlan_ids []int) string {
+	sorted := append([]int(nil), vlan_ids...)
+	sort.Ints(sorted)
+
+	var parts []string
+	for index := 0; index < len(sorted); {
+		first := sorted[index]
+		last := first
+		for index++; index < len(sorted) && sorted[index] <= last+1; index++ {
+			last = sorted[index]
+		}
+
+		if first == last {
+			parts = append(parts, strconv.Itoa(first))
+		} else {
+			parts = append(parts, strconv.Itoa(first)+"-"+strconv.Itoa(last))
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// VlanList is a list of VLAN IDs. It is written as ranges by String and
+// decoded from JSON either as an array of IDs or a range string such as
+// "10-20,30".
+type VlanList []int
+
+// ParseVlanList parses comma separated VLAN IDs and ranges, e.g. "10-20,30".
+// An empty string is an empty list.
+func ParseVlanList(ranges string) (VlanList, error) {
+	if strings.TrimSpace(ranges) == "" {
+		return VlanList{}, nil
+	}
+	vlan_ids, err := ParseVlanRange(ranges)
+	if err != nil {
+		return nil, err
+	}
+	return VlanList(vlan_ids), nil
+}
+
+// String returns the list as ranges, e.g. "10-20,30".
+func (l VlanList) String() string {
+	return FormatVlanRange(l)
+}
+
+// Contains returns true if the list holds the VLAN.
+func (l VlanList) Contains(vlan_id int) bool {
+	return slices.Contains(l, vlan_id)
+}
+
+// UnmarshalJSON decodes an array of VLAN IDs or a range string.
+func (l *VlanList) UnmarshalJSON(data []byte) error {
+	var ranges string
+	if json.Unmarshal(data, &ranges) == nil {
+		vlan_ids, err := ParseVlanList(ranges)
+		if err != nil {
+			return err
+		}
+		*l = vlan_ids
+		return nil
+	}
+
+	var vlan_ids []int
+	err := json.Unmarshal(data, &vlan_ids)
+	if err != nil {
+		return fmt.Errorf("VLAN list must be an array of IDs or a range string: %w", err)
+	}
+	*l = vlan_ids
+	return nil
+}
@@ -0,0 +1,170 @@
+package aoscxgo_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aruba/aoscxgo"
+)
+
+func TestVlanRangeCreateUpdate(t *testing.T) {
+	srv, c := connect(t)
+	ctx := context.Background()
+
+	r := aoscxgo.VlanRange{Ranges: "10-12,20", Name: "leaf-%d", Concurrency: 2}
+	err := r.Create(ctx, c)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if len(r.Results) != 4 || r.Results[3].VlanId != 20 || r.Results[3].Err != nil {
+		t.Errorf("Results = %+v", r.Results)
+	}
+	obj, ok := srv.Object("system/vlans", "11")
+	if !ok || obj["name"] != "leaf-11" {
+		t.Errorf("VLAN 11 = %v", obj)
+	}
+
+	srv.SetObject("system/vlans", "12", map[string]interface{}{
+		"id":    12,
+		"name":  "phones",
+		"type":  "static",
+		"voice": true,
+	})
+
+	// Only the fields set are sent
+	r = aoscxgo.VlanRange{Ranges: "10-12", Description: "leaf"}
+	err = r.Update(ctx, c)
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	obj, _ = srv.Object("system/vlans", "12")
+	if obj["name"] != "phones" || obj["description"] != "leaf" || obj["voice"] != true {
+		t.Errorf("after Update VLAN 12 = %v", obj)
+	}
+
+	r = aoscxgo.VlanRange{Ranges: "10-12"}
+	err = r.Update(ctx, c)
+	if !errors.Is(err, aoscxgo.ErrValidation) {
+		t.Errorf("Update without fields = %v, want ErrValidation", err)
+	}
+}
+
+func TestVlanRangeDelete(t *testing.T) {
+	srv, c := connect(t)
+	ctx := context.Background()
+
+	r := aoscxgo.VlanRange{Ranges: "10-14"}
+	err := r.Create(ctx, c)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	vlan_uri := "/rest/" + c.Version + "/system/vlans/"
+	srv.SetObject("system/interfaces", "1/1/1", map[string]interface{}{
+		"name":      "1/1/1",
+		"type":      "system",
+		"vlan_mode": "access",
+		"vlan_tag":  map[string]interface{}{"10": vlan_uri + "10"},
+	})
+	srv.SetObject("system/interfaces", "vlan11", map[string]interface{}{
+		"name": "vlan11",
+		"type": "vlan",
+	})
+
+	var mu sync.Mutex
+	interface_gets := 0
+	c.RequestHook = func(ctx context.Context, info *aoscxgo.RequestInfo) {
+		if info.Method == "GET" && strings.Contains(info.URL, "/system/interfaces") {
+			mu.Lock()
+			interface_gets++
+			mu.Unlock()
+		}
+	}
+
+	err = r.Delete(ctx, c)
+	var in_use *aoscxgo.VlanInUseError
+	if !errors.As(err, &in_use) {
+		t.Fatalf("Delete = %v, want VlanInUseError", err)
+	}
+	if interface_gets != 1 {
+		t.Errorf("Delete retrieved the interfaces %d times, want once", interface_gets)
+	}
+	if got := srv.Objects("system/vlans"); strings.Join(got, ",") != "1,10,11" {
+		t.Errorf("VLANs after Delete = %v", got)
+	}
+	for _, result := range r.Results {
+		in_use_err := errors.Is(result.Err, aoscxgo.ErrConflict)
+		if in_use_err != (result.VlanId == 10 || result.VlanId == 11) {
+			t.Errorf("VLAN %d: %v", result.VlanId, result.Err)
+		}
+	}
+
+	// The access interface is detached, the VlanInterface still blocks
+	r = aoscxgo.VlanRange{Ranges: "10-11", DeleteMode: aoscxgo.VlanDeleteCascade}
+	err = r.Delete(ctx, c)
+	if !errors.Is(err, aoscxgo.ErrConflict) {
+		t.Fatalf("Delete = %v, want ErrConflict", err)
+	}
+	if got := srv.Objects("system/vlans"); strings.Join(got, ",") != "1,11" {
+		t.Errorf("VLANs after cascading Delete = %v", got)
+	}
+	obj, _ := srv.Object("system/interfaces", "1/1/1")
+	if tag, _ := obj["vlan_tag"].(map[string]interface{}); tag["1"] == nil {
+		t.Errorf("access interface was not moved to VLAN 1: %v", obj["vlan_tag"])
+	}
+}
+
+func TestVlanRangeDeleteCascadeTrunks(t *testing.T) {
+	srv, c := connect(t)
+	ctx := context.Background()
+
+	r := aoscxgo.VlanRange{Ranges: "10-12"}
+	err := r.Create(ctx, c)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	vlan_uri := "/rest/" + c.Version + "/system/vlans/"
+	srv.SetObject("system/interfaces", "1/1/1", map[string]interface{}{
+		"name":        "1/1/1",
+		"type":        "system",
+		"vlan_mode":   "trunk",
+		"vlan_trunks": map[string]interface{}{"10": vlan_uri + "10", "11": vlan_uri + "11"},
+	})
+	srv.SetObject("system/interfaces", "1/1/2", map[string]interface{}{
+		"name":        "1/1/2",
+		"type":        "system",
+		"vlan_mode":   "trunk",
+		"vlan_trunks": map[string]interface{}{"1": vlan_uri + "1", "11": vlan_uri + "11", "12": vlan_uri + "12"},
+	})
+
+	// 1/1/1 allows only VLANs of the range so they are kept, 12 is removed
+	r = aoscxgo.VlanRange{Ranges: "10-12", DeleteMode: aoscxgo.VlanDeleteCascade}
+	err = r.Delete(ctx, c)
+	if !errors.Is(err, aoscxgo.ErrConflict) {
+		t.Fatalf("Delete = %v, want ErrConflict", err)
+	}
+	if got := srv.Objects("system/vlans"); strings.Join(got, ",") != "1,10,11" {
+		t.Errorf("VLANs after Delete = %v", got)
+	}
+	for _, result := range r.Results {
+		var in_use *aoscxgo.VlanInUseError
+		if errors.As(result.Err, &in_use) != (result.VlanId != 12) {
+			t.Errorf("VLAN %d: %v", result.VlanId, result.Err)
+			continue
+		}
+		if in_use != nil && strings.Join(in_use.Trunks, ",") != "1/1/1" {
+			t.Errorf("VLAN %d: Trunks = %v, want 1/1/1", result.VlanId, in_use.Trunks)
+		}
+	}
+
+	obj, _ := srv.Object("system/interfaces", "1/1/1")
+	if trunks, _ := obj["vlan_trunks"].(map[string]interface{}); len(trunks) != 2 {
+		t.Errorf("1/1/1 trunk VLANs = %v, want 10 and 11", obj["vlan_trunks"])
+	}
+	obj, _ = srv.Object("system/interfaces", "1/1/2")
+	if trunks, _ := obj["vlan_trunks"].(map[string]interface{}); len(trunks) != 2 || trunks["12"] != nil {
+		t.Errorf("1/1/2 trunk VLANs = %v, want 1 and 11", obj["vlan_trunks"])
+	}
+}
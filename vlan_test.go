@@ -0,0 +1,296 @@
+package aoscxgo_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aruba/aoscxgo"
+)
+
+func TestVlanCRUD(t *testing.T) {
+	srv, c := connect(t)
+	ctx := context.Background()
+
+	vlan := aoscxgo.Vlan{VlanId: 10, Name: "users", Description: "user access", AdminState: "up"}
+	err := vlan.Create(ctx, c)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if !vlan.GetStatus() {
+		t.Error("GetStatus = false after Create")
+	}
+	if vlan.GetURI() != "/rest/"+c.Version+"/system/vlans/10" {
+		t.Errorf("GetURI = %q", vlan.GetURI())
+	}
+
+	got := aoscxgo.Vlan{VlanId: 10}
+	err = got.Get(ctx, c)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.Name != "users" || got.Description != "user access" || got.AdminState != "up" || got.Type != "static" {
+		t.Errorf("Get = %+v", got)
+	}
+
+	vlan.Name = "staff"
+	vlan.AdminState = "down"
+	err = vlan.Update(ctx, c)
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	obj, _ := srv.Object("system/vlans", "10")
+	if obj["name"] != "staff" || obj["admin"] != "down" {
+		t.Errorf("after Update the switch holds %v", obj)
+	}
+
+	vlans, err := aoscxgo.ListVlans(ctx, c)
+	if err != nil {
+		t.Fatalf("ListVlans: %v", err)
+	}
+	if len(vlans) != 2 || vlans[0].VlanId != 1 || vlans[1].VlanId != 10 || vlans[1].Name != "staff" {
+		t.Errorf("ListVlans = %+v", vlans)
+	}
+
+	err = vlan.Delete(ctx, c)
+	if err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, ok := srv.Object("system/vlans", "10"); ok {
+		t.Error("VLAN 10 exists after Delete")
+	}
+
+	err = got.Get(ctx, c)
+	if !errors.Is(err, aoscxgo.ErrNotFound) {
+		t.Errorf("Get after Delete = %v, want ErrNotFound", err)
+	}
+	if got.GetStatus() {
+		t.Error("GetStatus = true after Delete")
+	}
+}
+
+func TestVlanCreateErrors(t *testing.T) {
+	_, c := connect(t)
+	ctx := context.Background()
+
+	vlan := aoscxgo.Vlan{VlanId: 10}
+	err := vlan.Create(ctx, c)
+	if !errors.Is(err, aoscxgo.ErrValidation) {
+		t.Errorf("Create without Name = %v, want ErrValidation", err)
+	}
+
+	vlan = aoscxgo.Vlan{VlanId: 1, Name: "default"}
+	err = vlan.Create(ctx, c)
+	if !errors.Is(err, aoscxgo.ErrConflict) {
+		t.Errorf("Create of existing VLAN = %v, want ErrConflict", err)
+	}
+}
+
+func TestVlanDeleteInUse(t *testing.T) {
+	srv, c := connect(t)
+	ctx := context.Background()
+
+	vlan := aoscxgo.Vlan{VlanId: 10, Name: "users"}
+	err := vlan.Create(ctx, c)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	srv.SetObject("system/interfaces", "1/1/1", map[string]interface{}{
+		"name":      "1/1/1",
+		"type":      "system",
+		"vlan_mode": "access",
+		"vlan_tag":  map[string]interface{}{"10": "/rest/" + c.Version + "/system/vlans/10"},
+	})
+
+	err = vlan.Delete(ctx, c)
+	var in_use *aoscxgo.VlanInUseError
+	if !errors.As(err, &in_use) || !errors.Is(err, aoscxgo.ErrConflict) {
+		t.Fatalf("Delete of VLAN in use = %v, want VlanInUseError", err)
+	}
+	if len(in_use.Interfaces) != 1 || in_use.Interfaces[0] != "1/1/1" {
+		t.Errorf("Interfaces = %v", in_use.Interfaces)
+	}
+
+	vlan.DeleteMode = aoscxgo.VlanDeleteCascade
+	err = vlan.Delete(ctx, c)
+	if err != nil {
+		t.Fatalf("Delete with VlanDeleteCascade: %v", err)
+	}
+	obj, _ := srv.Object("system/interfaces", "1/1/1")
+	if tag, _ := obj["vlan_tag"].(map[string]interface{}); tag["1"] == nil {
+		t.Errorf("access interface was not moved to VLAN 1: %v", obj["vlan_tag"])
+	}
+}
+
+func TestVlanUpdateFlags(t *testing.T) {
+	srv, c := connect(t)
+	ctx := context.Background()
+	srv.SetObject("system/vlans", "20", map[string]interface{}{
+		"id":          20,
+		"name":        "phones",
+		"type":        "static",
+		"voice":       true,
+		"mgmd_enable": map[string]interface{}{"igmp": true, "mld": false},
+	})
+
+	// Flags not set on the Vlan are left alone
+	vlan := aoscxgo.Vlan{VlanId: 20, Name: "voice", Description: "handsets"}
+	err := vlan.Update(ctx, c)
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	obj, _ := srv.Object("system/vlans", "20")
+	mgmd, _ := obj["mgmd_enable"].(map[string]interface{})
+	if obj["name"] != "voice" || obj["voice"] != true || mgmd["igmp"] != true {
+		t.Errorf("after Update the switch holds %v", obj)
+	}
+
+	// A retrieved Vlan clears them
+	vlan = aoscxgo.Vlan{VlanId: 20}
+	err = vlan.Get(ctx, c)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if !vlan.Voice || !vlan.Snooping.Igmp {
+		t.Fatalf("Get = %+v", vlan)
+	}
+	vlan.Voice = false
+	vlan.Snooping.Igmp = false
+	err = vlan.Update(ctx, c)
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	obj, _ = srv.Object("system/vlans", "20")
+	mgmd, _ = obj["mgmd_enable"].(map[string]interface{})
+	if obj["voice"] != false || mgmd["igmp"] != false {
+		t.Errorf("after Update the switch holds %v", obj)
+	}
+}
+
+func TestVlanDeleteVlanInterface(t *testing.T) {
+	srv, c := connect(t)
+	ctx := context.Background()
+
+	vlan := aoscxgo.Vlan{VlanId: 10, Name: "users", DeleteMode: aoscxgo.VlanDeleteCascade}
+	err := vlan.Create(ctx, c)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	srv.SetObject("system/interfaces", "vlan10", map[string]interface{}{
+		"name": "vlan10",
+		"type": "vlan",
+	})
+
+	err = vlan.Delete(ctx, c)
+	var in_use *aoscxgo.VlanInUseError
+	if !errors.As(err, &in_use) || in_use.VlanInterface != "vlan10" {
+		t.Fatalf("Delete = %v, want VlanInUseError for vlan10", err)
+	}
+	if !strings.Contains(err.Error(), "delete VlanInterface first") {
+		t.Errorf("Error = %q", err.Error())
+	}
+	if _, ok := srv.Object("system/vlans", "10"); !ok {
+		t.Error("VLAN 10 was deleted")
+	}
+}
+
+func TestVlanDeleteTrunk(t *testing.T) {
+	srv, c := connect(t)
+	ctx := context.Background()
+
+	for _, vlan_id := range []int{10, 20} {
+		vlan := aoscxgo.Vlan{VlanId: vlan_id, Name: "vlan"}
+		err := vlan.Create(ctx, c)
+		if err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+	}
+	vlan_uri := "/rest/" + c.Version + "/system/vlans/"
+	srv.SetObject("system/interfaces", "1/1/1", map[string]interface{}{
+		"name":        "1/1/1",
+		"type":        "system",
+		"vlan_mode":   "native-untagged",
+		"vlan_tag":    map[string]interface{}{"10": vlan_uri + "10"},
+		"vlan_trunks": map[string]interface{}{"10": vlan_uri + "10", "20": vlan_uri + "20"},
+	})
+	// Trunks allowing all VLANs do not reference them
+	srv.SetObject("system/interfaces", "1/1/2", map[string]interface{}{
+		"name":      "1/1/2",
+		"type":      "system",
+		"vlan_mode": "native-untagged",
+	})
+
+	vlan := aoscxgo.Vlan{VlanId: 10}
+	err := vlan.GetMembers(ctx, c)
+	if err != nil {
+		t.Fatalf("GetMembers: %v", err)
+	}
+	members := vlan.Members
+	if len(members.Native) != 1 || len(members.Trunk) != 2 || len(members.Access) != 0 {
+		t.Errorf("Members = %+v", members)
+	}
+
+	err = vlan.Delete(ctx, c)
+	var in_use *aoscxgo.VlanInUseError
+	if !errors.As(err, &in_use) || len(in_use.Interfaces) != 1 || in_use.Interfaces[0] != "1/1/1" {
+		t.Fatalf("Delete = %v, want VlanInUseError for 1/1/1", err)
+	}
+
+	vlan.DeleteMode = aoscxgo.VlanDeleteCascade
+	err = vlan.Delete(ctx, c)
+	if err != nil {
+		t.Fatalf("Delete with VlanDeleteCascade: %v", err)
+	}
+	obj, _ := srv.Object("system/interfaces", "1/1/1")
+	trunks, _ := obj["vlan_trunks"].(map[string]interface{})
+	if obj["vlan_tag"] != nil || len(trunks) != 1 || trunks["20"] == nil {
+		t.Errorf("trunk after cascading Delete = %v", obj)
+	}
+}
+
+func TestVlanDeleteCascadeLastTrunkVlan(t *testing.T) {
+	srv, c := connect(t)
+	ctx := context.Background()
+
+	vlan := aoscxgo.Vlan{VlanId: 10, Name: "users", DeleteMode: aoscxgo.VlanDeleteCascade}
+	err := vlan.Create(ctx, c)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	vlan_uri := "/rest/" + c.Version + "/system/vlans/10"
+	srv.SetObject("system/interfaces", "1/1/1", map[string]interface{}{
+		"name":      "1/1/1",
+		"type":      "system",
+		"vlan_mode": "access",
+		"vlan_tag":  map[string]interface{}{"10": vlan_uri},
+	})
+	srv.SetObject("system/interfaces", "1/1/2", map[string]interface{}{
+		"name":        "1/1/2",
+		"type":        "system",
+		"vlan_mode":   "native-untagged",
+		"vlan_tag":    map[string]interface{}{"1": "/rest/" + c.Version + "/system/vlans/1"},
+		"vlan_trunks": map[string]interface{}{"10": vlan_uri},
+	})
+
+	// Emptying the trunk would allow all VLANs on it, nothing is detached
+	err = vlan.Delete(ctx, c)
+	var in_use *aoscxgo.VlanInUseError
+	if !errors.As(err, &in_use) || !errors.Is(err, aoscxgo.ErrConflict) {
+		t.Fatalf("Delete = %v, want VlanInUseError", err)
+	}
+	if len(in_use.Trunks) != 1 || in_use.Trunks[0] != "1/1/2" {
+		t.Errorf("Trunks = %v, want 1/1/2", in_use.Trunks)
+	}
+	if _, ok := srv.Object("system/vlans", "10"); !ok {
+		t.Error("VLAN 10 was deleted")
+	}
+	obj, _ := srv.Object("system/interfaces", "1/1/1")
+	if tag, _ := obj["vlan_tag"].(map[string]interface{}); tag["10"] == nil {
+		t.Errorf("access interface was detached: %v", obj["vlan_tag"])
+	}
+	obj, _ = srv.Object("system/interfaces", "1/1/2")
+	if trunks, _ := obj["vlan_trunks"].(map[string]interface{}); len(trunks) != 1 || trunks["10"] == nil {
+		t.Errorf("trunk VLANs = %v, want 10", obj["vlan_trunks"])
+	}
+}
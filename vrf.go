@@ -0,0 +1,302 @@
+package aoscxgo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+)
+
+// Vrf is a VRF on the switch. The VRFs "default" and "mgmt" always exist.
+type Vrf struct {
+
+	// Connection properties.
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// Rd is the route distinguisher, e.g. "65000:1" or "10.0.0.1:1".
+	Rd string `json:"rd"`
+	// ImportTargets and ExportTargets are the route targets imported and
+	// exported by the VRF, in the format of Rd.
+	ImportTargets []string `json:"import_targets"`
+	ExportTargets []string `json:"export_targets"`
+
+	VrfDetails   map[string]interface{} `json:"details"`
+	materialized bool
+	uri          string
+}
+
+// checkRouteDistinguisher validates if a route distinguisher or route
+// target is valid or not
+func checkRouteDistinguisher(rd string) bool {
+	found, err := regexp.MatchString("^(\\d+|\\d+\\.\\d+\\.\\d+\\.\\d+):\\d+$", rd)
+	return found && err == nil
+}
+
+// checkValues validates the VRF settings, op names the calling operation in
+// the returned error.
+func (v *Vrf) checkValues(op string) error {
+	if v.Name == "" {
+		return newValidationError(op, "Name", "missing required value Name")
+	}
+	if v.Rd != "" && !checkRouteDistinguisher(v.Rd) {
+		return newValidationError(op, "Rd", "expected ASN:nn or IP:nn received: "+v.Rd)
+	}
+	for _, target := range append(append([]string(nil), v.ImportTargets...), v.ExportTargets...) {
+		if !checkRouteDistinguisher(target) {
+			return newValidationError(op, "RouteTargets", "expected ASN:nn or IP:nn received: "+target)
+		}
+	}
+	return nil
+}
+
+// vrfMap returns the attributes of the VRF to send to the switch.
+func (v *Vrf) vrfMap() map[string]interface{} {
+	import_targets := []string{}
+	if v.ImportTargets != nil {
+		import_targets = v.ImportTargets
+	}
+	export_targets := []string{}
+	if v.ExportTargets != nil {
+		export_targets = v.ExportTargets
+	}
+
+	vrfMap := map[string]interface{}{
+		"description":          v.Description,
+		"import_route_targets": import_targets,
+		"export_route_targets": export_targets,
+	}
+	if v.Rd != "" {
+		vrfMap["rd"] = v.Rd
+	} else {
+		vrfMap["rd"] = nil
+	}
+	return vrfMap
+}
+
+// Create performs POST to create VRF configuration on the given Client object.
+func (v *Vrf) Create(ctx context.Context, c *Client) error {
+	base_uri := "system/vrfs"
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri
+
+	err := v.checkValues("Vrf.Create")
+	if err != nil {
+		return err
+	}
+	v.uri = vrfURI(c, v.Name)
+
+	postMap := v.vrfMap()
+	postMap["name"] = v.Name
+
+	postBody, _ := json.Marshal(postMap)
+
+	json_body := bytes.NewBuffer(postBody)
+
+	res, err := post(ctx, c, url, json_body)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusCreated {
+		return newRequestError("Vrf.Create", res)
+	}
+
+	v.materialized = true
+
+	return nil
+}
+
+// Update performs PATCH to update VRF configuration on the given Client object.
+func (v *Vrf) Update(ctx context.Context, c *Client) error {
+	base_uri := "system/vrfs"
+
+	err := v.checkValues("Vrf.Update")
+	if err != nil {
+		return err
+	}
+
+	vrf_str := url.PathEscape(v.Name)
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri + "/" + vrf_str
+
+	patchBody, _ := json.Marshal(v.vrfMap())
+
+	json_body := bytes.NewBuffer(patchBody)
+
+	res, err := patch(ctx, c, url, json_body)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusNoContent {
+		return newRequestError("Vrf.Update", res)
+	}
+
+	return nil
+}
+
+// Delete performs DELETE to remove VRF configuration from the given Client
+// object. The "default" and "mgmt" VRFs cannot be deleted.
+func (v *Vrf) Delete(ctx context.Context, c *Client) error {
+	base_uri := "system/vrfs"
+
+	if v.Name == "default" || v.Name == "mgmt" {
+		return newValidationError("Vrf.Delete", "Name", "VRF "+v.Name+" cannot be deleted")
+	}
+
+	vrf_str := url.PathEscape(v.Name)
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri + "/" + vrf_str
+
+	res, err := delete(ctx, c, url)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusNoContent && res.StatusCode != http.StatusOK {
+		return newRequestError("Vrf.Delete", res)
+	}
+
+	v.materialized = false
+
+	return nil
+}
+
+// Get performs GET to retrieve VRF configuration from the given Client object.
+func (v *Vrf) Get(ctx context.Context, c *Client) error {
+	base_uri := "system/vrfs"
+	if v.Name == "" {
+		return newValidationError("Vrf.Get", "Name", "missing required value Name")
+	}
+	v.uri = vrfURI(c, v.Name)
+
+	vrf_str := url.PathEscape(v.Name)
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri + "/" + vrf_str
+
+	res, body, err := get(ctx, c, url)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		v.materialized = false
+		return newRequestError("Vrf.Get", res)
+	}
+
+	payload := vrfPayload{}
+	err = json.NewDecoder(res.Body).Decode(&payload)
+	if err != nil {
+		return fmt.Errorf("Vrf.Get: decoding VRF %s: %w", v.Name, err)
+	}
+	payload.decode(v)
+
+	if v.VrfDetails == nil {
+		v.VrfDetails = map[string]interface{}{}
+	}
+	for key, value := range body {
+		v.VrfDetails[key] = value
+	}
+
+	v.materialized = true
+
+	return nil
+}
+
+// GetStatus returns True if VRF exists on Client object or False if not.
+func (v *Vrf) GetStatus() bool {
+	return v.materialized
+}
+
+// GetURI returns URI of VRF.
+func (v *Vrf) GetURI() string {
+	return v.uri
+}
+
+// vrfPayload is the VRF as returned by the switch.
+type vrfPayload struct {
+	Description        *string  `json:"description"`
+	Rd                 *string  `json:"rd"`
+	ImportRouteTargets []string `json:"import_route_targets"`
+	ExportRouteTargets []string `json:"export_route_targets"`
+}
+
+// decode sets the fields of v from the payload.
+func (p *vrfPayload) decode(v *Vrf) {
+	v.Description = ""
+	if p.Description != nil {
+		v.Description = *p.Description
+	}
+	v.Rd = ""
+	if p.Rd != nil {
+		v.Rd = *p.Rd
+	}
+	v.ImportTargets = p.ImportRouteTargets
+	v.ExportTargets = p.ExportRouteTargets
+}
+
+// ListVrfs performs GET to retrieve all VRFs from the given Client object in
+// a single request, sorted by name.
+func ListVrfs(ctx context.Context, c *Client) ([]Vrf, error) {
+	base_uri := "system/vrfs"
+	url_str := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri +
+		"?depth=2&attributes=name,description,rd,import_route_targets,export_route_targets"
+
+	res, _, err := get(ctx, c, url_str)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, newRequestError("ListVrfs", res)
+	}
+
+	payloads := map[string]vrfPayload{}
+	err = json.NewDecoder(res.Body).Decode(&payloads)
+	if err != nil {
+		return nil, fmt.Errorf("ListVrfs: decoding VRFs: %w", err)
+	}
+
+	vrfs := []Vrf{}
+	for name, payload := range payloads {
+		v := Vrf{
+			Name:         name,
+			materialized: true,
+			uri:          vrfURI(c, name),
+		}
+		payload.decode(&v)
+		vrfs = append(vrfs, v)
+	}
+
+	sort.Slice(vrfs, func(a, b int) bool {
+		return vrfs[a].Name < vrfs[b].Name
+	})
+
+	return vrfs, nil
+}
+
+// vrfURI returns the URI of the named VRF, of the "default" VRF if name is
+// empty.
+func vrfURI(c *Client, name string) string {
+	if name == "" {
+		name = "default"
+	}
+	return "/rest/" + c.Version + "/system/vrfs/" + url.PathEscape(name)
+}
+
+// checkVrfExists performs GET to verify the VRF an interface is placed in
+// exists, so a missing VRF is reported as such rather than rejected by the
+// switch. The "default" VRF always exists and is not checked.
+func checkVrfExists(ctx context.Context, c *Client, op string, name string) error {
+	if name == "" || name == "default" {
+		return nil
+	}
+
+	tmp_vrf := Vrf{Name: name}
+	err := tmp_vrf.Get(ctx, c)
+	if errors.Is(err, ErrNotFound) {
+		return newDependencyError(op, "missing VRF "+name+" - Create Vrf first", err)
+	}
+	return err
+}
@@ -0,0 +1,101 @@
+package aoscxgo_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aruba/aoscxgo"
+)
+
+func TestVrfCRUD(t *testing.T) {
+	srv, c := connect(t)
+	ctx := context.Background()
+
+	vrf := aoscxgo.Vrf{
+		Name:          "tenant/a",
+		Description:   "tenant A",
+		Rd:            "65000:1",
+		ImportTargets: []string{"65000:1"},
+		ExportTargets: []string{"65000:1"},
+	}
+	err := vrf.Create(ctx, c)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if vrf.GetURI() != "/rest/"+c.Version+"/system/vrfs/tenant%2Fa" {
+		t.Errorf("GetURI = %q", vrf.GetURI())
+	}
+
+	got := aoscxgo.Vrf{Name: "tenant/a"}
+	err = got.Get(ctx, c)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.Description != "tenant A" || got.Rd != "65000:1" || len(got.ImportTargets) != 1 {
+		t.Errorf("Get = %+v", got)
+	}
+
+	vrf.Rd = ""
+	err = vrf.Update(ctx, c)
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	obj, _ := srv.Object("system/vrfs", "tenant/a")
+	if obj["rd"] != nil {
+		t.Errorf("after Update the switch holds %v", obj)
+	}
+
+	vrfs, err := aoscxgo.ListVrfs(ctx, c)
+	if err != nil {
+		t.Fatalf("ListVrfs: %v", err)
+	}
+	if len(vrfs) != 3 || vrfs[2].Name != "tenant/a" || vrfs[2].GetURI() != vrf.GetURI() {
+		t.Errorf("ListVrfs = %+v", vrfs)
+	}
+
+	err = vrf.Delete(ctx, c)
+	if err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, ok := srv.Object("system/vrfs", "tenant/a"); ok {
+		t.Error("VRF exists after Delete")
+	}
+
+	mgmt := aoscxgo.Vrf{Name: "mgmt"}
+	err = mgmt.Delete(ctx, c)
+	if !errors.Is(err, aoscxgo.ErrValidation) {
+		t.Errorf("Delete of mgmt = %v, want ErrValidation", err)
+	}
+}
+
+func TestVrfMissing(t *testing.T) {
+	srv, c := connect(t)
+	ctx := context.Background()
+	srv.AddInterface("1/1/1")
+
+	l3 := aoscxgo.L3Interface{
+		Interface: aoscxgo.Interface{Name: "1/1/1", AdminState: "up"},
+		Ipv4:      []interface{}{"10.0.0.1/24"},
+		Vrf:       "blue",
+	}
+	err := l3.Create(ctx, c)
+	var dependency *aoscxgo.DependencyError
+	if !errors.As(err, &dependency) || !errors.Is(err, aoscxgo.ErrNotFound) || !errors.Is(err, aoscxgo.ErrValidation) {
+		t.Fatalf("Create in missing VRF = %v, want DependencyError", err)
+	}
+
+	vrf := aoscxgo.Vrf{Name: "blue"}
+	err = vrf.Create(ctx, c)
+	if err != nil {
+		t.Fatalf("Vrf.Create: %v", err)
+	}
+	err = l3.Create(ctx, c)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	obj, _ := srv.Object("system/interfaces", "1/1/1")
+	if obj["vrf"] != "/rest/"+c.Version+"/system/vrfs/blue" {
+		t.Errorf("1/1/1 is in VRF %v", obj["vrf"])
+	}
+}
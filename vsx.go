@@ -0,0 +1,416 @@
+package aoscxgo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"golang.org/x/exp/slices"
+)
+
+// VsxSystem is the VSX configuration of a switch, pairing it with a peer
+// over an inter-switch link (ISL) LAG. There is one per switch.
+type VsxSystem struct {
+
+	// Connection properties.
+	// Role is "primary" or "secondary".
+	Role string `json:"role"`
+	// IslLag is the name of the LAG linking the peers, e.g. "lag256".
+	IslLag string `json:"isl_lag"`
+	// KeepalivePeer and KeepaliveSource are the IPv4 addresses of the peer
+	// and of this switch used for keepalive.
+	KeepalivePeer   string `json:"keepalive_peer"`
+	KeepaliveSource string `json:"keepalive_source"`
+	// KeepaliveVrf is the VRF of the keepalive, defaults to "default".
+	KeepaliveVrf string `json:"keepalive_vrf"`
+	// SystemMac is the MAC address shared by the pair, e.g.
+	// "02:01:00:00:01:00".
+	SystemMac string `json:"system_mac"`
+	// ConfigSyncDisable stops synchronizing configuration to the peer.
+	ConfigSyncDisable bool `json:"config_sync_disable"`
+	// ConfigSyncFeatures lists the features whose global configuration is
+	// synchronized to the peer, e.g. "acl", "qos" or "static-routes".
+	ConfigSyncFeatures []string `json:"config_sync_features"`
+	// LinkupDelay is the delay in seconds before multi-chassis LAGs are
+	// brought up after the ISL comes up, the switch default if zero.
+	LinkupDelay int `json:"linkup_delay"`
+
+	VsxDetails   map[string]interface{} `json:"details"`
+	materialized bool
+	uri          string
+}
+
+// checkValues validates the VSX settings, op names the calling operation in
+// the returned error.
+func (v *VsxSystem) checkValues(op string) error {
+	if v.Role != "primary" && v.Role != "secondary" {
+		return newValidationError(op, "Role", "valid options are 'primary' or 'secondary' received: "+v.Role)
+	}
+	if !checkLagName(v.IslLag) {
+		return newValidationError(op, "IslLag", "invalid LAG name, expected lagN received: "+v.IslLag)
+	}
+	for field, address := range map[string]string{"KeepalivePeer": v.KeepalivePeer, "KeepaliveSource": v.KeepaliveSource} {
+		if address != "" && net.ParseIP(address).To4() == nil {
+			return newValidationError(op, field, "invalid IPv4 address: "+address)
+		}
+	}
+	if (v.KeepalivePeer == "") != (v.KeepaliveSource == "") {
+		return newValidationError(op, "KeepalivePeer", "keepalive requires both peer and source address")
+	}
+	if v.SystemMac != "" {
+		if _, err := net.ParseMAC(v.SystemMac); err != nil {
+			return newValidationError(op, "SystemMac", "invalid MAC address: "+v.SystemMac)
+		}
+	}
+	if v.LinkupDelay < 0 || v.LinkupDelay > 600 {
+		return newValidationError(op, "LinkupDelay", "valid range is 0-600 received: "+strconv.Itoa(v.LinkupDelay))
+	}
+	return nil
+}
+
+// vsxMap returns the attributes of the VSX configuration to send to the
+// switch.
+func (v *VsxSystem) vsxMap(c *Client) map[string]interface{} {
+	features := []string{}
+	if v.ConfigSyncFeatures != nil {
+		features = v.ConfigSyncFeatures
+	}
+
+	vsxMap := map[string]interface{}{
+		"device_role":          v.Role,
+		"isl_port":             "/rest/" + c.Version + "/system/interfaces/" + v.IslLag,
+		"keepalive_vrf":        vrfURI(c, v.KeepaliveVrf),
+		"config_sync_disable":  v.ConfigSyncDisable,
+		"config_sync_features": features,
+	}
+	if v.KeepalivePeer != "" {
+		vsxMap["keepalive_peer_ip"] = v.KeepalivePeer
+		vsxMap["keepalive_src_ip"] = v.KeepaliveSource
+	}
+	if v.SystemMac != "" {
+		vsxMap["system_mac"] = strings.ToLower(v.SystemMac)
+	}
+	if v.LinkupDelay > 0 {
+		vsxMap["linkup_delay_timer"] = v.LinkupDelay
+	}
+	return vsxMap
+}
+
+// Create performs POST to create VSX configuration on the given Client object.
+func (v *VsxSystem) Create(ctx context.Context, c *Client) error {
+	base_uri := "system/vsx"
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri
+	v.uri = "/rest/" + c.Version + "/" + base_uri
+
+	err := v.checkValues("VsxSystem.Create")
+	if err != nil {
+		return err
+	}
+
+	postBody, _ := json.Marshal(v.vsxMap(c))
+
+	json_body := bytes.NewBuffer(postBody)
+
+	res, err := post(ctx, c, url, json_body)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusCreated {
+		return newRequestError("VsxSystem.Create", res)
+	}
+
+	v.materialized = true
+
+	return nil
+}
+
+// Update performs PUT to replace VSX configuration on the given Client object.
+func (v *VsxSystem) Update(ctx context.Context, c *Client) error {
+	base_uri := "system/vsx"
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri
+
+	err := v.checkValues("VsxSystem.Update")
+	if err != nil {
+		return err
+	}
+
+	putBody, _ := json.Marshal(v.vsxMap(c))
+
+	json_body := bytes.NewBuffer(putBody)
+
+	res, err := put(ctx, c, url, json_body)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return newRequestError("VsxSystem.Update", res)
+	}
+
+	return nil
+}
+
+// Delete performs DELETE to remove VSX configuration from the given Client
+// object.
+func (v *VsxSystem) Delete(ctx context.Context, c *Client) error {
+	base_uri := "system/vsx"
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri
+
+	res, err := delete(ctx, c, url)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusNoContent && res.StatusCode != http.StatusOK {
+		return newRequestError("VsxSystem.Delete", res)
+	}
+
+	v.materialized = false
+
+	return nil
+}
+
+// Get performs GET to retrieve VSX configuration from the given Client object.
+func (v *VsxSystem) Get(ctx context.Context, c *Client) error {
+	base_uri := "system/vsx"
+	url := "https://" + c.Hostname + "/rest/" + c.Version + "/" + base_uri + "?selector=writable"
+	v.uri = "/rest/" + c.Version + "/" + base_uri
+
+	res, body, err := get(ctx, c, url)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		v.materialized = false
+		return newRequestError("VsxSystem.Get", res)
+	}
+
+	payload := struct {
+		DeviceRole         string          `json:"device_role"`
+		IslPort            json.RawMessage `json:"isl_port"`
+		KeepalivePeerIp    string          `json:"keepalive_peer_ip"`
+		KeepaliveSrcIp     string          `json:"keepalive_src_ip"`
+		KeepaliveVrf       json.RawMessage `json:"keepalive_vrf"`
+		SystemMac          string          `json:"system_mac"`
+		ConfigSyncDisable  bool            `json:"config_sync_disable"`
+		ConfigSyncFeatures []string        `json:"config_sync_features"`
+		LinkupDelayTimer   int             `json:"linkup_delay_timer"`
+	}{}
+	err = json.NewDecoder(res.Body).Decode(&payload)
+	if err != nil {
+		return fmt.Errorf("VsxSystem.Get: decoding: %w", err)
+	}
+
+	v.Role = payload.DeviceRole
+	v.IslLag = ""
+	if keys := referenceKeys(payload.IslPort); len(keys) > 0 {
+		v.IslLag = keys[0]
+	}
+	v.KeepalivePeer = payload.KeepalivePeerIp
+	v.KeepaliveSource = payload.KeepaliveSrcIp
+	v.KeepaliveVrf = ""
+	if keys := referenceKeys(payload.KeepaliveVrf); len(keys) > 0 {
+		v.KeepaliveVrf = keys[0]
+	}
+	v.SystemMac = payload.SystemMac
+	v.ConfigSyncDisable = payload.ConfigSyncDisable
+	v.ConfigSyncFeatures = payload.ConfigSyncFeatures
+	v.LinkupDelay = payload.LinkupDelayTimer
+
+	if v.VsxDetails == nil {
+		v.VsxDetails = map[string]interface{}{}
+	}
+	for key, value := range body {
+		v.VsxDetails[key] = value
+	}
+
+	v.materialized = true
+
+	return nil
+}
+
+// GetStatus returns True if VSX is configured on Client object or False if not.
+func (v *VsxSystem) GetStatus() bool {
+	return v.materialized
+}
+
+// GetURI returns URI of VSX configuration.
+func (v *VsxSystem) GetURI() string {
+	return v.uri
+}
+
+// VsxMismatchError is returned by ValidateVsxPair when the peers of a VSX
+// pair are not configured consistently. It matches ErrValidation.
+type VsxMismatchError struct {
+	// Peers are the hostnames of the two switches.
+	Peers [2]string
+	// Mismatches describes each inconsistency found.
+	Mismatches []string
+}
+
+// Error lists the inconsistencies.
+func (e *VsxMismatchError) Error() string {
+	return fmt.Sprintf("ValidateVsxPair: %s and %s are inconsistent: %s",
+		e.Peers[0], e.Peers[1], strings.Join(e.Mismatches, "; "))
+}
+
+// Is matches ErrValidation.
+func (e *VsxMismatchError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+// ValidateVsxPair checks that the two switches of a VSX pair are configured
+// consistently: one primary and one secondary with the same system MAC,
+// keepalive VRF, config-sync options and linkup delay, keepalive addresses
+// pointing at each other, the same VLANs, and the same multi-chassis LAGs
+// with identical LACP and VLAN settings. Members of the LAGs may differ.
+// A *VsxMismatchError listing all inconsistencies is returned if any are
+// found.
+func ValidateVsxPair(ctx context.Context, c1 *Client, c2 *Client) error {
+	op := "ValidateVsxPair"
+	clients := [2]*Client{c1, c2}
+	mismatch := &VsxMismatchError{Peers: [2]string{c1.Hostname, c2.Hostname}}
+
+	var vsx [2]VsxSystem
+	var vlans [2]map[int]string
+	var lags [2]map[string]Lag
+	var interface_vlans [2]map[string]interfaceVlans
+
+	for index, c := range clients {
+		err := vsx[index].Get(ctx, c)
+		if err != nil {
+			return fmt.Errorf("%s: VSX of %s: %w", op, c.Hostname, err)
+		}
+
+		vlan_list, err := ListVlans(ctx, c)
+		if err != nil {
+			return fmt.Errorf("%s: VLANs of %s: %w", op, c.Hostname, err)
+		}
+		vlans[index] = map[int]string{}
+		for _, vlan := range vlan_list {
+			vlans[index][vlan.VlanId] = vlan.Name
+		}
+
+		lag_list, err := getLags(ctx, c, op)
+		if err != nil {
+			return err
+		}
+		lags[index] = map[string]Lag{}
+		for _, lag := range lag_list {
+			if lag.MultiChassis {
+				lags[index][lag.Name] = lag
+			}
+		}
+
+		interface_list, err := getInterfaceVlans(ctx, c, op)
+		if err != nil {
+			return err
+		}
+		interface_vlans[index] = map[string]interfaceVlans{}
+		for _, i := range interface_list {
+			interface_vlans[index][i.name] = i
+		}
+	}
+
+	differ := func(what string, value1 interface{}, value2 interface{}) {
+		mismatch.Mismatches = append(mismatch.Mismatches,
+			fmt.Sprintf("%s is %v on %s but %v on %s", what, value1, c1.Hostname, value2, c2.Hostname))
+	}
+
+	roles := []string{vsx[0].Role, vsx[1].Role}
+	slices.Sort(roles)
+	if roles[0] != "primary" || roles[1] != "secondary" {
+		differ("role", vsx[0].Role, vsx[1].Role)
+	}
+	if !strings.EqualFold(vsx[0].SystemMac, vsx[1].SystemMac) {
+		differ("system MAC", vsx[0].SystemMac, vsx[1].SystemMac)
+	}
+	if vsx[0].KeepaliveVrf != vsx[1].KeepaliveVrf {
+		differ("keepalive VRF", vsx[0].KeepaliveVrf, vsx[1].KeepaliveVrf)
+	}
+	if vsx[0].KeepalivePeer != vsx[1].KeepaliveSource || vsx[1].KeepalivePeer != vsx[0].KeepaliveSource {
+		differ("keepalive peer/source",
+			vsx[0].KeepalivePeer+"/"+vsx[0].KeepaliveSource, vsx[1].KeepalivePeer+"/"+vsx[1].KeepaliveSource)
+	}
+	if vsx[0].ConfigSyncDisable != vsx[1].ConfigSyncDisable {
+		differ("config-sync disable", vsx[0].ConfigSyncDisable, vsx[1].ConfigSyncDisable)
+	}
+	features := [2][]string{
+		append([]string(nil), vsx[0].ConfigSyncFeatures...),
+		append([]string(nil), vsx[1].ConfigSyncFeatures...),
+	}
+	slices.Sort(features[0])
+	slices.Sort(features[1])
+	if !slices.Equal(features[0], features[1]) {
+		differ("config-sync features", features[0], features[1])
+	}
+	if vsx[0].LinkupDelay != vsx[1].LinkupDelay {
+		differ("linkup delay", vsx[0].LinkupDelay, vsx[1].LinkupDelay)
+	}
+
+	var missing [2][]int
+	for vlan_id, name := range vlans[0] {
+		other_name, ok := vlans[1][vlan_id]
+		if !ok {
+			missing[1] = append(missing[1], vlan_id)
+		} else if name != other_name {
+			differ(fmt.Sprintf("name of VLAN %d", vlan_id), name, other_name)
+		}
+	}
+	for vlan_id := range vlans[1] {
+		if _, ok := vlans[0][vlan_id]; !ok {
+			missing[0] = append(missing[0], vlan_id)
+		}
+	}
+	for index, vlan_ids := range missing {
+		if len(vlan_ids) > 0 {
+			mismatch.Mismatches = append(mismatch.Mismatches,
+				fmt.Sprintf("VLANs %s are missing on %s", FormatVlanRange(vlan_ids), clients[index].Hostname))
+		}
+	}
+
+	for name, lag := range lags[0] {
+		other, ok := lags[1][name]
+		if !ok {
+			mismatch.Mismatches = append(mismatch.Mismatches,
+				fmt.Sprintf("multi-chassis %s is missing on %s", name, c2.Hostname))
+			continue
+		}
+		settings := func(l Lag) string {
+			return fmt.Sprintf("lacp %s rate %s hash %s min-links %d fallback %t",
+				l.LacpMode, l.LacpRate, l.Hash, l.MinLinks, l.Fallback)
+		}
+		if settings(lag) != settings(other) {
+			differ("multi-chassis "+name, settings(lag), settings(other))
+		}
+
+		vlan_settings := func(i interfaceVlans) string {
+			trunks := append([]string(nil), i.trunks...)
+			slices.Sort(trunks)
+			return fmt.Sprintf("mode %s tag %s trunks [%s]", i.mode, i.tag, strings.Join(trunks, ","))
+		}
+		if vlan_settings(interface_vlans[0][name]) != vlan_settings(interface_vlans[1][name]) {
+			differ("VLANs of multi-chassis "+name,
+				vlan_settings(interface_vlans[0][name]), vlan_settings(interface_vlans[1][name]))
+		}
+	}
+	for name := range lags[1] {
+		if _, ok := lags[0][name]; !ok {
+			mismatch.Mismatches = append(mismatch.Mismatches,
+				fmt.Sprintf("multi-chassis %s is missing on %s", name, c1.Hostname))
+		}
+	}
+
+	if len(mismatch.Mismatches) > 0 {
+		slices.Sort(mismatch.Mismatches)
+		return mismatch
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package aoscxgo_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aruba/aoscxgo"
+)
+
+// vsxPeer configures one switch of a VSX pair with the ISL lag256 and the
+// multi-chassis lag1.
+func vsxPeer(t *testing.T, c *aoscxgo.Client, role string, peer string, source string) {
+	t.Helper()
+	ctx := context.Background()
+
+	for _, lag := range []aoscxgo.Lag{
+		{Name: "lag256", AdminState: "up"},
+		{Name: "lag1", AdminState: "up", MultiChassis: true, LacpRate: "fast"},
+	} {
+		err := lag.Create(ctx, c)
+		if err != nil {
+			t.Fatalf("Lag.Create: %v", err)
+		}
+	}
+
+	vsx := aoscxgo.VsxSystem{
+		Role:               role,
+		IslLag:             "lag256",
+		KeepalivePeer:      peer,
+		KeepaliveSource:    source,
+		SystemMac:          "02:01:00:00:01:00",
+		ConfigSyncFeatures: []string{"vsx-global", "acl"},
+	}
+	err := vsx.Create(ctx, c)
+	if err != nil {
+		t.Fatalf("VsxSystem.Create: %v", err)
+	}
+}
+
+func TestVsxSystem(t *testing.T) {
+	_, c := connect(t)
+	ctx := context.Background()
+	vsxPeer(t, c, "primary", "192.168.0.2", "192.168.0.1")
+
+	got := aoscxgo.VsxSystem{}
+	err := got.Get(ctx, c)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.Role != "primary" || got.IslLag != "lag256" || got.KeepalivePeer != "192.168.0.2" ||
+		got.KeepaliveVrf != "default" || got.SystemMac != "02:01:00:00:01:00" || len(got.ConfigSyncFeatures) != 2 {
+		t.Errorf("Get = %+v", got)
+	}
+
+	got.LinkupDelay = 300
+	err = got.Update(ctx, c)
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	invalid := aoscxgo.VsxSystem{Role: "primary", IslLag: "lag256", KeepalivePeer: "192.168.0.2"}
+	err = invalid.Update(ctx, c)
+	if !errors.Is(err, aoscxgo.ErrValidation) {
+		t.Errorf("Update without keepalive source = %v, want ErrValidation", err)
+	}
+
+	err = got.Delete(ctx, c)
+	if err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	err = got.Get(ctx, c)
+	if !errors.Is(err, aoscxgo.ErrNotFound) {
+		t.Errorf("Get after Delete = %v, want ErrNotFound", err)
+	}
+}
+
+func TestValidateVsxPair(t *testing.T) {
+	_, c1 := connect(t)
+	_, c2 := connect(t)
+	ctx := context.Background()
+	vsxPeer(t, c1, "primary", "192.168.0.2", "192.168.0.1")
+	vsxPeer(t, c2, "secondary", "192.168.0.1", "192.168.0.2")
+
+	err := aoscxgo.ValidateVsxPair(ctx, c1, c2)
+	if err != nil {
+		t.Fatalf("ValidateVsxPair: %v", err)
+	}
+
+	vlan := aoscxgo.Vlan{VlanId: 10, Name: "users"}
+	err = vlan.Create(ctx, c1)
+	if err != nil {
+		t.Fatalf("Vlan.Create: %v", err)
+	}
+	lag := aoscxgo.Lag{Name: "lag1", LacpRate: "slow"}
+	err = lag.Update(ctx, c2)
+	if err != nil {
+		t.Fatalf("Lag.Update: %v", err)
+	}
+
+	err = aoscxgo.ValidateVsxPair(ctx, c1, c2)
+	var mismatch *aoscxgo.VsxMismatchError
+	if !errors.As(err, &mismatch) || !errors.Is(err, aoscxgo.ErrValidation) {
+		t.Fatalf("ValidateVsxPair = %v, want VsxMismatchError", err)
+	}
+	mismatches := strings.Join(mismatch.Mismatches, "\n")
+	if len(mismatch.Mismatches) != 2 || !strings.Contains(mismatches, "VLANs 10 are missing") ||
+		!strings.Contains(mismatches, "multi-chassis lag1") {
+		t.Errorf("Mismatches = %q", mismatch.Mismatches)
+	}
+}